@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"ats/internal/backtest"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/optimize"
+	"ats/internal/report"
+	"ats/internal/strategy"
+)
+
+// barSource holds the flags shared by the offline commands: bars come from
+// a CSV file or, without one, from the synthetic generator.
+type barSource struct {
+	path       string
+	symbol     string
+	model      string
+	seed       int64
+	bars       int
+	startPrice float64
+	volatility float64
+	drift      float64
+	pairPath   string
+	pairRatio  float64
+}
+
+func (s *barSource) register(fs *flag.FlagSet) {
+	fs.StringVar(&s.path, "bars", "", "CSV of 1m bars (timestamp,open,high,low,close,volume); default: synthetic")
+	fs.StringVar(&s.symbol, "symbol", "SPY", "symbol name for the bars")
+	fs.StringVar(&s.model, "synthetic-model", md.ModelGBM, "synthetic price model when --bars is not set")
+	fs.Int64Var(&s.seed, "synthetic-seed", 1, "synthetic RNG seed")
+	fs.IntVar(&s.bars, "synthetic-bars", 390*5, "number of synthetic bars")
+	fs.Float64Var(&s.startPrice, "synthetic-start-price", 100, "synthetic starting price")
+	fs.Float64Var(&s.volatility, "synthetic-volatility", 0.3, "synthetic annualized volatility")
+	fs.Float64Var(&s.drift, "synthetic-drift", 0, "synthetic annualized drift")
+	fs.StringVar(&s.pairPath, "pair-bars", "", "CSV of 1m bars for --pair-symbol; default: synthetic, cointegrated with --bars")
+	fs.Float64Var(&s.pairRatio, "synthetic-pair-ratio", 2, "price of the primary symbol over the synthetic pair symbol")
+}
+
+func (s *barSource) load() ([]md.Bar, error) {
+	if s.path != "" {
+		return md.LoadBarsCSV(s.path, s.symbol)
+	}
+	gen, err := md.NewSyntheticGenerator(md.SyntheticConfig{
+		Model:      s.model,
+		Seed:       s.seed,
+		StartPrice: s.startPrice,
+		Volatility: s.volatility,
+		Drift:      s.drift,
+	}, s.symbol)
+	if err != nil {
+		return nil, err
+	}
+	bars := make([]md.Bar, s.bars)
+	for i := range bars {
+		bars[i] = gen.Next()
+	}
+	return bars, nil
+}
+
+// loadPair returns the bars of the pair symbol, nil when there is none.
+func (s *barSource) loadPair(symbol string, bars []md.Bar) ([]md.Bar, error) {
+	if symbol == "" {
+		return nil, nil
+	}
+	if s.pairPath != "" {
+		return md.LoadBarsCSV(s.pairPath, symbol)
+	}
+	gen := md.NewPairedGenerator(symbol, s.pairRatio, s.seed+1)
+	pairBars := make([]md.Bar, len(bars))
+	for i, bar := range bars {
+		pairBars[i] = gen.Next(bar)
+	}
+	return pairBars, nil
+}
+
+// engineFlags are the config fields a backtest honours, on top of defaults.
+func engineFlags(fs *flag.FlagSet) *config.Config {
+	cfg := config.Default()
+	fs.StringVar(&cfg.Strategy, "strategy", "sma", "strategy: "+strings.Join(strategy.Names(), ", "))
+	fs.StringVar(&cfg.RulesPath, "rules", cfg.RulesPath, "strategy=rules: path to a JSON or YAML rules file")
+	fs.StringVar(&cfg.PairSymbol, "pair-symbol", cfg.PairSymbol, "strategy=pairs: hedge symbol traded against --symbol")
+	fs.IntVar(&cfg.SMAWindow, "sma-window", cfg.SMAWindow, "SMA window length")
+	fs.IntVar(&cfg.MaxQty, "max-qty", cfg.MaxQty, "max position size")
+	fs.Float64Var(&cfg.MaxNotional, "max-notional", cfg.MaxNotional, "max notional per order")
+	fs.BoolVar(&cfg.AllowShort, "allow-short", cfg.AllowShort, "allow short positions")
+	fs.IntVar(&cfg.MaxShortQty, "max-short-qty", cfg.MaxShortQty, "max short position size (0 = max-qty)")
+	fs.Float64Var(&cfg.MaxShortNotional, "max-short-notional", cfg.MaxShortNotional, "max notional per short sale (0 = max-notional)")
+	fs.StringVar(&cfg.Timeframe, "timeframe", cfg.Timeframe, "bar timeframe the strategy evaluates")
+	fs.StringVar(&cfg.OrderType, "order-type", cfg.OrderType, "order type: market or limit")
+	fs.BoolVar(&cfg.Fractional, "fractional", cfg.Fractional, "size entries in fractional shares")
+	fs.BoolVar(&cfg.NotionalOrders, "notional-orders", cfg.NotionalOrders, "submit buys as dollar amounts (market orders only; implies fractional)")
+	fs.StringVar(&cfg.LimitPricing, "limit-pricing", cfg.LimitPricing, "limit price source: last, join, mid, cross")
+	fs.Float64Var(&cfg.LimitOffset, "limit-offset", cfg.LimitOffset, "limit price offset")
+	fs.StringVar(&cfg.Sizing, "sizing", cfg.Sizing, "position sizing: strategy, fixed_qty, notional, percent_equity, volatility, kelly")
+	fs.IntVar(&cfg.SizingQty, "sizing-qty", cfg.SizingQty, "shares per entry for sizing=fixed_qty (0 = max-qty)")
+	fs.Float64Var(&cfg.SizingNotional, "sizing-notional", cfg.SizingNotional, "dollars per entry for sizing=notional (0 = max-notional)")
+	fs.Float64Var(&cfg.SizingEquityPct, "sizing-equity-pct", cfg.SizingEquityPct, "fraction of equity per entry for sizing=percent_equity")
+	fs.Float64Var(&cfg.SizingRiskPct, "sizing-risk-pct", cfg.SizingRiskPct, "fraction of equity one ATR move may cost for sizing=volatility")
+	fs.IntVar(&cfg.SizingATRPeriod, "sizing-atr-period", cfg.SizingATRPeriod, "ATR period for sizing=volatility")
+	fs.Float64Var(&cfg.KellyFraction, "kelly-fraction", cfg.KellyFraction, "fraction of the full Kelly bet for sizing=kelly")
+	fs.IntVar(&cfg.KellyMinTrades, "kelly-min-trades", cfg.KellyMinTrades, "closed trades before sizing=kelly replaces fixed_qty")
+	fs.StringVar(&cfg.Ensemble.Vote, "ensemble-vote", cfg.Ensemble.Vote, "strategy=ensemble voting: unanimous, majority, weighted, first_non_hold, veto")
+	fs.Float64Var(&cfg.Ensemble.Threshold, "ensemble-threshold", cfg.Ensemble.Threshold, "share of total weight an action needs with ensemble-vote=weighted")
+	fs.Func("ensemble-members", "strategy=ensemble members with optional weights, e.g. sma,mean_reversion*2", func(value string) error {
+		members, err := config.ParseEnsembleMembers(value)
+		if err != nil {
+			return err
+		}
+		cfg.Ensemble.Members = members
+		return nil
+	})
+	return &cfg
+}
+
+// offlineContext quiets per-bar engine logs and cancels on SIGINT/SIGTERM.
+func offlineContext() (context.Context, context.CancelFunc) {
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level:       slog.LevelWarn,
+		ReplaceAttr: dropTimeAttr,
+	})))
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	var source barSource
+	source.register(fs)
+	cfg := engineFlags(fs)
+	params := fs.String("params", "", "strategy parameters, e.g. \"band_pct=0.01;min_bars=20\"")
+	capital := fs.Float64("capital", 10000, "initial capital")
+	jsonPath := fs.String("json", "", "optional path to write the report as JSON")
+	htmlPath := fs.String("html", "", "optional path to write a self-contained HTML tearsheet")
+	var benchmark benchmarkFlags
+	benchmark.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	bars, err := source.load()
+	if err != nil {
+		return err
+	}
+	pairBars, err := source.loadPair(cfg.PairSymbol, bars)
+	if err != nil {
+		return err
+	}
+	strategyParams, err := parseStrategyParams(*params)
+	if err != nil {
+		return err
+	}
+	strat, err := strategy.Build(cfg.Strategy, *cfg, strategyParams)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := offlineContext()
+	defer stop()
+	result, err := backtest.Run(ctx, backtest.Options{Config: *cfg, Capital: *capital, PairBars: pairBars}, strat, bars)
+	if err != nil {
+		return err
+	}
+	if err := benchmark.apply(ctx, &result.Input, source.symbol); err != nil {
+		return err
+	}
+	result.Report = report.Compute(result.Input)
+
+	if err := report.WriteTable(os.Stdout, result.Report); err != nil {
+		return err
+	}
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, result.Report); err != nil {
+			return err
+		}
+	}
+	if *htmlPath != "" {
+		if err := writeHTML(*htmlPath, "Backtest: "+cfg.Strategy+" on "+source.symbol, result.Report, result.Input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseStrategyParams reads --params as a single value per parameter, e.g.
+// "band_pct=0.01;min_bars=20".
+func parseStrategyParams(spec string) (map[string]float64, error) {
+	params := map[string]float64{}
+	if spec == "" {
+		return params, nil
+	}
+	space, err := optimize.ParseSpace(spec)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range space {
+		if len(p.Values) != 1 {
+			return nil, fmt.Errorf("parameter %s: backtest takes a single value", p.Name)
+		}
+		params[p.Name] = p.Values[0]
+	}
+	return params, nil
+}
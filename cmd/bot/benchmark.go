@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"ats/internal/md"
+	"ats/internal/report"
+)
+
+// benchmarkFlags select what a report is compared against.
+type benchmarkFlags struct {
+	spec  string
+	cache string
+	feed  string
+}
+
+func (b *benchmarkFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&b.spec, "benchmark", "", "compare against: \"self\" (buy-and-hold of the traded symbol), a bars CSV path, or a symbol fetched via the bar cache")
+	fs.StringVar(&b.cache, "bar-cache", "bars-cache", "directory for cached historical bars")
+	fs.StringVar(&b.feed, "benchmark-feed", "iex", "historical data feed for benchmark symbols: iex or sip")
+}
+
+// apply attaches the benchmark series to input.
+func (b *benchmarkFlags) apply(ctx context.Context, input *report.Input, symbol string) error {
+	switch {
+	case b.spec == "":
+		return nil
+	case b.spec == "self":
+		input.Benchmark = report.BenchmarkFromEquity(input.Equity)
+		input.BenchmarkSymbol = symbol
+		return nil
+	case strings.HasSuffix(b.spec, ".csv"):
+		bars, err := md.LoadBarsCSV(b.spec, b.spec)
+		if err != nil {
+			return fmt.Errorf("load benchmark: %w", err)
+		}
+		input.Benchmark = pricePoints(bars)
+		input.BenchmarkSymbol = b.spec
+		return nil
+	}
+
+	if len(input.Equity) == 0 {
+		return nil
+	}
+	apiKey, apiSecret := os.Getenv("APCA_API_KEY_ID"), os.Getenv("APCA_API_SECRET_KEY")
+	var source md.HistoricalBars
+	if apiKey != "" && apiSecret != "" {
+		source = md.NewHistorical(apiKey, apiSecret, b.feed)
+	}
+	cache := md.NewBarCache(b.cache, source)
+	// Start a day early so the first equity point has a prior benchmark price.
+	start := input.Equity[0].Time.AddDate(0, 0, -1)
+	end := input.Equity[len(input.Equity)-1].Time
+	bars, err := cache.Bars(ctx, b.spec, start, end)
+	if err != nil {
+		return fmt.Errorf("load benchmark %s: %w", b.spec, err)
+	}
+	if len(bars) == 0 {
+		return fmt.Errorf("no %s bars between %s and %s", b.spec, start.Format(time.RFC3339), end.Format(time.RFC3339))
+	}
+	input.Benchmark = pricePoints(bars)
+	input.BenchmarkSymbol = b.spec
+	return nil
+}
+
+func pricePoints(bars []md.Bar) []report.PricePoint {
+	points := make([]report.PricePoint, 0, len(bars))
+	for _, bar := range bars {
+		points = append(points, report.PricePoint{Time: time.Unix(bar.Timestamp, 0).UTC(), Price: bar.Close})
+	}
+	return points
+}
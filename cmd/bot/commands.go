@@ -0,0 +1,12 @@
+package main
+
+// commands are subcommands selected by the first argument; anything else
+// (including flags) runs the trading bot.
+var commands = map[string]func(args []string) error{
+	"report":      runReport,
+	"backtest":    runBacktest,
+	"optimize":    runOptimize,
+	"walkforward": runWalkForward,
+	"montecarlo":  runMonteCarlo,
+	"strategies":  runStrategies,
+}
@@ -4,18 +4,19 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
-	"fmt"
 	"log/slog"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strings"
 	"syscall"
 	"time"
 
 	"ats/internal/broker"
+	"ats/internal/clock"
 	"ats/internal/config"
 	"ats/internal/engine"
-	"ats/internal/llm"
-	"ats/internal/llm/ollama"
+	"ats/internal/event"
 	"ats/internal/md"
 	"ats/internal/risk"
 	"ats/internal/state"
@@ -56,12 +57,33 @@ func dropTimeAttr(groups []string, a slog.Attr) slog.Attr {
 func main() {
 	setupLogger()
 
+	if len(os.Args) > 1 {
+		if command, ok := commands[os.Args[1]]; ok {
+			if err := command(os.Args[2:]); err != nil {
+				slog.Error("command failed", "command", os.Args[1], "error", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		slog.Error("config error", "error", err)
 		os.Exit(1)
 	}
 
+	slog.Info("initializing strategy", "strategy", cfg.Strategy, "params", cfg.StrategyParams, "max_qty", cfg.MaxQty)
+	strategyImpl, err := strategy.Build(cfg.Strategy, cfg, nil)
+	if err != nil {
+		slog.Error("strategy error", "error", err)
+		os.Exit(1)
+	}
+	if cfg.TrendTimeframe != "" {
+		slog.Info("applying trend filter", "timeframe", cfg.TrendTimeframe)
+		strategyImpl = strategy.TrendFilter{Inner: strategyImpl, TrendTimeframe: md.Timeframe(cfg.TrendTimeframe)}
+	}
+
 	runID := generateRunID()
 	slog.Info("generated run_id", "run_id", runID)
 
@@ -78,7 +100,16 @@ func main() {
 		}
 	}()
 
-	store := state.NewStore()
+	// Synthetic bars arrive faster than real time, so the engine follows
+	// bar time instead of the wall clock.
+	var clk clock.Clock = clock.Real{}
+	var simulated *clock.Simulated
+	if cfg.Feed == config.FeedSynthetic {
+		simulated = clock.NewSimulated()
+		clk = simulated
+	}
+
+	store := state.NewStore(clk)
 	if err := store.Load(cfg.CheckpointPath); err == nil {
 		slog.Info("checkpoint loaded", "path", cfg.CheckpointPath)
 	} else {
@@ -88,17 +119,36 @@ func main() {
 	slog.Info("initializing broker client", "base_url", cfg.PaperBaseURL)
 	brokerClient := broker.New(cfg.APIKey, cfg.APISecret, cfg.PaperBaseURL)
 
-	strategyImpl, err := buildStrategy(cfg)
-	if err != nil {
-		slog.Error("strategy error", "error", err)
-		os.Exit(1)
-	}
-
 	slog.Info("initializing risk gate")
 	gate := risk.Gate{}
 
+	var quotes *md.QuoteBook
+	if cfg.Quotes {
+		quotes = md.NewQuoteBook()
+	}
+
+	calendar := md.NewCalendar(nil)
+	if cfg.Feed != config.FeedSynthetic {
+		calendar = loadCalendar(context.Background(), brokerClient)
+	}
+
+	bus := event.NewBus()
+	if simulated != nil {
+		bus.Subscribe(event.KindBar, func(ctx context.Context, ev event.Event) {
+			simulated.Observe(ev.Time().Add(md.BarInterval))
+		})
+	}
+	eventCounts := event.NewCounter(bus)
+
 	slog.Info("creating trading engine")
-	engineImpl := engine.New(cfg, strategyImpl, gate, brokerClient, store, decisions)
+	engineImpl := engine.New(cfg, strategyImpl, gate, brokerClient, store, decisions, quotes, calendar, clk)
+	engineImpl.Subscribe(bus)
+	hedgeStore := engineImpl.HedgeStore()
+	if hedgeStore != nil {
+		if err := hedgeStore.Load(pairCheckpointPath(cfg)); err == nil {
+			slog.Info("pair checkpoint loaded", "path", pairCheckpointPath(cfg))
+		}
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -113,23 +163,72 @@ func main() {
 
 	if cfg.Mode == config.ModePaper {
 		slog.Info("starting reconciliation loop", "interval", cfg.ReconcileInterval)
-		go engine.ReconcileLoop(ctx, brokerClient, store, cfg.Symbol, cfg.ReconcileInterval)
+		engine.SubscribeReconciler(bus, brokerClient, store, cfg.Symbol)
+		if hedgeStore != nil {
+			engine.SubscribeReconciler(bus, brokerClient, hedgeStore, cfg.PairSymbol)
+		}
+		go event.RunTimer(ctx, bus, clk, engine.ReconcileTimer, cfg.ReconcileInterval)
+	}
+	if simulated == nil {
+		// Completes a session's last higher-timeframe bar shortly after the
+		// close even when its final minute does not trade.
+		go event.RunTimer(ctx, bus, clk, engine.BarFlushTimer, md.BarInterval)
+	}
+	bus.Start(ctx)
+	engineImpl.Start(ctx)
+	if cfg.Mode == config.ModePaper {
+		symbols := []string{cfg.Symbol}
+		if hedgeStore != nil {
+			symbols = append(symbols, cfg.PairSymbol)
+		}
+		slog.Info("streaming trade updates", "symbols", symbols)
+		go func() {
+			if err := engine.RunTradeUpdates(ctx, bus, brokerClient, symbols...); err != nil && err != context.Canceled {
+				slog.Error("trade updates stream stopped", "error", err)
+			}
+		}()
 	}
 
 	slog.Info("bot starting", "mode", cfg.Mode, "symbol", cfg.Symbol, "feed", cfg.Feed, "run_id", runID)
-	slog.Info("connecting to market data", "feed", cfg.Feed, "symbol", cfg.Symbol)
-	if err := md.StartStream(ctx, cfg.APIKey, cfg.APISecret, cfg.Feed, cfg.Symbol, func(bar md.Bar) {
-		engineImpl.OnBar(ctx, bar)
-	}); err != nil && err != context.Canceled {
+	handler := func(bar md.Bar) {
+		bus.Publish(ctx, event.BarEvent{Bar: bar})
+	}
+	var streamOpts []md.StreamOption
+	if quotes != nil {
+		streamOpts = append(streamOpts,
+			md.WithQuotes(func(q md.Quote) { bus.Publish(ctx, event.QuoteEvent{Quote: q}) }),
+			md.WithTrades(func(t md.Trade) { bus.Publish(ctx, event.TradeEvent{Trade: t}) }),
+		)
+	}
+	if cfg.Backfill && cfg.Feed != config.FeedSynthetic {
+		filler := md.NewGapFiller(calendar, md.NewHistorical(cfg.APIKey, cfg.APISecret, cfg.Feed), md.BarInterval, handler)
+		handler = func(bar md.Bar) {
+			filler.OnBar(ctx, bar)
+		}
+	}
+
+	slog.Info("connecting to market data", "feed", cfg.Feed, "symbol", cfg.Symbol, "pair_symbol", cfg.PairSymbol)
+	if err := startMarketData(ctx, cfg, calendar, handler, streamOpts); err != nil && err != context.Canceled {
 		slog.Info("market data stream stopped", "error", err)
 	} else {
 		slog.Info("market data stream ended normally")
 	}
+	bus.Close()
+	engineImpl.Close()
+	slog.Info("event totals", "counts", eventCounts.Counts())
 
+	if err := engineImpl.SaveStrategyState(); err != nil {
+		slog.Error("failed to save strategy state", "error", err)
+	}
 	slog.Info("saving checkpoint before shutdown")
 	if err := store.Save(cfg.CheckpointPath); err != nil {
 		slog.Error("failed to save checkpoint", "error", err)
 	}
+	if hedgeStore != nil {
+		if err := hedgeStore.Save(pairCheckpointPath(cfg)); err != nil {
+			slog.Error("failed to save pair checkpoint", "error", err)
+		}
+	}
 
 	slog.Info("bot shutdown complete")
 }
@@ -143,30 +242,46 @@ func generateRunID() string {
 	return timestamp + "-" + hex.EncodeToString(randomBytes)
 }
 
-func buildStrategy(cfg config.Config) (strategy.Strategy, error) {
-	switch cfg.Strategy {
-	case "random_noise":
-		slog.Info("initializing strategy", "type", "RandomNoise", "max_qty", cfg.MaxQty)
-		return strategy.NewRandomNoise(cfg.MaxQty), nil
-	case "mean_reversion":
-		slog.Info("initializing strategy", "type", "MeanReversion", "max_qty", cfg.MaxQty)
-		return strategy.NewMeanReversion(cfg.MaxQty), nil
-	case "sma":
-		slog.Info("initializing strategy", "type", "SMA", "max_qty", cfg.MaxQty)
-		return strategy.SMA{MaxQty: cfg.MaxQty}, nil
-	case "llm":
-		slog.Info("initializing strategy", "type", "LLM", "model", cfg.LLMModel)
-		provider := ollama.New(cfg.LLMBaseURL, cfg.LLMModel)
-		client := llm.New(provider)
-		return strategy.NewLLMStrategy(
-			client,
-			cfg.MaxQty,
-			cfg.LLMSystemPromptPath,
-			cfg.LLMDecisionPromptPath,
-			cfg.LLMTimeout,
-			cfg.LLMContextPrompt,
-		), nil
-	default:
-		return nil, fmt.Errorf("unknown strategy: %s", cfg.Strategy)
+// pairCheckpointPath is where the hedge leg's state is saved, next to the
+// checkpoint: checkpoint.json becomes checkpoint.PEP.json.
+func pairCheckpointPath(cfg config.Config) string {
+	ext := filepath.Ext(cfg.CheckpointPath)
+	return strings.TrimSuffix(cfg.CheckpointPath, ext) + "." + cfg.PairSymbol + ext
+}
+
+func startMarketData(ctx context.Context, cfg config.Config, calendar *md.Calendar, handler md.BarHandler, opts []md.StreamOption) error {
+	if cfg.Feed == config.FeedSynthetic {
+		return md.StartSynthetic(ctx, md.SyntheticConfig{
+			Model:      cfg.SyntheticModel,
+			Seed:       cfg.SyntheticSeed,
+			StartPrice: cfg.SyntheticStartPrice,
+			Volatility: cfg.SyntheticVolatility,
+			Drift:      cfg.SyntheticDrift,
+			Bars:       cfg.SyntheticBars,
+			Speed:      cfg.SyntheticSpeed,
+			Calendar:   calendar,
+			PairSymbol: cfg.PairSymbol,
+		}, cfg.Symbol, handler, opts...)
+	}
+	symbols := []string{cfg.Symbol}
+	if cfg.PairSymbol != "" {
+		symbols = append(symbols, cfg.PairSymbol)
+	}
+	return md.StartStream(ctx, cfg.APIKey, cfg.APISecret, cfg.Feed, symbols, handler, opts...)
+}
+
+// loadCalendar fetches the trading calendar around today, falling back to
+// regular weekday hours when the broker is unreachable (e.g. no credentials).
+func loadCalendar(ctx context.Context, brokerClient *broker.Client) *md.Calendar {
+	now := time.Now()
+	days, err := brokerClient.Calendar(ctx, now.AddDate(0, 0, -7), now.AddDate(0, 0, 30))
+	if err != nil {
+		slog.Warn("calendar unavailable, using regular hours", "error", err)
+		return md.NewCalendar(nil)
+	}
+	sessions := make([]md.Session, 0, len(days))
+	for _, day := range days {
+		sessions = append(sessions, md.Session{Open: day.Open, Close: day.Close})
 	}
+	return md.NewCalendar(sessions)
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"ats/internal/backtest"
+	"ats/internal/montecarlo"
+	"ats/internal/report"
+	"ats/internal/strategy"
+)
+
+func runMonteCarlo(args []string) error {
+	fs := flag.NewFlagSet("montecarlo", flag.ContinueOnError)
+	var source barSource
+	source.register(fs)
+	cfg := engineFlags(fs)
+	params := fs.String("params", "", "strategy parameters for the backtest, e.g. \"band_pct=0.01;min_bars=20\"")
+	decisionsPath := fs.String("decisions", "", "evaluate a recorded run instead of backtesting (decisions.ndjson)")
+	fillsPath := fs.String("fills", "", "optional ndjson of fills for --decisions")
+	runID := fs.String("run-id", "", "run to evaluate with --decisions (default: last run)")
+	capital := fs.Float64("capital", 10000, "initial capital")
+	simulations := fs.Int("simulations", 1000, "number of simulated paths")
+	method := fs.String("method", montecarlo.MethodBootstrap, "trade resampling: bootstrap or block")
+	blockSize := fs.Int("block-size", 5, "trades per block for block bootstrap")
+	slippage := fs.Float64("slippage-bps", 0, "scale of extra random slippage per side, in bps")
+	jitter := fs.Int("timing-jitter", 0, "max bars to shift each entry and exit")
+	ruin := fs.Float64("ruin", 0.5, "fraction of capital lost that counts as ruin")
+	seed := fs.Int64("seed", 1, "random seed")
+	jsonPath := fs.String("json", "", "optional path to write the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	ctx, stop := offlineContext()
+	defer stop()
+	var input report.Input
+	if *decisionsPath != "" {
+		var err error
+		if input, err = loadReportInput(*decisionsPath, *fillsPath, *runID, *capital); err != nil {
+			return err
+		}
+	} else {
+		bars, err := source.load()
+		if err != nil {
+			return err
+		}
+		pairBars, err := source.loadPair(cfg.PairSymbol, bars)
+		if err != nil {
+			return err
+		}
+		strategyParams, err := parseStrategyParams(*params)
+		if err != nil {
+			return err
+		}
+		strat, err := strategy.Build(cfg.Strategy, *cfg, strategyParams)
+		if err != nil {
+			return err
+		}
+		result, err := backtest.Run(ctx, backtest.Options{Config: *cfg, Capital: *capital, PairBars: pairBars}, strat, bars)
+		if err != nil {
+			return err
+		}
+		input = result.Input
+	}
+
+	result, err := montecarlo.Run(input, montecarlo.Options{
+		Simulations:  *simulations,
+		Method:       *method,
+		BlockSize:    *blockSize,
+		SlippageBps:  *slippage,
+		TimingJitter: *jitter,
+		RuinLoss:     *ruin,
+		Seed:         *seed,
+		Capital:      *capital,
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d %s simulations of %d trades (%.1f trades/day)\n", result.Simulations, *method, result.Trades, result.TradesPerDay)
+	fmt.Printf("Original path: return %s, max drawdown %s\n\n", report.Percent(result.OriginalReturn), report.Percent(result.OriginalDrawdown))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "metric\tp1\tp5\tp25\tp50\tp75\tp95\tp99\tmean")
+	for _, row := range []struct {
+		name string
+		d    montecarlo.Distribution
+	}{
+		{"final return", result.FinalReturn},
+		{"max drawdown", result.MaxDrawdown},
+		{"worst day loss", result.WorstDayLoss},
+	} {
+		d := row.d
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", row.name,
+			report.Percent(d.P1), report.Percent(d.P5), report.Percent(d.P25), report.Percent(d.P50), report.Percent(d.P75), report.Percent(d.P95), report.Percent(d.P99), report.Percent(d.Mean))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nProbability of ruin (losing %s): %s\n", report.Percent(*ruin), report.Percent(result.ProbabilityOfRuin))
+	fmt.Printf("Suggested limits (p95 / p99): max drawdown %s / %s, daily loss %s / %s\n",
+		report.Percent(result.MaxDrawdown.P95), report.Percent(result.MaxDrawdown.P99), report.Percent(result.WorstDayLoss.P95), report.Percent(result.WorstDayLoss.P99))
+
+	if *jsonPath != "" {
+		return writeJSON(*jsonPath, result)
+	}
+	return nil
+}
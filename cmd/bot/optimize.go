@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/optimize"
+)
+
+// searchFlags are shared by optimize and walkforward.
+type searchFlags struct {
+	source    barSource
+	cfg       *config.Config
+	space     *string
+	trials    *int
+	seed      *int64
+	objective *string
+	workers   *int
+	capital   *float64
+}
+
+func registerSearch(fs *flag.FlagSet) *searchFlags {
+	s := &searchFlags{}
+	s.source.register(fs)
+	s.cfg = engineFlags(fs)
+	s.space = fs.String("params", "", "search space, e.g. \"band_pct=0.005,0.01,0.02;sma_window=10,20\" or \"band_pct=0.005:0.03\" with --trials")
+	s.trials = fs.Int("trials", 0, "random search: number of sampled trials (0 runs the full grid)")
+	s.seed = fs.Int64("seed", 1, "random search seed")
+	s.objective = fs.String("objective", optimize.ObjectiveSharpe, "rank by: sharpe, sortino, calmar, total_return, profit_factor, max_drawdown")
+	s.workers = fs.Int("workers", 0, "parallel backtests (default: number of CPUs)")
+	s.capital = fs.Float64("capital", 10000, "initial capital")
+	return s
+}
+
+// load parses the search space and loads bars. Names are sorted for output.
+func (s *searchFlags) load() ([]string, []map[string]float64, []md.Bar, error) {
+	if *s.space == "" {
+		return nil, nil, nil, fmt.Errorf("--params is required")
+	}
+	params, err := optimize.ParseSpace(*s.space)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	var combos []map[string]float64
+	if *s.trials > 0 {
+		combos = optimize.Random(params, *s.trials, *s.seed)
+	} else if combos, err = optimize.Grid(params); err != nil {
+		return nil, nil, nil, err
+	}
+	bars, err := s.source.load()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	names := make([]string, len(params))
+	for i, p := range params {
+		names[i] = p.Name
+	}
+	sort.Strings(names)
+	return names, combos, bars, nil
+}
+
+func (s *searchFlags) options() optimize.Options {
+	return optimize.Options{
+		Config:    *s.cfg,
+		Strategy:  s.cfg.Strategy,
+		Capital:   *s.capital,
+		Objective: *s.objective,
+		Workers:   *s.workers,
+	}
+}
+
+func runOptimize(args []string) error {
+	fs := flag.NewFlagSet("optimize", flag.ContinueOnError)
+	search := registerSearch(fs)
+	csvPath := fs.String("csv", "optimize.csv", "path to write every trial as CSV")
+	top := fs.Int("top", 10, "number of best trials to print")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	names, combos, bars, err := search.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := offlineContext()
+	defer stop()
+	results, err := optimize.Run(ctx, search.options(), bars, combos)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*csvPath)
+	if err != nil {
+		return err
+	}
+	if err := optimize.WriteCSV(file, names, results); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	fmt.Printf("%d trials of %s on %d bars, ranked by %s (all trials in %s)\n", len(results), search.cfg.Strategy, len(bars), *search.objective, *csvPath)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "rank")
+	for _, name := range names {
+		fmt.Fprintf(w, "\t%s", name)
+	}
+	fmt.Fprintln(w, "\tscore\treturn\tsharpe\tmax_dd\ttrades")
+	for i, t := range results {
+		if i >= *top {
+			break
+		}
+		fmt.Fprintf(w, "%d", i+1)
+		for _, name := range names {
+			fmt.Fprintf(w, "\t%g", t.Params[name])
+		}
+		if t.Err != nil {
+			fmt.Fprintf(w, "\terror: %v\n", t.Err)
+			continue
+		}
+		fmt.Fprintf(w, "\t%.4f\t%.2f%%\t%.2f\t%.2f%%\t%d\n", t.Score, t.Report.TotalReturn*100, t.Report.Sharpe, t.Report.MaxDrawdown*100, t.Report.TradeCount)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"ats/internal/report"
+)
+
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ContinueOnError)
+	decisionsPath := fs.String("decisions", "decisions.ndjson", "path to decisions log")
+	fillsPath := fs.String("fills", "", "optional ndjson of fills (default: simulate fills at bar close)")
+	runID := fs.String("run-id", "", "run to evaluate (default: last run in the log)")
+	capital := fs.Float64("capital", 10000, "initial capital")
+	jsonPath := fs.String("json", "", "optional path to write the report as JSON")
+	htmlPath := fs.String("html", "", "optional path to write a self-contained HTML tearsheet")
+	var benchmark benchmarkFlags
+	benchmark.register(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	input, err := loadReportInput(*decisionsPath, *fillsPath, *runID, *capital)
+	if err != nil {
+		return err
+	}
+	symbol := "self"
+	if len(input.Fills) > 0 {
+		symbol = input.Fills[0].Symbol
+	}
+	if err := benchmark.apply(context.Background(), &input, symbol); err != nil {
+		return err
+	}
+	result := report.Compute(input)
+
+	if err := report.WriteTable(os.Stdout, result); err != nil {
+		return err
+	}
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, result); err != nil {
+			return err
+		}
+	}
+	if *htmlPath != "" {
+		if err := writeHTML(*htmlPath, "Run report: "+*decisionsPath, result, input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeHTML(path, title string, result report.Report, input report.Input) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := report.WriteHTML(file, title, result, input); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func loadReportInput(decisionsPath, fillsPath, runID string, capital float64) (report.Input, error) {
+	records, err := report.LoadDecisions(decisionsPath)
+	if err != nil {
+		return report.Input{}, fmt.Errorf("load decisions: %w", err)
+	}
+	records = report.FilterRun(records, runID)
+	if len(records) == 0 {
+		return report.Input{}, fmt.Errorf("no decisions found for run %q", runID)
+	}
+
+	var fills []report.Fill
+	if fillsPath != "" {
+		fills, err = report.LoadFills(fillsPath)
+		if err != nil {
+			return report.Input{}, fmt.Errorf("load fills: %w", err)
+		}
+	}
+	return report.FromDecisions(records, fills, capital), nil
+}
+
+func writeJSON(path string, value any) error {
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"ats/internal/strategy"
+)
+
+// runStrategies handles `bot strategies list`, which prints every
+// registered strategy with its parameter schema.
+func runStrategies(args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return fmt.Errorf("usage: bot strategies list")
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for i, def := range strategy.Definitions() {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", def.Name, def.Description)
+		if len(def.Params) == 0 {
+			fmt.Fprintln(w, "  (no parameters)")
+			continue
+		}
+		fmt.Fprintln(w, "  param\ttype\tdefault\trange\tdescription")
+		for _, p := range def.Params {
+			fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\n", p.Name, p.Kind, strconv.FormatFloat(p.Default, 'g', -1, 64), p.Range(), p.Description)
+		}
+	}
+	return w.Flush()
+}
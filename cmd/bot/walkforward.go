@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"ats/internal/optimize"
+	"ats/internal/report"
+)
+
+func runWalkForward(args []string) error {
+	fs := flag.NewFlagSet("walkforward", flag.ContinueOnError)
+	search := registerSearch(fs)
+	inSample := fs.Int("is-bars", 390*5, "in-sample (optimization) window length in bars")
+	outOfSample := fs.Int("oos-bars", 390, "out-of-sample (test) window length in bars")
+	step := fs.Int("step-bars", 0, "bars between window starts (default: --oos-bars)")
+	anchored := fs.Bool("anchored", false, "grow the in-sample window from the first bar instead of rolling it")
+	jsonPath := fs.String("json", "", "optional path to write the walk-forward result as JSON")
+	htmlPath := fs.String("html", "", "optional path to write a tearsheet of the stitched out-of-sample run")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	names, combos, bars, err := search.load()
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := offlineContext()
+	defer stop()
+	result, err := optimize.WalkForward(ctx, optimize.WalkForwardOptions{
+		Options:     search.options(),
+		InSample:    *inSample,
+		OutOfSample: *outOfSample,
+		Step:        *step,
+		Anchored:    *anchored,
+	}, bars, combos)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("%d walk-forward windows of %s, %d trials each, ranked by %s\n\n", len(result.Windows), search.cfg.Strategy, len(combos), *search.objective)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprint(w, "window\toos start\toos end")
+	for _, name := range names {
+		fmt.Fprintf(w, "\t%s", name)
+	}
+	fmt.Fprintln(w, "\tis score\toos score\toos return")
+	for _, win := range result.Windows {
+		fmt.Fprintf(w, "%d\t%s\t%s", win.Index, win.OOSStart.Format("2006-01-02 15:04"), win.OOSEnd.Format("2006-01-02 15:04"))
+		for _, name := range names {
+			fmt.Fprintf(w, "\t%g", win.Best[name])
+		}
+		fmt.Fprintf(w, "\t%.4f\t%.4f\t%.2f%%\n", win.ISScore, win.OOSScore, win.OOS.TotalReturn*100)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nParameter stability")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "param\tmean\tstd\tcv\tmin\tmax\tdistinct")
+	for _, s := range result.Stability {
+		fmt.Fprintf(w, "%s\t%.4g\t%.4g\t%.2f\t%g\t%g\t%d\n", s.Name, s.Mean, s.Std, s.CV, s.Min, s.Max, s.Distinct)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\nWalk-forward efficiency (mean OOS / mean IS score): %.2f\n\nStitched out-of-sample run\n", result.Efficiency)
+	if err := report.WriteTable(os.Stdout, result.Report); err != nil {
+		return err
+	}
+
+	if *jsonPath != "" {
+		if err := writeJSON(*jsonPath, result); err != nil {
+			return err
+		}
+	}
+	if *htmlPath != "" {
+		if err := writeHTML(*htmlPath, "Walk-forward (out-of-sample): "+search.cfg.Strategy, result.Report, result.Input); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -5,6 +5,7 @@ go 1.22
 require (
 	github.com/alpacahq/alpaca-trade-api-go/v3 v3.0.0
 	github.com/shopspring/decimal v1.3.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,311 @@
+// Package backtest replays historical or synthetic bars through the live
+// engine against a simulated broker.
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/engine"
+	"ats/internal/event"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/report"
+	"ats/internal/risk"
+	"ats/internal/sizing"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+type Options struct {
+	// Config carries the engine and risk settings; Mode is forced to backtest.
+	Config   config.Config
+	Capital  float64
+	Calendar *md.Calendar
+	RunID    string
+	// Warmup bars prime the engine's buffers before the measured run:
+	// orders placed during warm-up are discarded and their decisions are
+	// left out of the result.
+	Warmup int
+	// PairBars are the hedge leg's bars for a pairs strategy; their symbol
+	// is the pair symbol unless Config.PairSymbol is set.
+	PairBars []md.Bar
+}
+
+type Result struct {
+	Input     report.Input
+	Report    report.Report
+	Decisions []engine.Decision
+}
+
+// Run feeds bars through a fresh engine. Orders are filled by the simulated
+// broker at the next bar's open, so a decision never trades on the bar that
+// produced it.
+func Run(ctx context.Context, opts Options, strat strategy.Strategy, bars []md.Bar) (Result, error) {
+	if len(bars) == 0 {
+		return Result{}, fmt.Errorf("backtest needs at least one bar")
+	}
+	cfg := opts.Config
+	cfg.Mode = config.ModeBacktest
+	cfg.KillSwitch = false
+	// Replays are deterministic only when each bar is decided before the next.
+	cfg.AsyncStrategy = false
+	if opts.Capital > 0 {
+		cfg.Capital = opts.Capital
+	}
+	if cfg.Symbol == "" {
+		cfg.Symbol = bars[0].Symbol
+	}
+	feed := bars
+	if _, ok := strat.(strategy.PairStrategy); ok {
+		if len(opts.PairBars) == 0 {
+			return Result{}, fmt.Errorf("pairs backtest needs bars for the pair symbol")
+		}
+		if cfg.PairSymbol == "" {
+			cfg.PairSymbol = opts.PairBars[0].Symbol
+		}
+		feed = mergeBars(bars, opts.PairBars)
+	}
+	calendar := opts.Calendar
+	if calendar == nil {
+		calendar = md.NewCalendar(nil)
+	}
+	runID := opts.RunID
+	if runID == "" {
+		runID = "backtest"
+	}
+
+	clk := clock.NewSimulated()
+	store := state.NewStore(clk)
+	bus := event.NewBus()
+	sim := NewSimBroker(bus, calendar)
+	recorder := &decisionRecorder{runID: runID}
+	eng := engine.New(cfg, strat, risk.Gate{}, sim, store, recorder, nil, calendar, clk)
+	eng.Subscribe(bus)
+
+	if opts.Warmup >= len(bars) {
+		return Result{}, fmt.Errorf("backtest warm-up of %d bars leaves nothing to test", opts.Warmup)
+	}
+	sim.discard = opts.Warmup > 0
+	warm := opts.Warmup == 0
+	for _, bar := range feed {
+		if err := ctx.Err(); err != nil {
+			return Result{}, err
+		}
+		if !warm && bar.Timestamp >= bars[opts.Warmup].Timestamp {
+			warm = true
+			sim.discard = false
+			recorder.decisions = nil
+		}
+		// Orders queued on the previous bar fill at this bar's open, before
+		// the engine sees the bar, which it does once the bar has closed.
+		sim.OnBar(ctx, bar)
+		clk.Observe(time.Unix(bar.Timestamp, 0).Add(md.BarInterval))
+		bus.Publish(ctx, event.BarEvent{Bar: bar})
+	}
+
+	records := make([]report.DecisionRecord, 0, len(recorder.decisions))
+	for _, d := range recorder.decisions {
+		records = append(records, DecisionRecord(d))
+	}
+	input := report.Input{
+		Fills:          sim.Fills(),
+		Equity:         report.EquityCurve(records, sim.Fills(), opts.Capital),
+		InitialCapital: opts.Capital,
+	}
+	return Result{Input: input, Report: report.Compute(input), Decisions: recorder.decisions}, nil
+}
+
+// mergeBars interleaves the bars of both legs in time order, the primary
+// leg first at equal times.
+func mergeBars(bars, pairBars []md.Bar) []md.Bar {
+	merged := make([]md.Bar, 0, len(bars)+len(pairBars))
+	i, j := 0, 0
+	for i < len(bars) || j < len(pairBars) {
+		if j == len(pairBars) || (i < len(bars) && bars[i].Timestamp <= pairBars[j].Timestamp) {
+			merged = append(merged, bars[i])
+			i++
+			continue
+		}
+		merged = append(merged, pairBars[j])
+		j++
+	}
+	return merged
+}
+
+// DecisionRecord converts an engine decision into the report's view of it.
+func DecisionRecord(d engine.Decision) report.DecisionRecord {
+	record := report.DecisionRecord{
+		RunID:      d.RunID,
+		BarTime:    d.BarTime,
+		Symbol:     d.Symbol,
+		Close:      d.Close,
+		SMA:        d.SMA,
+		Intent:     string(d.Intent),
+		IntentQty:  d.IntentQty,
+		Reason:     d.Reason,
+		Result:     d.Result,
+		Backfilled: d.Backfilled,
+	}
+	if d.Pair != nil {
+		record.Pair = &report.PairRecord{HedgeSymbol: d.Pair.HedgeSymbol, HedgeClose: d.Pair.HedgeClose}
+		for _, leg := range d.Pair.Legs {
+			record.Pair.Legs = append(record.Pair.Legs, report.LegRecord{Symbol: leg.Symbol, Intent: string(leg.Intent), Qty: leg.Qty, Result: leg.Result})
+		}
+	}
+	return record
+}
+
+type decisionRecorder struct {
+	runID     string
+	decisions []engine.Decision
+}
+
+func (r *decisionRecorder) RunID() string {
+	return r.runID
+}
+
+func (r *decisionRecorder) Append(decision engine.Decision) {
+	r.decisions = append(r.decisions, decision)
+}
+
+// SimBroker queues orders and fills them on the next bar of their symbol:
+// market orders at the open (notional ones for as many fractional shares as
+// they buy there), limit orders at the open or their limit if the bar trades
+// through it. Unfilled day limits work until the close of the session in
+// which they first meet a bar, like the broker's; outside a session they
+// expire after that bar. Fills and order updates are published on the bus,
+// where the engine applies them.
+type SimBroker struct {
+	bus      *event.Bus
+	calendar *md.Calendar
+	pending  []pendingOrder
+	fills    []report.Fill
+	seq      int
+	// discard acknowledges orders as canceled without filling them (warm-up).
+	discard bool
+}
+
+type pendingOrder struct {
+	req broker.OrderRequest
+	id  string
+	// expires is the close of the order's session, set on its first bar.
+	expires time.Time
+}
+
+func NewSimBroker(bus *event.Bus, calendar *md.Calendar) *SimBroker {
+	return &SimBroker{bus: bus, calendar: calendar}
+}
+
+func (b *SimBroker) PlaceOrder(ctx context.Context, req broker.OrderRequest) (broker.OrderRef, error) {
+	b.seq++
+	ref := broker.OrderRef{
+		ID:            fmt.Sprintf("sim-%d", b.seq),
+		ClientOrderID: req.ClientOrderID,
+		Status:        "new",
+		Symbol:        req.Symbol,
+		Side:          req.Side,
+		Qty:           req.Qty,
+	}
+	if b.discard {
+		ref.Status = "canceled"
+		return ref, nil
+	}
+	b.pending = append(b.pending, pendingOrder{req: req, id: ref.ID})
+	return ref, nil
+}
+
+// CancelOrder drops an order that has not filled yet. Orders fill whole on
+// a bar, so a canceled order never has a partial fill.
+func (b *SimBroker) CancelOrder(ctx context.Context, orderID string) (broker.OrderRef, error) {
+	for i, order := range b.pending {
+		if order.id != orderID {
+			continue
+		}
+		b.pending = append(b.pending[:i:i], b.pending[i+1:]...)
+		req := order.req
+		return broker.OrderRef{ID: order.id, ClientOrderID: req.ClientOrderID, Status: "canceled", Symbol: req.Symbol, Side: req.Side, Qty: req.Qty}, nil
+	}
+	return broker.OrderRef{}, fmt.Errorf("order %s is not open", orderID)
+}
+
+func (b *SimBroker) Fills() []report.Fill {
+	return b.fills
+}
+
+// OnBar fills every order in bar's symbol queued before this bar that it
+// can, and expires the day orders whose session ends with it. Orders placed
+// while the fills are handled wait for the next bar.
+func (b *SimBroker) OnBar(ctx context.Context, bar md.Bar) {
+	barTime := time.Unix(bar.Timestamp, 0).UTC()
+	pending := b.pending
+	b.pending = nil
+	var waiting []pendingOrder
+	for _, order := range pending {
+		req := order.req
+		if req.Symbol != bar.Symbol {
+			waiting = append(waiting, order)
+			continue
+		}
+		first := order.expires.IsZero()
+		if first && b.calendar.InSession(barTime) {
+			session, _ := b.calendar.Session(barTime)
+			order.expires = session.Close
+		}
+		update := event.OrderUpdateEvent{At: barTime, Sym: req.Symbol, OrderID: order.id, ClientOrderID: req.ClientOrderID, Status: "expired", Side: string(req.Side), Qty: req.Qty}
+		price, ok := fillPrice(req, bar)
+		if !first && !barTime.Before(order.expires) {
+			ok = false
+		} else if !ok && barTime.Add(md.BarInterval).Before(order.expires) {
+			waiting = append(waiting, order)
+			continue
+		}
+		if ok {
+			qty := req.Qty
+			if req.Notional.IsPositive() {
+				qty = req.Notional.Div(price).RoundDown(sizing.FractionalPlaces)
+				update.Qty = qty
+			}
+			b.bus.Publish(ctx, event.FillEvent{
+				At:            barTime,
+				Sym:           req.Symbol,
+				Side:          string(req.Side),
+				Qty:           qty,
+				Price:         price,
+				OrderID:       order.id,
+				ClientOrderID: req.ClientOrderID,
+			})
+			b.fills = append(b.fills, report.Fill{Time: barTime, Symbol: req.Symbol, Side: strings.ToUpper(string(req.Side)), Qty: qty.InexactFloat64(), Price: price.InexactFloat64()})
+			update.Status = "filled"
+			update.FilledQty = qty
+		}
+		b.bus.Publish(ctx, update)
+	}
+	b.pending = append(waiting, b.pending...)
+}
+
+func fillPrice(req broker.OrderRequest, bar md.Bar) (money.Price, bool) {
+	open := money.FromFloat(bar.Open)
+	if req.Type != alpaca.Limit || req.LimitPrice == nil {
+		return open, true
+	}
+	limit := *req.LimitPrice
+	if req.Side == alpaca.Buy {
+		if money.FromFloat(bar.Low).GreaterThan(limit) {
+			return money.Price{}, false
+		}
+		return decimal.Min(open, limit), true
+	}
+	if money.FromFloat(bar.High).LessThan(limit) {
+		return money.Price{}, false
+	}
+	return decimal.Max(open, limit), true
+}
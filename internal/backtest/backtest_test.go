@@ -0,0 +1,228 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/config"
+	"ats/internal/event"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+func recordEvents(bus *event.Bus) *[]event.Event {
+	var events []event.Event
+	record := func(ctx context.Context, ev event.Event) { events = append(events, ev) }
+	bus.Subscribe(event.KindFill, record)
+	bus.Subscribe(event.KindOrderUpdate, record)
+	return &events
+}
+
+func TestSimBrokerFillsAtNextOpen(t *testing.T) {
+	bus := event.NewBus()
+	events := recordEvents(bus)
+	sim := NewSimBroker(bus, md.NewCalendar(nil))
+	if _, err := sim.PlaceOrder(context.Background(), broker.OrderRequest{Symbol: "SPY", Qty: strategy.Shares(2), Side: alpaca.Buy, Type: alpaca.Market, ClientOrderID: "c1"}); err != nil {
+		t.Fatalf("place: %v", err)
+	}
+	if len(sim.Fills()) != 0 {
+		t.Fatalf("order must not fill before the next bar")
+	}
+	sim.OnBar(context.Background(), md.Bar{Symbol: "SPY", Timestamp: 60, Open: 101, High: 102, Low: 100, Close: 101.5})
+	fills := sim.Fills()
+	if len(fills) != 1 || fills[0].Price != 101 || fills[0].Qty != 2 {
+		t.Fatalf("expected one fill of 2 @ 101, got %+v", fills)
+	}
+	if len(*events) != 2 {
+		t.Fatalf("expected a fill and an order update, got %+v", *events)
+	}
+	fill, ok := (*events)[0].(event.FillEvent)
+	if !ok || fill.Side != "buy" || fill.ClientOrderID != "c1" {
+		t.Fatalf("unexpected first event %+v", (*events)[0])
+	}
+	if update := (*events)[1].(event.OrderUpdateEvent); update.Status != "filled" {
+		t.Fatalf("expected filled update, got %+v", update)
+	}
+}
+
+// sessionBar is a SPY bar at hh:mm New York time on 2024-01-02.
+func sessionBar(hour, minute int, open, low, high float64) md.Bar {
+	ts := time.Date(2024, 1, 2, hour+5, minute, 0, 0, time.UTC)
+	return md.Bar{Symbol: "SPY", Timestamp: ts.Unix(), Open: open, High: high, Low: low, Close: open}
+}
+
+func TestSimBrokerLimitExpiresAtTheClose(t *testing.T) {
+	bus := event.NewBus()
+	events := recordEvents(bus)
+	sim := NewSimBroker(bus, md.NewCalendar(nil))
+	limit := money.FromFloat(95)
+	_, _ = sim.PlaceOrder(context.Background(), broker.OrderRequest{Symbol: "SPY", Qty: strategy.Shares(1), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: &limit})
+	sim.OnBar(context.Background(), sessionBar(15, 58, 100, 99, 101))
+	if len(*events) != 0 {
+		t.Fatalf("expected the day limit to keep working, got %+v", *events)
+	}
+	sim.OnBar(context.Background(), sessionBar(15, 59, 100, 99, 101))
+	next := sessionBar(9, 30, 94, 93, 95)
+	next.Timestamp += 24 * 60 * 60
+	sim.OnBar(context.Background(), next)
+	if len(sim.Fills()) != 0 {
+		t.Fatalf("expected the day limit to expire unfilled, got %+v", sim.Fills())
+	}
+	if len(*events) != 1 || (*events)[0].(event.OrderUpdateEvent).Status != "expired" || (*events)[0].Time().Hour() != 20 {
+		t.Fatalf("expected one expired update on the last bar, got %+v", *events)
+	}
+}
+
+func TestSimBrokerLimitFillsLaterInTheDay(t *testing.T) {
+	bus := event.NewBus()
+	events := recordEvents(bus)
+	sim := NewSimBroker(bus, md.NewCalendar(nil))
+	limit := money.FromFloat(95)
+	_, _ = sim.PlaceOrder(context.Background(), broker.OrderRequest{Symbol: "SPY", Qty: strategy.Shares(1), Side: alpaca.Buy, Type: alpaca.Limit, LimitPrice: &limit})
+	sim.OnBar(context.Background(), sessionBar(10, 0, 100, 99, 101))
+	sim.OnBar(context.Background(), sessionBar(10, 1, 98, 97, 99))
+	sim.OnBar(context.Background(), sessionBar(10, 2, 96, 94, 96))
+	fills := sim.Fills()
+	if len(fills) != 1 || fills[0].Price != 95 || fills[0].Time.Minute() != 2 {
+		t.Fatalf("expected a fill at the limit on the third bar, got %+v", fills)
+	}
+	if len(*events) != 2 || (*events)[1].(event.OrderUpdateEvent).Status != "filled" {
+		t.Fatalf("expected a fill and a filled update, got %+v", *events)
+	}
+}
+
+func TestSimBrokerCanceledOrderNeverFills(t *testing.T) {
+	bus := event.NewBus()
+	events := recordEvents(bus)
+	sim := NewSimBroker(bus, md.NewCalendar(nil))
+	ref, _ := sim.PlaceOrder(context.Background(), broker.OrderRequest{Symbol: "SPY", Qty: strategy.Shares(1), Side: alpaca.Buy, Type: alpaca.Market, ClientOrderID: "c1"})
+	canceled, err := sim.CancelOrder(context.Background(), ref.ID)
+	if err != nil || canceled.Status != "canceled" || canceled.ClientOrderID != "c1" {
+		t.Fatalf("expected the order to be canceled, got %+v (%v)", canceled, err)
+	}
+	sim.OnBar(context.Background(), md.Bar{Symbol: "SPY", Timestamp: 60, Open: 101, High: 102, Low: 100, Close: 101.5})
+	if len(sim.Fills()) != 0 || len(*events) != 0 {
+		t.Fatalf("expected no fill after the cancel, got %+v", *events)
+	}
+	if _, err := sim.CancelOrder(context.Background(), ref.ID); err == nil {
+		t.Fatalf("expected canceling twice to fail")
+	}
+}
+
+func TestRunIsDeterministic(t *testing.T) {
+	gen, err := md.NewSyntheticGenerator(md.SyntheticConfig{Seed: 7}, "SPY")
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	bars := make([]md.Bar, 400)
+	for i := range bars {
+		bars[i] = gen.Next()
+	}
+	cfg := config.Default()
+	cfg.SMAWindow = 10
+
+	run := func() Result {
+		result, err := Run(context.Background(), Options{Config: cfg, Capital: 10000}, strategy.SMA{MaxQty: 1}, bars)
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+		return result
+	}
+	first, second := run(), run()
+	if first.Report.TradeCount == 0 {
+		t.Fatalf("expected the SMA strategy to trade")
+	}
+	if first.Report.FinalEquity != second.Report.FinalEquity || len(first.Input.Fills) != len(second.Input.Fills) {
+		t.Fatalf("runs differ: %v vs %v", first.Report.FinalEquity, second.Report.FinalEquity)
+	}
+	var firstOrder time.Time
+	for _, d := range first.Decisions {
+		if d.Result == "order_submitted" {
+			firstOrder = d.BarTime
+			break
+		}
+	}
+	if !first.Input.Fills[0].Time.After(firstOrder) {
+		t.Fatalf("fill at %v must come after the deciding bar %v", first.Input.Fills[0].Time, firstOrder)
+	}
+}
+
+func TestCooldownFollowsBarTime(t *testing.T) {
+	gen, err := md.NewSyntheticGenerator(md.SyntheticConfig{Seed: 7}, "SPY")
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	bars := make([]md.Bar, 400)
+	for i := range bars {
+		bars[i] = gen.Next()
+	}
+	cfg := config.Default()
+	cfg.SMAWindow = 10
+	cfg.Cooldown = 15 * time.Minute
+
+	result, err := Run(context.Background(), Options{Config: cfg, Capital: 10000}, strategy.SMA{MaxQty: 1}, bars)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	var submitted []time.Time
+	for _, d := range result.Decisions {
+		if d.Result == "order_submitted" {
+			submitted = append(submitted, d.Timestamp)
+		}
+	}
+	if len(submitted) < 2 {
+		t.Fatalf("expected trading to resume after the cooldown, got %d orders", len(submitted))
+	}
+	for i := 1; i < len(submitted); i++ {
+		if gap := submitted[i].Sub(submitted[i-1]); gap < cfg.Cooldown {
+			t.Fatalf("orders %v apart, inside the %v cooldown", gap, cfg.Cooldown)
+		}
+	}
+}
+
+func TestPairsBacktestTradesBothLegs(t *testing.T) {
+	gen, _ := md.NewSyntheticGenerator(md.SyntheticConfig{Seed: 1, Volatility: 0.5}, "KO")
+	paired := md.NewPairedGenerator("PEP", 2, 2)
+	var bars, pairBars []md.Bar
+	for i := 0; i < 780; i++ {
+		bar := gen.Next()
+		bars = append(bars, bar)
+		pairBars = append(pairBars, paired.Next(bar))
+	}
+	cfg := config.Default()
+	cfg.AllowShort = true
+	cfg.PairSymbol = "PEP"
+	cfg.MaxQty = 10
+	cfg.MaxNotional = 5000
+	strat, err := strategy.Build("pairs", cfg, nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if _, err := Run(context.Background(), Options{Config: cfg, Capital: 10000}, strat, bars); err == nil {
+		t.Fatalf("expected a pairs backtest without pair bars to fail")
+	}
+	result, err := Run(context.Background(), Options{Config: cfg, Capital: 10000, PairBars: pairBars}, strat, bars)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Decisions) != len(bars) || result.Decisions[0].Pair == nil {
+		t.Fatalf("expected one pair decision per aligned bar, got %d", len(result.Decisions))
+	}
+	traded := map[string]int{}
+	for _, fill := range result.Input.Fills {
+		traded[fill.Symbol]++
+	}
+	if traded["KO"] == 0 || traded["KO"] != traded["PEP"] {
+		t.Fatalf("expected both legs to trade together, got %v", traded)
+	}
+	for _, d := range result.Decisions {
+		if d.Result == "leg_unwound" || d.Result == "leg_unwind_failed" {
+			t.Fatalf("market orders should fill both legs, got %+v", d)
+		}
+	}
+}
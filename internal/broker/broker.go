@@ -2,39 +2,65 @@ package broker
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"time"
 
+	"ats/internal/money"
+
 	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 	"github.com/shopspring/decimal"
 )
 
+// OrderRequest is for Qty shares, which may be fractional, or for a
+// Notional dollar amount when that is non-zero (market day orders only).
 type OrderRequest struct {
 	Symbol        string
-	Qty           int
+	Qty           decimal.Decimal
+	Notional      decimal.Decimal
 	Side          alpaca.Side
 	Type          alpaca.OrderType
 	TimeInForce   alpaca.TimeInForce
 	ClientOrderID string
 	ExtendedHours bool
-	LimitPrice    *float64
+	LimitPrice    *money.Price
 }
 
 type OrderRef struct {
 	ID            string
 	ClientOrderID string
 	Status        string
+	Symbol        string
+	Side          alpaca.Side
+	Qty           decimal.Decimal
+	FilledQty     decimal.Decimal
+	// Notional is the dollar amount of a notional order, which has no Qty.
+	Notional decimal.Decimal
 }
 
 type Position struct {
 	Symbol   string
-	Qty      int
-	AvgEntry float64
+	Qty      decimal.Decimal
+	AvgEntry money.Price
+}
+
+// Asset is the borrow status the engine checks before selling short, and
+// whether fractional orders are accepted.
+type Asset struct {
+	Symbol       string
+	Shortable    bool
+	EasyToBorrow bool
+	Fractionable bool
 }
 
 type Account struct {
-	Equity      float64
-	BuyingPower float64
+	Equity      money.Money
+	BuyingPower money.Money
+}
+
+type CalendarDay struct {
+	Open  time.Time
+	Close time.Time
 }
 
 type Client struct {
@@ -51,33 +77,69 @@ func New(apiKey, apiSecret, baseURL string) *Client {
 }
 
 func (c *Client) PlaceOrder(ctx context.Context, req OrderRequest) (OrderRef, error) {
-	qty := decimal.NewFromInt(int64(req.Qty))
 	orderReq := alpaca.PlaceOrderRequest{
 		Symbol:        req.Symbol,
-		Qty:           &qty,
 		Side:          req.Side,
 		Type:          req.Type,
 		TimeInForce:   req.TimeInForce,
 		ClientOrderID: req.ClientOrderID,
 		ExtendedHours: req.ExtendedHours,
 	}
+	if req.Notional.IsPositive() {
+		notional := req.Notional
+		orderReq.Notional = &notional
+	} else {
+		qty := req.Qty
+		orderReq.Qty = &qty
+	}
 	if req.LimitPrice != nil {
-		limitPrice := decimal.NewFromFloat(*req.LimitPrice)
+		limitPrice := *req.LimitPrice
 		orderReq.LimitPrice = &limitPrice
 	}
 
 	order, err := c.client.PlaceOrder(orderReq)
 	if err != nil {
-		slog.Error("place order failed", "side", req.Side, "symbol", req.Symbol, "qty", req.Qty, "type", req.Type, "error", err)
+		slog.Error("place order failed", "side", req.Side, "symbol", req.Symbol, "qty", req.Qty, "notional", req.Notional, "type", req.Type, "error", err)
 		return OrderRef{}, err
 	}
 
-	slog.Info("place order success", "order_id", order.ID, "side", req.Side, "symbol", req.Symbol, "qty", req.Qty, "type", req.Type, "status", order.Status)
-	return OrderRef{
+	slog.Info("place order success", "order_id", order.ID, "side", req.Side, "symbol", req.Symbol, "qty", req.Qty, "notional", req.Notional, "type", req.Type, "status", order.Status)
+	return orderRef(order), nil
+}
+
+// CancelOrder requests the cancellation of an order and returns it as the
+// broker then reports it; a cancel is asynchronous, so the order may still
+// be pending_cancel or have filled meanwhile.
+func (c *Client) CancelOrder(ctx context.Context, orderID string) (OrderRef, error) {
+	if err := c.client.CancelOrder(orderID); err != nil {
+		slog.Error("cancel order failed", "order_id", orderID, "error", err)
+		return OrderRef{}, err
+	}
+	order, err := c.client.GetOrder(orderID)
+	if err != nil {
+		slog.Error("fetch canceled order failed", "order_id", orderID, "error", err)
+		return OrderRef{}, err
+	}
+	slog.Info("cancel order success", "order_id", orderID, "status", order.Status, "filled_qty", order.FilledQty)
+	return orderRef(order), nil
+}
+
+func orderRef(order *alpaca.Order) OrderRef {
+	ref := OrderRef{
 		ID:            order.ID,
 		ClientOrderID: order.ClientOrderID,
 		Status:        string(order.Status),
-	}, nil
+		Symbol:        order.Symbol,
+		Side:          order.Side,
+		FilledQty:     order.FilledQty,
+	}
+	if order.Qty != nil {
+		ref.Qty = *order.Qty
+	}
+	if order.Notional != nil {
+		ref.Notional = *order.Notional
+	}
+	return ref
 }
 
 func (c *Client) OpenOrders(ctx context.Context) ([]OrderRef, error) {
@@ -91,12 +153,8 @@ func (c *Client) OpenOrders(ctx context.Context) ([]OrderRef, error) {
 	}
 	slog.Info("open orders fetched", "count", len(orders))
 	refs := make([]OrderRef, 0, len(orders))
-	for _, order := range orders {
-		refs = append(refs, OrderRef{
-			ID:            order.ID,
-			ClientOrderID: order.ClientOrderID,
-			Status:        string(order.Status),
-		})
+	for i := range orders {
+		refs = append(refs, orderRef(&orders[i]))
 	}
 	return refs, nil
 }
@@ -107,28 +165,58 @@ func (c *Client) Position(ctx context.Context, symbol string) (Position, error)
 		slog.Error("fetch position failed", "symbol", symbol, "error", err)
 		return Position{}, err
 	}
-	qty := int(pos.Qty.IntPart())
-	avgEntry, _ := pos.AvgEntryPrice.Float64()
-
-	slog.Info("position fetched", "symbol", symbol, "qty", qty, "avg_entry", avgEntry)
+	slog.Info("position fetched", "symbol", symbol, "qty", pos.Qty, "avg_entry", pos.AvgEntryPrice)
 	return Position{
 		Symbol:   pos.Symbol,
-		Qty:      qty,
-		AvgEntry: avgEntry,
+		Qty:      pos.Qty,
+		AvgEntry: pos.AvgEntryPrice,
 	}, nil
 }
 
+func (c *Client) Asset(ctx context.Context, symbol string) (Asset, error) {
+	asset, err := c.client.GetAsset(symbol)
+	if err != nil {
+		slog.Error("fetch asset failed", "symbol", symbol, "error", err)
+		return Asset{}, err
+	}
+	slog.Info("asset fetched", "symbol", symbol, "shortable", asset.Shortable, "easy_to_borrow", asset.EasyToBorrow, "fractionable", asset.Fractionable)
+	return Asset{Symbol: asset.Symbol, Shortable: asset.Shortable, EasyToBorrow: asset.EasyToBorrow, Fractionable: asset.Fractionable}, nil
+}
+
 func (c *Client) Account(ctx context.Context) (Account, error) {
 	acct, err := c.client.GetAccount()
 	if err != nil {
 		slog.Error("fetch account failed", "error", err)
 		return Account{}, err
 	}
-	equity, _ := acct.Equity.Float64()
-	buyingPower, _ := acct.BuyingPower.Float64()
+	slog.Info("account fetched", "equity", acct.Equity, "buying_power", acct.BuyingPower)
+	return Account{Equity: acct.Equity, BuyingPower: acct.BuyingPower}, nil
+}
 
-	slog.Info("account fetched", "equity", equity, "buying_power", buyingPower)
-	return Account{Equity: equity, BuyingPower: buyingPower}, nil
+func (c *Client) Calendar(ctx context.Context, start, end time.Time) ([]CalendarDay, error) {
+	days, err := c.client.GetCalendar(alpaca.GetCalendarRequest{Start: start, End: end})
+	if err != nil {
+		slog.Error("fetch calendar failed", "error", err)
+		return nil, err
+	}
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return nil, err
+	}
+	result := make([]CalendarDay, 0, len(days))
+	for _, day := range days {
+		open, err := time.ParseInLocation("2006-01-02 15:04", day.Date+" "+day.Open, loc)
+		if err != nil {
+			return nil, fmt.Errorf("parse calendar open %q: %w", day.Open, err)
+		}
+		closeTime, err := time.ParseInLocation("2006-01-02 15:04", day.Date+" "+day.Close, loc)
+		if err != nil {
+			return nil, fmt.Errorf("parse calendar close %q: %w", day.Close, err)
+		}
+		result = append(result, CalendarDay{Open: open, Close: closeTime})
+	}
+	slog.Info("calendar fetched", "days", len(result))
+	return result, nil
 }
 
 func WaitForContext(ctx context.Context, delay time.Duration) error {
@@ -0,0 +1,69 @@
+package broker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ats/internal/money"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// TradeUpdate is one account trade event: a fill, a cancel or another order
+// status change. Qty and Price are set for fill and partial_fill only, and
+// PositionQty is the broker's position right after the fill.
+type TradeUpdate struct {
+	At          time.Time
+	Event       string
+	Order       OrderRef
+	Qty         decimal.Decimal
+	Price       money.Price
+	PositionQty *decimal.Decimal
+}
+
+// streamRetryDelay is how long StreamTradeUpdates waits before reconnecting.
+const streamRetryDelay = 5 * time.Second
+
+// StreamTradeUpdates calls handler for every trade update of the account
+// until ctx is done. A dropped stream is reopened from the last update seen,
+// so updates in between are replayed rather than lost; the replayed ones
+// already handled are skipped.
+func (c *Client) StreamTradeUpdates(ctx context.Context, handler func(TradeUpdate)) error {
+	var since time.Time
+	for {
+		err := c.client.StreamTradeUpdates(ctx, func(tu alpaca.TradeUpdate) {
+			if !since.IsZero() && !tu.At.After(since) {
+				return
+			}
+			since = tu.At
+			handler(tradeUpdate(tu))
+		}, alpaca.StreamTradeUpdatesRequest{Since: since})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		slog.Warn("trade updates stream closed", "since", since, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(streamRetryDelay):
+		}
+	}
+}
+
+func tradeUpdate(tu alpaca.TradeUpdate) TradeUpdate {
+	update := TradeUpdate{
+		At:          tu.At,
+		Event:       tu.Event,
+		Order:       orderRef(&tu.Order),
+		PositionQty: tu.PositionQty,
+	}
+	if tu.Qty != nil {
+		update.Qty = *tu.Qty
+	}
+	if tu.Price != nil {
+		update.Price = *tu.Price
+	}
+	return update
+}
@@ -0,0 +1,146 @@
+// Package clock abstracts time so the same engine code runs against the
+// wall clock live and against bar time in replays and tests.
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	NewTicker(d time.Duration) Ticker
+}
+
+// Ticker mirrors time.Ticker behind an interface.
+type Ticker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// Real is the wall clock.
+type Real struct{}
+
+func (Real) Now() time.Time {
+	return time.Now().UTC()
+}
+
+func (Real) NewTicker(d time.Duration) Ticker {
+	return realTicker{time.NewTicker(d)}
+}
+
+type realTicker struct {
+	ticker *time.Ticker
+}
+
+func (t realTicker) C() <-chan time.Time {
+	return t.ticker.C
+}
+
+func (t realTicker) Stop() {
+	t.ticker.Stop()
+}
+
+// OrReal returns c, or the wall clock when c is nil.
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real{}
+	}
+	return c
+}
+
+// Manual only moves when told to. Tickers fire as Set or Advance cross
+// their deadlines; like time.Ticker, a slow reader misses ticks rather
+// than queueing them.
+type Manual struct {
+	mu      sync.Mutex
+	now     time.Time
+	tickers []*manualTicker
+}
+
+func NewManual(start time.Time) *Manual {
+	return &Manual{now: start.UTC()}
+}
+
+func (m *Manual) Now() time.Time {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.now
+}
+
+func (m *Manual) Advance(d time.Duration) {
+	m.Set(m.Now().Add(d))
+}
+
+// Set moves the clock to t. Moving backwards is ignored.
+func (m *Manual) Set(t time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	t = t.UTC()
+	if !t.After(m.now) {
+		return
+	}
+	m.now = t
+	active := m.tickers[:0]
+	for _, ticker := range m.tickers {
+		if ticker.stopped {
+			continue
+		}
+		if !ticker.next.After(t) {
+			select {
+			case ticker.c <- t:
+			default:
+			}
+			for !ticker.next.After(t) {
+				ticker.next = ticker.next.Add(ticker.period)
+			}
+		}
+		active = append(active, ticker)
+	}
+	m.tickers = active
+}
+
+func (m *Manual) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ticker := &manualTicker{clock: m, c: make(chan time.Time, 1), period: d, next: m.now.Add(d)}
+	m.tickers = append(m.tickers, ticker)
+	return ticker
+}
+
+type manualTicker struct {
+	clock   *Manual
+	c       chan time.Time
+	period  time.Duration
+	next    time.Time
+	stopped bool
+}
+
+func (t *manualTicker) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *manualTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	t.stopped = true
+}
+
+// Simulated is driven by market data: each observed bar end moves it
+// forward, so cooldowns and timestamps follow bar time however fast bars
+// are replayed. It reads zero until the first bar.
+type Simulated struct {
+	Manual
+}
+
+func NewSimulated() *Simulated {
+	return &Simulated{}
+}
+
+// Observe advances to t if it is later than the current time.
+func (s *Simulated) Observe(t time.Time) {
+	s.Set(t)
+}
@@ -0,0 +1,59 @@
+package clock
+
+import (
+	"testing"
+	"time"
+)
+
+var start = time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+
+func TestManualTickerFiresOnAdvance(t *testing.T) {
+	m := NewManual(start)
+	ticker := m.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	m.Advance(30 * time.Second)
+	select {
+	case <-ticker.C():
+		t.Fatalf("ticker fired before its interval")
+	default:
+	}
+
+	m.Advance(45 * time.Second)
+	select {
+	case got := <-ticker.C():
+		if !got.Equal(start.Add(75 * time.Second)) {
+			t.Fatalf("unexpected tick time %v", got)
+		}
+	default:
+		t.Fatalf("expected a tick after crossing the interval")
+	}
+}
+
+func TestManualIgnoresBackwardsAndStoppedTickers(t *testing.T) {
+	m := NewManual(start)
+	ticker := m.NewTicker(time.Minute)
+	ticker.Stop()
+	m.Set(start.Add(-time.Hour))
+	if !m.Now().Equal(start) {
+		t.Fatalf("clock moved backwards to %v", m.Now())
+	}
+	m.Advance(time.Hour)
+	select {
+	case <-ticker.C():
+		t.Fatalf("stopped ticker fired")
+	default:
+	}
+}
+
+func TestSimulatedFollowsObservedTime(t *testing.T) {
+	s := NewSimulated()
+	if !s.Now().IsZero() {
+		t.Fatalf("expected zero time before the first bar")
+	}
+	s.Observe(start)
+	s.Observe(start.Add(-time.Minute)) // late bar
+	if !s.Now().Equal(start) {
+		t.Fatalf("expected %v, got %v", start, s.Now())
+	}
+}
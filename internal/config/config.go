@@ -5,8 +5,11 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"ats/internal/md"
 )
 
 type Mode string
@@ -14,34 +17,149 @@ type Mode string
 const (
 	ModeStream Mode = "stream"
 	ModePaper  Mode = "paper"
+	// ModeBacktest is set programmatically by the backtest runner; orders go
+	// to a simulated broker.
+	ModeBacktest Mode = "backtest"
+)
+
+// Policies for async decisions that finish after a newer bar has arrived:
+// drop them, or re-run the risk gate against the latest price.
+const (
+	LateDrop       = "drop"
+	LateRevalidate = "revalidate"
 )
 
+// Position sizing methods; see package sizing.
+const (
+	SizingStrategy      = "strategy"
+	SizingFixedQty      = "fixed_qty"
+	SizingNotional      = "notional"
+	SizingPercentEquity = "percent_equity"
+	SizingVolatility    = "volatility"
+	SizingKelly         = "kelly"
+)
+
+// EnsembleConfig lists the child strategies of strategy=ensemble and how
+// their intents are combined.
+type EnsembleConfig struct {
+	Vote      string
+	Threshold float64
+	Members   []EnsembleMember
+}
+
+type EnsembleMember struct {
+	Strategy string
+	Weight   float64
+	Params   map[string]float64
+}
+
+// ParseEnsembleMembers parses a comma-separated member list with optional
+// weights, e.g. "sma,mean_reversion*2". Members parsed this way use their
+// default parameters.
+func ParseEnsembleMembers(spec string) ([]EnsembleMember, error) {
+	var members []EnsembleMember
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, weightText, weighted := strings.Cut(part, "*")
+		member := EnsembleMember{Strategy: strings.TrimSpace(name), Weight: 1}
+		if weighted {
+			weight, err := strconv.ParseFloat(strings.TrimSpace(weightText), 64)
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("ensemble member %q: weight must be a positive number", part)
+			}
+			member.Weight = weight
+		}
+		if member.Strategy == "" {
+			return nil, fmt.Errorf("ensemble member %q: missing strategy name", part)
+		}
+		members = append(members, member)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("ensemble needs at least one member")
+	}
+	return members, nil
+}
+
+// FeedSynthetic selects the offline generator instead of Alpaca's stream.
+const FeedSynthetic = "synthetic"
+
 type Config struct {
 	Mode                  Mode
 	Symbol                string
+	PairSymbol            string
 	Feed                  string
 	Strategy              string
+	StrategyParams        map[string]float64
+	Ensemble              EnsembleConfig
+	RulesPath             string
+	Timeframe             string
+	TrendTimeframe        string
 	BarsWindow            int
 	SMAWindow             int
 	MaxQty                int
 	MaxNotional           float64
+	AllowShort            bool
+	MaxShortQty           int
+	MaxShortNotional      float64
 	Cooldown              time.Duration
 	ReconcileInterval     time.Duration
 	KillSwitch            bool
 	ExtendedHours         bool
+	Backfill              bool
+	Quotes                bool
+	MaxSpreadBps          float64
+	LimitPricing          string
+	LimitOffset           float64
 	OrderType             string
+	Fractional            bool
+	NotionalOrders        bool
 	TimeInForce           string
 	DecisionsPath         string
 	CheckpointPath        string
 	PaperBaseURL          string
 	APIKey                string
 	APISecret             string
+	SyntheticModel        string
+	SyntheticSeed         int64
+	SyntheticBars         int
+	SyntheticSpeed        float64
+	SyntheticStartPrice   float64
+	SyntheticVolatility   float64
+	SyntheticDrift        float64
 	LLMBaseURL            string
 	LLMModel              string
 	LLMSystemPromptPath   string
 	LLMDecisionPromptPath string
 	LLMContextPrompt      string
 	LLMTimeout            time.Duration
+	Capital               float64
+	Sizing                string
+	SizingQty             int
+	SizingNotional        float64
+	SizingEquityPct       float64
+	SizingRiskPct         float64
+	SizingATRPeriod       int
+	KellyFraction         float64
+	KellyMinTrades        int
+	AsyncStrategy         bool
+	StrategyQueue         int
+	LateDecisions         string
+}
+
+// ShortLimits returns the short position and per-sale notional limits,
+// falling back to the long limits when unset.
+func (c Config) ShortLimits() (int, float64) {
+	qty, notional := c.MaxShortQty, c.MaxShortNotional
+	if qty == 0 {
+		qty = c.MaxQty
+	}
+	if notional == 0 {
+		notional = c.MaxNotional
+	}
+	return qty, notional
 }
 
 func Load() (Config, error) {
@@ -69,9 +187,23 @@ func Load() (Config, error) {
 
 	flag.StringVar(&mode, "mode", string(cfg.Mode), "run mode: stream or paper")
 	flag.StringVar(&symbol, "symbol", cfg.Symbol, "trading symbol")
-	flag.StringVar(&feed, "feed", cfg.Feed, "market data feed: iex or test")
-	flag.StringVar(&strategy, "strategy", cfg.Strategy, "strategy: random_noise, mean_reversion, sma, llm")
+	flag.StringVar(&cfg.PairSymbol, "pair-symbol", cfg.PairSymbol, "strategy=pairs: hedge symbol traded against --symbol")
+	flag.StringVar(&feed, "feed", cfg.Feed, "market data feed: iex, test or synthetic")
+	flag.StringVar(&strategy, "strategy", cfg.Strategy, "strategy name (bot strategies list shows all); parameters go in strategyParams in the config file")
+	flag.StringVar(&cfg.Ensemble.Vote, "ensemble-vote", cfg.Ensemble.Vote, "strategy=ensemble voting: unanimous, majority, weighted, first_non_hold or veto")
+	flag.Float64Var(&cfg.Ensemble.Threshold, "ensemble-threshold", cfg.Ensemble.Threshold, "share of total weight an action needs with ensemble-vote=weighted")
+	flag.Func("ensemble-members", "strategy=ensemble members with optional weights, e.g. sma,mean_reversion*2 (replaces the config file's members)", func(value string) error {
+		members, err := ParseEnsembleMembers(value)
+		if err != nil {
+			return err
+		}
+		cfg.Ensemble.Members = members
+		return nil
+	})
+	flag.StringVar(&cfg.RulesPath, "rules", cfg.RulesPath, "strategy=rules: path to a JSON or YAML rules file")
 	flag.StringVar(&configPath, "config", configPath, "path to JSON config file")
+	flag.StringVar(&cfg.Timeframe, "timeframe", cfg.Timeframe, "strategy bar timeframe: 1m, 5m, 15m, 1h or 1d")
+	flag.StringVar(&cfg.TrendTimeframe, "trend-timeframe", cfg.TrendTimeframe, "optional higher timeframe whose close must be above its SMA for entries")
 	flag.IntVar(&cfg.BarsWindow, "bars-window", cfg.BarsWindow, "number of bars in rolling window")
 	flag.IntVar(&cfg.SMAWindow, "sma-window", cfg.SMAWindow, "SMA window length")
 	flag.IntVar(&cfg.MaxQty, "max-qty", cfg.MaxQty, "max position size")
@@ -79,9 +211,38 @@ func Load() (Config, error) {
 	flag.DurationVar(&cfg.Cooldown, "cooldown", cfg.Cooldown, "cooldown between trades")
 	flag.DurationVar(&cfg.ReconcileInterval, "reconcile-interval", cfg.ReconcileInterval, "reconciliation interval")
 	flag.BoolVar(&cfg.KillSwitch, "kill-switch", cfg.KillSwitch, "if true, never place orders")
+	flag.BoolVar(&cfg.AllowShort, "allow-short", cfg.AllowShort, "allow short positions (sell short, buy to cover)")
+	flag.IntVar(&cfg.MaxShortQty, "max-short-qty", cfg.MaxShortQty, "max short position size (0 = max-qty)")
+	flag.Float64Var(&cfg.MaxShortNotional, "max-short-notional", cfg.MaxShortNotional, "max notional per short sale (0 = max-notional)")
 	flag.BoolVar(&cfg.ExtendedHours, "extended-hours", cfg.ExtendedHours, "allow extended hours (limit+day only)")
+	flag.BoolVar(&cfg.Backfill, "backfill", cfg.Backfill, "backfill bars missed during stream gaps from the historical API")
 	flag.StringVar(&cfg.OrderType, "order-type", cfg.OrderType, "order type: market or limit")
+	flag.BoolVar(&cfg.Fractional, "fractional", cfg.Fractional, "size entries in fractional shares")
+	flag.BoolVar(&cfg.NotionalOrders, "notional-orders", cfg.NotionalOrders, "submit buys as dollar amounts (market orders only; implies fractional)")
+	flag.BoolVar(&cfg.Quotes, "quotes", cfg.Quotes, "subscribe to quotes and trades for NBBO-aware pricing")
+	flag.Float64Var(&cfg.MaxSpreadBps, "max-spread-bps", cfg.MaxSpreadBps, "reject orders when the quoted spread exceeds this many bps (0 disables)")
+	flag.StringVar(&cfg.LimitPricing, "limit-pricing", cfg.LimitPricing, "limit price source: last, join, mid or cross")
+	flag.Float64Var(&cfg.LimitOffset, "limit-offset", cfg.LimitOffset, "price offset beyond the far touch for limit-pricing=cross")
 	flag.StringVar(&cfg.TimeInForce, "time-in-force", cfg.TimeInForce, "time in force: day")
+	flag.StringVar(&cfg.SyntheticModel, "synthetic-model", cfg.SyntheticModel, "synthetic feed model: gbm, ou, regime, jump, flash_crash, gap_open")
+	flag.Int64Var(&cfg.SyntheticSeed, "synthetic-seed", cfg.SyntheticSeed, "synthetic feed random seed")
+	flag.IntVar(&cfg.SyntheticBars, "synthetic-bars", cfg.SyntheticBars, "stop the synthetic feed after this many bars (0 = unlimited)")
+	flag.Float64Var(&cfg.SyntheticSpeed, "synthetic-speed", cfg.SyntheticSpeed, "synthetic bars per second (0 = as fast as possible)")
+	flag.Float64Var(&cfg.SyntheticStartPrice, "synthetic-start-price", cfg.SyntheticStartPrice, "synthetic feed starting price")
+	flag.Float64Var(&cfg.SyntheticVolatility, "synthetic-volatility", cfg.SyntheticVolatility, "synthetic annualized volatility")
+	flag.Float64Var(&cfg.SyntheticDrift, "synthetic-drift", cfg.SyntheticDrift, "synthetic annualized drift")
+	flag.Float64Var(&cfg.Capital, "capital", cfg.Capital, "equity assumed for sizing until the broker reports account equity")
+	flag.StringVar(&cfg.Sizing, "sizing", cfg.Sizing, "position sizing: strategy, fixed_qty, notional, percent_equity, volatility or kelly")
+	flag.IntVar(&cfg.SizingQty, "sizing-qty", cfg.SizingQty, "shares per entry for sizing=fixed_qty (0 = max-qty)")
+	flag.Float64Var(&cfg.SizingNotional, "sizing-notional", cfg.SizingNotional, "dollars per entry for sizing=notional (0 = max-notional)")
+	flag.Float64Var(&cfg.SizingEquityPct, "sizing-equity-pct", cfg.SizingEquityPct, "fraction of equity per entry for sizing=percent_equity")
+	flag.Float64Var(&cfg.SizingRiskPct, "sizing-risk-pct", cfg.SizingRiskPct, "fraction of equity one ATR move may cost for sizing=volatility")
+	flag.IntVar(&cfg.SizingATRPeriod, "sizing-atr-period", cfg.SizingATRPeriod, "ATR period in bars for sizing=volatility")
+	flag.Float64Var(&cfg.KellyFraction, "kelly-fraction", cfg.KellyFraction, "fraction of the full Kelly bet for sizing=kelly")
+	flag.IntVar(&cfg.KellyMinTrades, "kelly-min-trades", cfg.KellyMinTrades, "closed trades before sizing=kelly replaces fixed_qty")
+	flag.BoolVar(&cfg.AsyncStrategy, "async-strategy", cfg.AsyncStrategy, "evaluate the strategy off the market data goroutine")
+	flag.IntVar(&cfg.StrategyQueue, "strategy-queue", cfg.StrategyQueue, "bars that may wait for async evaluation; the oldest is skipped when full")
+	flag.StringVar(&cfg.LateDecisions, "late-decisions", cfg.LateDecisions, "async decisions overtaken by a newer bar: drop or revalidate")
 	flag.StringVar(&cfg.DecisionsPath, "decisions-path", cfg.DecisionsPath, "path to decisions log")
 	flag.StringVar(&cfg.CheckpointPath, "checkpoint-path", cfg.CheckpointPath, "path to checkpoint file")
 	flag.StringVar(&cfg.PaperBaseURL, "paper-base-url", cfg.PaperBaseURL, "paper trading base URL")
@@ -125,6 +286,25 @@ func validate(cfg Config) error {
 			return fmt.Errorf("APCA_API_KEY_ID and APCA_API_SECRET_KEY are required in paper mode")
 		}
 	}
+	if cfg.Feed == FeedSynthetic {
+		if cfg.Mode != ModeStream {
+			return fmt.Errorf("feed=synthetic is only supported in stream mode")
+		}
+		if !md.ValidSyntheticModel(cfg.SyntheticModel) {
+			return fmt.Errorf("invalid synthetic-model: %s", cfg.SyntheticModel)
+		}
+		if cfg.SyntheticBars < 0 || cfg.SyntheticSpeed < 0 {
+			return fmt.Errorf("synthetic-bars and synthetic-speed must be >= 0")
+		}
+	}
+	if _, err := md.ParseTimeframe(cfg.Timeframe); err != nil {
+		return err
+	}
+	if cfg.TrendTimeframe != "" {
+		if _, err := md.ParseTimeframe(cfg.TrendTimeframe); err != nil {
+			return fmt.Errorf("trend-timeframe: %w", err)
+		}
+	}
 	if cfg.SMAWindow <= 1 {
 		return fmt.Errorf("sma-window must be > 1")
 	}
@@ -137,38 +317,111 @@ func validate(cfg Config) error {
 	if cfg.MaxNotional <= 0 {
 		return fmt.Errorf("max-notional must be > 0")
 	}
+	if cfg.MaxShortQty < 0 || cfg.MaxShortNotional < 0 {
+		return fmt.Errorf("max-short-qty and max-short-notional must be >= 0")
+	}
 	if cfg.ReconcileInterval <= 0 {
 		return fmt.Errorf("reconcile-interval must be > 0")
 	}
 	if cfg.Cooldown < 0 {
 		return fmt.Errorf("cooldown must be >= 0")
 	}
+	switch cfg.LimitPricing {
+	case "", "last", "join", "mid", "cross":
+	default:
+		return fmt.Errorf("invalid limit-pricing: %s", cfg.LimitPricing)
+	}
+	if cfg.LimitPricing != "" && cfg.LimitPricing != "last" && !cfg.Quotes {
+		return fmt.Errorf("limit-pricing=%s requires quotes", cfg.LimitPricing)
+	}
+	if cfg.MaxSpreadBps < 0 {
+		return fmt.Errorf("max-spread-bps must be >= 0")
+	}
+	if cfg.MaxSpreadBps > 0 && !cfg.Quotes {
+		return fmt.Errorf("max-spread-bps requires quotes")
+	}
+	if cfg.LimitOffset < 0 {
+		return fmt.Errorf("limit-offset must be >= 0")
+	}
+	switch cfg.Sizing {
+	case "", SizingStrategy, SizingFixedQty, SizingNotional, SizingPercentEquity, SizingVolatility, SizingKelly:
+	default:
+		return fmt.Errorf("invalid sizing: %s", cfg.Sizing)
+	}
+	if cfg.SizingQty < 0 || cfg.SizingNotional < 0 || cfg.Capital < 0 {
+		return fmt.Errorf("sizing-qty, sizing-notional and capital must be >= 0")
+	}
+	if cfg.SizingEquityPct < 0 || cfg.SizingEquityPct > 1 || cfg.SizingRiskPct < 0 || cfg.SizingRiskPct > 1 {
+		return fmt.Errorf("sizing-equity-pct and sizing-risk-pct must be in [0, 1]")
+	}
+	if cfg.Sizing == SizingVolatility && cfg.SizingATRPeriod < 1 {
+		return fmt.Errorf("sizing-atr-period must be >= 1")
+	}
+	if cfg.KellyFraction < 0 || cfg.KellyFraction > 1 {
+		return fmt.Errorf("kelly-fraction must be in [0, 1]")
+	}
+	if cfg.NotionalOrders && cfg.OrderType != "market" {
+		return fmt.Errorf("notional-orders requires order-type=market")
+	}
+	if cfg.AsyncStrategy && cfg.StrategyQueue <= 0 {
+		return fmt.Errorf("strategy-queue must be > 0")
+	}
+	switch cfg.LateDecisions {
+	case "", LateDrop, LateRevalidate:
+	default:
+		return fmt.Errorf("invalid late-decisions: %s", cfg.LateDecisions)
+	}
+	if cfg.PairSymbol != "" && cfg.PairSymbol == cfg.Symbol {
+		return fmt.Errorf("pair-symbol must differ from symbol")
+	}
 	if cfg.Strategy == "llm" && cfg.LLMModel == "" {
 		return fmt.Errorf("llm-model is required when strategy=llm")
 	}
 	return nil
 }
 
+// Default returns the built-in defaults, before any file, env or flag overrides.
+func Default() Config {
+	return defaultConfig()
+}
+
 func defaultConfig() Config {
 	return Config{
-		Mode:              ModeStream,
-		Symbol:            "",
-		Feed:              "",
-		Strategy:          "random_noise",
-		BarsWindow:        50,
-		SMAWindow:         20,
-		MaxQty:            1,
-		MaxNotional:       200,
-		Cooldown:          120 * time.Second,
-		ReconcileInterval: 10 * time.Second,
-		KillSwitch:        false,
-		ExtendedHours:     false,
-		OrderType:         "market",
-		TimeInForce:       "day",
-		DecisionsPath:     "decisions.ndjson",
-		CheckpointPath:    "checkpoint.json",
-		PaperBaseURL:      "https://paper-api.alpaca.markets",
-		LLMTimeout:        8 * time.Second,
+		Mode:                ModeStream,
+		Symbol:              "",
+		Feed:                "",
+		Strategy:            "random_noise",
+		Timeframe:           "1m",
+		BarsWindow:          50,
+		SMAWindow:           20,
+		MaxQty:              1,
+		MaxNotional:         200,
+		Cooldown:            120 * time.Second,
+		ReconcileInterval:   10 * time.Second,
+		KillSwitch:          false,
+		ExtendedHours:       false,
+		LimitPricing:        "last",
+		OrderType:           "market",
+		TimeInForce:         "day",
+		DecisionsPath:       "decisions.ndjson",
+		CheckpointPath:      "checkpoint.json",
+		PaperBaseURL:        "https://paper-api.alpaca.markets",
+		SyntheticModel:      md.ModelGBM,
+		SyntheticSeed:       1,
+		SyntheticSpeed:      10,
+		SyntheticStartPrice: 100,
+		SyntheticVolatility: 0.3,
+		LLMTimeout:          8 * time.Second,
+		Capital:             10000,
+		Sizing:              SizingStrategy,
+		SizingEquityPct:     0.02,
+		SizingRiskPct:       0.005,
+		SizingATRPeriod:     14,
+		KellyFraction:       0.5,
+		KellyMinTrades:      20,
+		StrategyQueue:       4,
+		LateDecisions:       LateDrop,
+		Ensemble:            EnsembleConfig{Vote: "majority", Threshold: 0.5},
 	}
 }
 
@@ -189,33 +442,86 @@ func applyConfigFile(cfg *Config, configPath string) error {
 		return fmt.Errorf("parse config file: %w", err)
 	}
 	mergeConfig(cfg, fileConfig)
+	var explicit explicitFields
+	if err := json.Unmarshal(contents, &explicit); err != nil {
+		return fmt.Errorf("parse config file: %w", err)
+	}
+	if explicit.SyntheticSpeed != nil {
+		cfg.SyntheticSpeed = *explicit.SyntheticSpeed
+	}
 	return nil
 }
 
+// explicitFields are config file fields whose zero value is a setting of its
+// own, so mergeConfig cannot treat zero as unset.
+type explicitFields struct {
+	SyntheticSpeed *float64 // 0 = as fast as possible
+}
+
 func mergeConfig(cfg *Config, other Config) {
 	cfg.Mode = Mode(overrideString(string(cfg.Mode), string(other.Mode)))
 	cfg.Symbol = overrideString(cfg.Symbol, other.Symbol)
+	cfg.PairSymbol = overrideString(cfg.PairSymbol, other.PairSymbol)
 	cfg.Feed = overrideString(cfg.Feed, other.Feed)
 	cfg.Strategy = overrideString(cfg.Strategy, other.Strategy)
+	if other.StrategyParams != nil {
+		cfg.StrategyParams = other.StrategyParams
+	}
+	cfg.Ensemble.Vote = overrideString(cfg.Ensemble.Vote, other.Ensemble.Vote)
+	cfg.Ensemble.Threshold = overrideFloat(cfg.Ensemble.Threshold, other.Ensemble.Threshold)
+	if len(other.Ensemble.Members) > 0 {
+		cfg.Ensemble.Members = other.Ensemble.Members
+	}
+	cfg.RulesPath = overrideString(cfg.RulesPath, other.RulesPath)
+	cfg.Timeframe = overrideString(cfg.Timeframe, other.Timeframe)
+	cfg.TrendTimeframe = overrideString(cfg.TrendTimeframe, other.TrendTimeframe)
 	cfg.BarsWindow = overrideInt(cfg.BarsWindow, other.BarsWindow)
 	cfg.SMAWindow = overrideInt(cfg.SMAWindow, other.SMAWindow)
 	cfg.MaxQty = overrideInt(cfg.MaxQty, other.MaxQty)
 	cfg.MaxNotional = overrideFloat(cfg.MaxNotional, other.MaxNotional)
+	cfg.AllowShort = overrideBool(cfg.AllowShort, other.AllowShort)
+	cfg.MaxShortQty = overrideInt(cfg.MaxShortQty, other.MaxShortQty)
+	cfg.MaxShortNotional = overrideFloat(cfg.MaxShortNotional, other.MaxShortNotional)
 	cfg.Cooldown = overrideDuration(cfg.Cooldown, other.Cooldown)
 	cfg.ReconcileInterval = overrideDuration(cfg.ReconcileInterval, other.ReconcileInterval)
 	cfg.KillSwitch = overrideBool(cfg.KillSwitch, other.KillSwitch)
 	cfg.ExtendedHours = overrideBool(cfg.ExtendedHours, other.ExtendedHours)
+	cfg.Backfill = overrideBool(cfg.Backfill, other.Backfill)
+	cfg.Quotes = overrideBool(cfg.Quotes, other.Quotes)
+	cfg.MaxSpreadBps = overrideFloat(cfg.MaxSpreadBps, other.MaxSpreadBps)
+	cfg.LimitPricing = overrideString(cfg.LimitPricing, other.LimitPricing)
+	cfg.LimitOffset = overrideFloat(cfg.LimitOffset, other.LimitOffset)
 	cfg.OrderType = overrideString(cfg.OrderType, other.OrderType)
+	cfg.Fractional = overrideBool(cfg.Fractional, other.Fractional)
+	cfg.NotionalOrders = overrideBool(cfg.NotionalOrders, other.NotionalOrders)
 	cfg.TimeInForce = overrideString(cfg.TimeInForce, other.TimeInForce)
 	cfg.DecisionsPath = overrideString(cfg.DecisionsPath, other.DecisionsPath)
 	cfg.CheckpointPath = overrideString(cfg.CheckpointPath, other.CheckpointPath)
 	cfg.PaperBaseURL = overrideString(cfg.PaperBaseURL, other.PaperBaseURL)
+	cfg.SyntheticModel = overrideString(cfg.SyntheticModel, other.SyntheticModel)
+	cfg.SyntheticSeed = overrideInt64(cfg.SyntheticSeed, other.SyntheticSeed)
+	cfg.SyntheticBars = overrideInt(cfg.SyntheticBars, other.SyntheticBars)
+	cfg.SyntheticStartPrice = overrideFloat(cfg.SyntheticStartPrice, other.SyntheticStartPrice)
+	cfg.SyntheticVolatility = overrideFloat(cfg.SyntheticVolatility, other.SyntheticVolatility)
+	cfg.SyntheticDrift = overrideFloat(cfg.SyntheticDrift, other.SyntheticDrift)
 	cfg.LLMBaseURL = overrideString(cfg.LLMBaseURL, other.LLMBaseURL)
 	cfg.LLMModel = overrideString(cfg.LLMModel, other.LLMModel)
 	cfg.LLMSystemPromptPath = overrideString(cfg.LLMSystemPromptPath, other.LLMSystemPromptPath)
 	cfg.LLMDecisionPromptPath = overrideString(cfg.LLMDecisionPromptPath, other.LLMDecisionPromptPath)
 	cfg.LLMContextPrompt = overrideString(cfg.LLMContextPrompt, other.LLMContextPrompt)
 	cfg.LLMTimeout = overrideDuration(cfg.LLMTimeout, other.LLMTimeout)
+	cfg.Capital = overrideFloat(cfg.Capital, other.Capital)
+	cfg.Sizing = overrideString(cfg.Sizing, other.Sizing)
+	cfg.SizingQty = overrideInt(cfg.SizingQty, other.SizingQty)
+	cfg.SizingNotional = overrideFloat(cfg.SizingNotional, other.SizingNotional)
+	cfg.SizingEquityPct = overrideFloat(cfg.SizingEquityPct, other.SizingEquityPct)
+	cfg.SizingRiskPct = overrideFloat(cfg.SizingRiskPct, other.SizingRiskPct)
+	cfg.SizingATRPeriod = overrideInt(cfg.SizingATRPeriod, other.SizingATRPeriod)
+	cfg.KellyFraction = overrideFloat(cfg.KellyFraction, other.KellyFraction)
+	cfg.KellyMinTrades = overrideInt(cfg.KellyMinTrades, other.KellyMinTrades)
+	cfg.AsyncStrategy = overrideBool(cfg.AsyncStrategy, other.AsyncStrategy)
+	cfg.StrategyQueue = overrideInt(cfg.StrategyQueue, other.StrategyQueue)
+	cfg.LateDecisions = overrideString(cfg.LateDecisions, other.LateDecisions)
 }
 
 func overrideString(current string, candidate string) string {
@@ -232,6 +538,13 @@ func overrideInt(current int, candidate int) int {
 	return candidate
 }
 
+func overrideInt64(current int64, candidate int64) int64 {
+	if candidate == 0 {
+		return current
+	}
+	return candidate
+}
+
 func overrideFloat(current float64, candidate float64) float64 {
 	if candidate == 0 {
 		return current
@@ -48,6 +48,7 @@ func TestLoadConfigPrecedence(t *testing.T) {
   "mode": "stream",
   "strategy": "sma",
   "maxQty": 5,
+  "strategyParams": {"band_pct": 0.02},
   "llmModel": "config-model",
   "apiKey": "config-key"
 }`
@@ -85,6 +86,9 @@ func TestLoadConfigPrecedence(t *testing.T) {
 	if cfg.APIKey != "env-key" {
 		t.Fatalf("expected API key from env, got %q", cfg.APIKey)
 	}
+	if cfg.StrategyParams["band_pct"] != 0.02 {
+		t.Fatalf("expected strategy params from config file, got %v", cfg.StrategyParams)
+	}
 }
 
 func resetFlagSet(t *testing.T) func() {
@@ -97,3 +101,43 @@ func resetFlagSet(t *testing.T) func() {
 		os.Args = originalArgs
 	}
 }
+
+func TestParseEnsembleMembers(t *testing.T) {
+	members, err := ParseEnsembleMembers("sma, mean_reversion*2.5")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(members) != 2 || members[0].Strategy != "sma" || members[0].Weight != 1 || members[1].Weight != 2.5 {
+		t.Fatalf("unexpected members: %+v", members)
+	}
+	for _, bad := range []string{"", "sma*0", "*2", "sma*x"} {
+		if _, err := ParseEnsembleMembers(bad); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+}
+
+func TestConfigFileCanSetSyntheticSpeedZero(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"syntheticSpeed": 0}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg := defaultConfig()
+	if err := applyConfigFile(&cfg, path); err != nil {
+		t.Fatalf("apply config: %v", err)
+	}
+	if cfg.SyntheticSpeed != 0 {
+		t.Fatalf("expected speed 0 from the file, got %v", cfg.SyntheticSpeed)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"syntheticBars": 10}`), 0o600); err != nil {
+		t.Fatalf("write config: %v", err)
+	}
+	cfg = defaultConfig()
+	if err := applyConfigFile(&cfg, path); err != nil {
+		t.Fatalf("apply config: %v", err)
+	}
+	if cfg.SyntheticSpeed != defaultConfig().SyntheticSpeed {
+		t.Fatalf("expected the default speed when the file omits it, got %v", cfg.SyntheticSpeed)
+	}
+}
@@ -0,0 +1,118 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"ats/internal/md"
+	"ats/internal/strategy"
+)
+
+// evaluation is everything the strategy sees for one primary bar, captured
+// on the bar handler so it can be decided on another goroutine.
+type evaluation struct {
+	bar      md.Bar
+	sma      float64
+	top      md.TopOfBook
+	snapshot strategy.MarketSnapshot
+	queued   time.Time
+}
+
+// Start launches the strategy worker when async evaluation is enabled.
+// Decisions still pending when ctx is done are recorded as skipped.
+func (e *Engine) Start(ctx context.Context) {
+	if e.jobs == nil {
+		return
+	}
+	slog.Info("async strategy evaluation enabled", "queue", cap(e.jobs), "late_decisions", e.cfg.LateDecisions)
+	e.wg.Add(1)
+	go e.work(ctx)
+}
+
+// Close stops accepting bars for evaluation and waits for the worker to
+// finish the ones already queued.
+func (e *Engine) Close() {
+	e.mu.Lock()
+	if e.jobs == nil || e.closed {
+		e.mu.Unlock()
+		return
+	}
+	e.closed = true
+	close(e.jobs)
+	e.mu.Unlock()
+	e.wg.Wait()
+}
+
+func (e *Engine) work(ctx context.Context) {
+	defer e.wg.Done()
+	for ev := range e.jobs {
+		if ctx.Err() != nil {
+			e.skip(ev, "shutdown")
+			continue
+		}
+		e.decide(ctx, ev)
+	}
+}
+
+// enqueue never blocks the bar handler: when the queue is full the oldest
+// waiting bar is skipped, since a newer bar supersedes it anyway.
+func (e *Engine) enqueue(ev evaluation) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.closed {
+		e.skip(ev, "engine_closed")
+		return
+	}
+	for {
+		select {
+		case e.jobs <- ev:
+			return
+		default:
+		}
+		select {
+		case old := <-e.jobs:
+			e.skip(old, "queue_full")
+		default:
+		}
+	}
+}
+
+func (e *Engine) skip(ev evaluation, reason string) {
+	barTime := time.Unix(ev.bar.Timestamp, 0).UTC()
+	e.decisions.Append(Decision{
+		RunID:        e.runID,
+		Timestamp:    e.clock.Now(),
+		BarTime:      barTime,
+		Symbol:       ev.bar.Symbol,
+		Close:        ev.bar.Close,
+		SMA:          ev.sma,
+		Bid:          ev.top.Bid,
+		Ask:          ev.top.Ask,
+		Intent:       strategy.Hold,
+		Result:       "skipped",
+		RejectReason: reason,
+		QueueMs:      milliseconds(time.Since(ev.queued)),
+	})
+	slog.Warn("strategy evaluation skipped", "bar", barTime.Format(time.RFC3339), "reason", reason)
+}
+
+// observe remembers the newest primary bar, which late decisions are
+// checked against.
+func (e *Engine) observe(bar md.Bar) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if bar.Timestamp >= e.latest.Timestamp {
+		e.latest = bar
+	}
+}
+
+func (e *Engine) latestBar() md.Bar {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.latest
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
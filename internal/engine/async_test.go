@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+)
+
+// gatedStrategy buys on every bar but only decides when released, standing
+// in for a slow LLM call.
+type gatedStrategy struct {
+	started chan time.Time
+	release chan struct{}
+}
+
+func (s gatedStrategy) Decide(snapshot strategy.MarketSnapshot) strategy.TradeIntent {
+	s.started <- snapshot.Timestamp
+	<-s.release
+	return strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1), Reason: "gated"}
+}
+
+type memorySink struct {
+	mu        sync.Mutex
+	decisions []Decision
+}
+
+func (s *memorySink) RunID() string { return "test" }
+
+func (s *memorySink) Append(decision Decision) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.decisions = append(s.decisions, decision)
+}
+
+type nopBroker struct{}
+
+func (nopBroker) PlaceOrder(ctx context.Context, req broker.OrderRequest) (broker.OrderRef, error) {
+	return broker.OrderRef{ID: "1", ClientOrderID: req.ClientOrderID, Status: "new"}, nil
+}
+
+func newAsyncEngine(policy string, maxNotional float64) (*Engine, gatedStrategy, *memorySink) {
+	cfg := config.Default()
+	cfg.Mode = config.ModeStream
+	cfg.Symbol = "SPY"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	cfg.MaxNotional = maxNotional
+	cfg.Cooldown = 0
+	cfg.AsyncStrategy = true
+	cfg.StrategyQueue = 1
+	cfg.LateDecisions = policy
+	strat := gatedStrategy{started: make(chan time.Time), release: make(chan struct{})}
+	sink := &memorySink{}
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	e := New(cfg, strat, risk.Gate{}, nopBroker{}, state.NewStore(clk), sink, nil, md.NewCalendar(nil), clk)
+	return e, strat, sink
+}
+
+func minuteBar(minute int, close float64) md.Bar {
+	ts := time.Date(2024, 1, 2, 15, minute, 0, 0, time.UTC)
+	return md.Bar{Symbol: "SPY", Timestamp: ts.Unix(), Open: close, High: close, Low: close, Close: close}
+}
+
+func TestAsyncEvaluationDropsLateAndOverflowingBars(t *testing.T) {
+	e, strat, sink := newAsyncEngine(config.LateDrop, 1000)
+	ctx := context.Background()
+	e.Start(ctx)
+
+	e.OnBar(ctx, minuteBar(0, 100))
+	<-strat.started
+	e.OnBar(ctx, minuteBar(1, 101)) // waits in the queue
+	e.OnBar(ctx, minuteBar(2, 102)) // queue full: minute 1 is skipped
+	strat.release <- struct{}{}     // minute 0 finishes late
+	<-strat.started
+	strat.release <- struct{}{}
+	e.Close()
+
+	want := []struct {
+		minute int
+		result string
+	}{{1, "skipped"}, {0, "late_dropped"}, {2, "dry_run"}}
+	if len(sink.decisions) != len(want) {
+		t.Fatalf("expected %d decisions, got %+v", len(want), sink.decisions)
+	}
+	for i, w := range want {
+		d := sink.decisions[i]
+		if d.BarTime.Minute() != w.minute || d.Result != w.result {
+			t.Fatalf("decision %d: expected minute %d %s, got minute %d %s", i, w.minute, w.result, d.BarTime.Minute(), d.Result)
+		}
+	}
+	if sink.decisions[0].RejectReason != "queue_full" || !sink.decisions[1].Late || sink.decisions[2].Late {
+		t.Fatalf("unexpected decision details: %+v", sink.decisions)
+	}
+}
+
+func TestAsyncEvaluationRevalidatesLateDecisionsAtLatestPrice(t *testing.T) {
+	e, strat, sink := newAsyncEngine(config.LateRevalidate, 120)
+	ctx := context.Background()
+	e.Start(ctx)
+
+	e.OnBar(ctx, minuteBar(0, 100))
+	<-strat.started
+	e.OnBar(ctx, minuteBar(1, 150))
+	strat.release <- struct{}{}
+	<-strat.started
+	strat.release <- struct{}{}
+	e.Close()
+
+	if len(sink.decisions) != 2 {
+		t.Fatalf("expected 2 decisions, got %+v", sink.decisions)
+	}
+	late := sink.decisions[0]
+	if !late.Late || late.RevalidatedClose != 150 || late.Result != "rejected" || late.RejectReason != "max_notional_exceeded" {
+		t.Fatalf("expected late decision rejected at the latest price, got %+v", late)
+	}
+}
@@ -0,0 +1,168 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/event"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/risk"
+	"ats/internal/sizing"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+// snapshotRecorder holds on every bar and keeps the snapshots it was shown.
+type snapshotRecorder struct {
+	snapshots []strategy.MarketSnapshot
+}
+
+func (s *snapshotRecorder) Decide(snapshot strategy.MarketSnapshot) strategy.TradeIntent {
+	s.snapshots = append(s.snapshots, snapshot)
+	return strategy.TradeIntent{Action: strategy.Hold, Reason: "recording"}
+}
+
+func TestSnapshotCarriesPositionContext(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mode = config.ModeStream
+	cfg.Symbol = "SPY"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	store := state.NewStore(clk)
+	strat := &snapshotRecorder{}
+	e := New(cfg, strat, risk.Gate{}, nopBroker{}, store, &memorySink{}, nil, md.NewCalendar(nil), clk)
+	ctx := context.Background()
+
+	filled := time.Date(2024, 1, 2, 14, 59, 0, 0, time.UTC)
+	e.onFill(event.FillEvent{At: filled, Sym: "SPY", Side: "buy", Qty: strategy.Shares(2), Price: money.FromFloat(100), ClientOrderID: "a"})
+	store.SetOpenOrder(state.OpenOrder{ClientOrderID: "b", Status: "new", Side: "sell", Qty: strategy.Shares(1)})
+	e.OnBar(ctx, minuteBar(0, 101))
+	e.OnBar(ctx, minuteBar(1, 99.5))
+
+	if len(strat.snapshots) != 2 {
+		t.Fatalf("expected two snapshots, got %d", len(strat.snapshots))
+	}
+	first, second := strat.snapshots[0], strat.snapshots[1]
+	if first.AvgEntry != 100 || first.UnrealizedPnL != 2 || !first.EntryTime.Equal(filled) || first.BarsSinceEntry != 1 {
+		t.Fatalf("unexpected position context: %+v", first)
+	}
+	if second.UnrealizedPnL != -1 || second.BarsSinceEntry != 2 {
+		t.Fatalf("expected -1 unrealized after 2 bars, got %v after %d", second.UnrealizedPnL, second.BarsSinceEntry)
+	}
+	if first.LastFill == nil || first.LastFill.Price != 100 || first.LastFill.Side != "buy" {
+		t.Fatalf("expected the buy fill, got %+v", first.LastFill)
+	}
+	if len(first.OpenOrders) != 1 || first.OpenOrders[0].ClientOrderID != "b" || !first.PendingQty.Equal(strategy.Shares(-1)) {
+		t.Fatalf("expected the open sell order, got %+v pending %s", first.OpenOrders, first.PendingQty)
+	}
+
+	e.onFill(event.FillEvent{At: filled.Add(2 * time.Minute), Sym: "SPY", Side: "sell", Qty: strategy.Shares(2), Price: money.FromFloat(99.5)})
+	e.OnBar(ctx, minuteBar(2, 99))
+	if flat := strat.snapshots[2]; flat.AvgEntry != 0 || flat.BarsSinceEntry != 0 || !flat.EntryTime.IsZero() {
+		t.Fatalf("expected no position context when flat, got %+v", flat)
+	}
+}
+
+// accountBroker reports position, or no position when it is nil.
+type accountBroker struct {
+	position *broker.Position
+}
+
+func (b *accountBroker) OpenOrders(ctx context.Context) ([]broker.OrderRef, error) {
+	return nil, nil
+}
+
+func (b *accountBroker) Position(ctx context.Context, symbol string) (broker.Position, error) {
+	if b.position == nil {
+		return broker.Position{}, &alpaca.APIError{StatusCode: 404, Message: "position does not exist"}
+	}
+	return *b.position, nil
+}
+
+func (b *accountBroker) Account(ctx context.Context) (broker.Account, error) {
+	return broker.Account{Equity: money.FromFloat(10000)}, nil
+}
+
+func TestSnapshotKeepsFillContextWhenReconciliationIsAhead(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mode = config.ModePaper
+	cfg.Symbol = "SPY"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	store := state.NewStore(clk)
+	strat := &snapshotRecorder{}
+	e := New(cfg, strat, risk.Gate{}, nopBroker{}, store, &memorySink{}, nil, md.NewCalendar(nil), clk)
+	bus := event.NewBus()
+	e.Subscribe(bus)
+	brk := &accountBroker{position: &broker.Position{Symbol: "SPY", Qty: strategy.Shares(2), AvgEntry: money.FromFloat(100)}}
+	SubscribeReconciler(bus, brk, store, "SPY")
+	ctx := context.Background()
+	reconcile := func() { bus.Publish(ctx, event.TimerEvent{At: clk.Now(), Name: ReconcileTimer}) }
+	stream := func(at time.Time, side alpaca.Side, price float64) {
+		order := broker.OrderRef{ID: at.String(), ClientOrderID: at.String(), Status: "filled", Symbol: "SPY", Side: side, Qty: strategy.Shares(2), FilledQty: strategy.Shares(2)}
+		update := broker.TradeUpdate{At: at, Event: "fill", Order: order, Qty: strategy.Shares(2), Price: money.FromFloat(price)}
+		if err := RunTradeUpdates(ctx, bus, scriptedStream{update}, "SPY"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The buy is reconciled before its fill is streamed.
+	filled := time.Date(2024, 1, 2, 14, 59, 0, 0, time.UTC)
+	reconcile()
+	stream(filled, alpaca.Buy, 100)
+	e.OnBar(ctx, minuteBar(0, 101))
+	first := strat.snapshots[0]
+	if !first.PositionQty.Equal(strategy.Shares(2)) || first.AvgEntry != 100 || !first.EntryTime.Equal(filled) || first.LastFill == nil || first.LastFill.Price != 100 {
+		t.Fatalf("expected the streamed buy to date the position, got %+v last fill %+v", first, first.LastFill)
+	}
+
+	// So is the sale.
+	brk.position = nil
+	reconcile()
+	stream(filled.Add(2*time.Minute), alpaca.Sell, 105)
+	snap := store.Snapshot()
+	if !snap.Position.Qty.IsZero() || !snap.RealizedPnL.Equal(money.FromFloat(10)) || snap.LastFill == nil || snap.LastFill.Side != "sell" {
+		t.Fatalf("expected a flat position with 10 realized on the sale, got %+v realized %s last fill %+v", snap.Position, snap.RealizedPnL, snap.LastFill)
+	}
+}
+
+func TestKellyObservesOneTradePerRoundTrip(t *testing.T) {
+	cfg := config.Default()
+	cfg.Symbol = "SPY"
+	cfg.Sizing = config.SizingKelly
+	cfg.KellyMinTrades = 1
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	e := New(cfg, &snapshotRecorder{}, risk.Gate{}, nopBroker{}, state.NewStore(clk), &memorySink{}, nil, md.NewCalendar(nil), clk)
+	kelly := e.sizer.(*sizing.Kelly)
+	fill := func(side string, qty int, price float64) {
+		e.onFill(event.FillEvent{At: clk.Now(), Sym: "SPY", Side: side, Qty: strategy.Shares(qty), Price: money.FromFloat(price)})
+	}
+
+	fill("buy", 2, 100)
+	fill("sell", 1, 110)
+	if _, trades, _ := kelly.Bet(); trades != 0 {
+		t.Fatalf("expected no trade while the position is open, got %d", trades)
+	}
+	// +10 and -6 on a 200 basis close as one +2% trade.
+	fill("sell", 1, 94)
+	if f, trades, _ := kelly.Bet(); trades != 1 || f != cfg.KellyFraction {
+		t.Fatalf("expected one winning trade, got %d (bet %g)", trades, f)
+	}
+
+	// Selling through zero closes the short-lived long and opens a short.
+	fill("buy", 1, 100)
+	fill("sell", 2, 99)
+	fill("buy", 1, 97)
+	if _, trades, _ := kelly.Bet(); trades != 3 {
+		t.Fatalf("expected the flip and the cover as two more trades, got %d", trades)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"sync"
 	"time"
 
+	"ats/internal/money"
 	"ats/internal/strategy"
 )
 
@@ -17,15 +18,35 @@ type Decision struct {
 	BarTime        time.Time       `json:"bar_time"`
 	Symbol         string          `json:"symbol"`
 	Close          float64         `json:"close"`
+	Backfilled     bool            `json:"backfilled,omitempty"`
 	SMA            float64         `json:"sma"`
+	Bid            float64         `json:"bid,omitempty"`
+	Ask            float64         `json:"ask,omitempty"`
 	Intent         strategy.Action `json:"intent"`
-	IntentQty      int             `json:"intent_qty"`
+	IntentQty      float64         `json:"intent_qty"`
+	Target         *float64        `json:"target,omitempty"`
+	Strength       *float64        `json:"strength,omitempty"`
+	Sizing         string          `json:"sizing,omitempty"`
 	Reason         string          `json:"reason"`
 	Result         string          `json:"result"`
 	ApprovalReason string          `json:"approval_reason,omitempty"`
 	RejectReason   string          `json:"reject_reason,omitempty"`
 	OrderID        string          `json:"order_id,omitempty"`
 	ClientOrderID  string          `json:"client_order_id,omitempty"`
+	LimitPrice     *money.Price    `json:"limit_price,omitempty"`
+	// Children are the member intents of a composite strategy.
+	Children []strategy.ChildIntent `json:"children,omitempty"`
+	// Pair is set for pairs strategies; Symbol and Close are then the
+	// primary leg's.
+	Pair *PairDecision `json:"pair,omitempty"`
+	// LatencyMs is how long the strategy took to decide; QueueMs how long
+	// the bar waited for async evaluation. Late decisions finished after a
+	// newer bar arrived and, when revalidated, were risk-checked against
+	// RevalidatedClose.
+	LatencyMs        float64 `json:"latency_ms,omitempty"`
+	QueueMs          float64 `json:"queue_ms,omitempty"`
+	Late             bool    `json:"late,omitempty"`
+	RevalidatedClose float64 `json:"revalidated_close,omitempty"`
 }
 
 type DecisionLogger struct {
@@ -4,53 +4,265 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"ats/internal/broker"
+	"ats/internal/clock"
 	"ats/internal/config"
+	"ats/internal/event"
 	"ats/internal/md"
+	"ats/internal/money"
 	"ats/internal/risk"
+	"ats/internal/sizing"
 	"ats/internal/state"
 	"ats/internal/strategy"
 
 	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
 )
 
+// Broker is the order entry the engine needs; *broker.Client in paper mode,
+// a simulated broker in backtests.
+type Broker interface {
+	PlaceOrder(ctx context.Context, req broker.OrderRequest) (broker.OrderRef, error)
+}
+
+// Locator reports whether a symbol can be borrowed for a short sale;
+// *broker.Client implements it. Brokers without it (the backtest simulator)
+// are assumed to lend anything.
+type Locator interface {
+	Asset(ctx context.Context, symbol string) (broker.Asset, error)
+}
+
+// Canceler cancels a working order and reports it as of the cancel;
+// *broker.Client and the backtest simulator implement it.
+type Canceler interface {
+	CancelOrder(ctx context.Context, orderID string) (broker.OrderRef, error)
+}
+
+// DecisionSink records decisions; *DecisionLogger writes them to ndjson.
+type DecisionSink interface {
+	RunID() string
+	Append(decision Decision)
+}
+
 type Engine struct {
 	cfg         config.Config
 	strategy    strategy.Strategy
 	gate        risk.Gate
-	broker      *broker.Client
+	broker      Broker
 	state       *state.Store
-	decisions   *DecisionLogger
+	decisions   DecisionSink
 	buffer      *md.RingBuffer
+	quotes      *md.QuoteBook
+	timeframe   md.Timeframe
+	frames      map[md.Timeframe]*frameSeries
 	runID       string
 	orderSeqNum uint64
+	clock       clock.Clock
+	bus         *event.Bus
+	sizer       sizing.Sizer
+
+	// Async strategy evaluation (see async.go); jobs is nil when the
+	// strategy runs inline on the bar handler.
+	jobs   chan evaluation
+	wg     sync.WaitGroup
+	mu     sync.Mutex
+	closed bool
+	latest md.Bar
+	// fractionable caches the broker's answer per symbol (see fractional).
+	fractionable map[string]bool
+	// pair is set when a pairs strategy trades cfg.Symbol against
+	// cfg.PairSymbol (see pairs.go).
+	pair *pairTrader
+	// framesMu serializes bars with the BarFlushTimer, which runs on its
+	// own worker.
+	framesMu sync.Mutex
 }
 
-func New(cfg config.Config, strategy strategy.Strategy, gate risk.Gate, brokerClient *broker.Client, stateStore *state.Store, decisions *DecisionLogger) *Engine {
-	slog.Info("engine initializing", "run_id", decisions.RunID(), "symbol", cfg.Symbol, "sma_window", cfg.SMAWindow, "bars_window", cfg.BarsWindow)
+func New(cfg config.Config, strat strategy.Strategy, gate risk.Gate, brokerClient Broker, stateStore *state.Store, decisions DecisionSink, quotes *md.QuoteBook, calendar *md.Calendar, clk clock.Clock) *Engine {
+	timeframe, _ := md.ParseTimeframe(cfg.Timeframe)
+	if tfStrategy, ok := strat.(strategy.TimeframeStrategy); ok && tfStrategy.Timeframe() != "" {
+		timeframe = tfStrategy.Timeframe()
+	}
+	slog.Info("engine initializing", "run_id", decisions.RunID(), "symbol", cfg.Symbol, "timeframe", timeframe, "sma_window", cfg.SMAWindow, "bars_window", cfg.BarsWindow)
 	e := &Engine{
 		cfg:       cfg,
-		strategy:  strategy,
+		strategy:  strat,
 		gate:      gate,
 		broker:    brokerClient,
 		state:     stateStore,
 		decisions: decisions,
 		buffer:    md.NewRingBuffer(cfg.BarsWindow),
+		quotes:    quotes,
+		timeframe: timeframe,
+		frames:    make(map[md.Timeframe]*frameSeries),
 		runID:     decisions.RunID(),
+		clock:     clock.OrReal(clk),
+		sizer:     sizing.New(cfg),
+
+		fractionable: make(map[string]bool),
+	}
+	if cfg.AsyncStrategy {
+		e.jobs = make(chan evaluation, cfg.StrategyQueue)
 	}
+	for _, tf := range requestedTimeframes(timeframe, strat) {
+		e.frames[tf] = newFrameSeries(calendar, tf, cfg.BarsWindow)
+	}
+	if pairs, ok := strat.(strategy.PairStrategy); ok && cfg.PairSymbol != "" {
+		e.pair = newPairTrader(pairs, cfg, calendar, timeframe, e.clock)
+		slog.Info("pairs trading", "symbol", cfg.Symbol, "hedge", cfg.PairSymbol)
+	}
+	e.restoreStrategyState()
 	slog.Info("engine initialized", "run_id", e.runID)
 	return e
 }
 
+// Subscribe attaches the engine to a bus: bars drive evaluation, quotes and
+// trades feed the quote book, fills and order updates maintain state, and
+// BarFlushTimer completes ended bars. Fills and broker order updates come
+// from the backtest simulator or, live, from RunTradeUpdates. The engine
+// publishes its own order updates and risk trips back onto the bus.
+func (e *Engine) Subscribe(bus *event.Bus) {
+	e.bus = bus
+	bus.Subscribe(event.KindBar, func(ctx context.Context, ev event.Event) {
+		e.OnBar(ctx, ev.(event.BarEvent).Bar)
+	})
+	if e.quotes != nil {
+		bus.Subscribe(event.KindQuote, func(ctx context.Context, ev event.Event) {
+			e.quotes.UpdateQuote(ev.(event.QuoteEvent).Quote)
+		})
+		bus.Subscribe(event.KindTrade, func(ctx context.Context, ev event.Event) {
+			e.quotes.UpdateTrade(ev.(event.TradeEvent).Trade)
+		})
+	}
+	bus.Subscribe(event.KindFill, func(ctx context.Context, ev event.Event) {
+		e.onFill(ev.(event.FillEvent))
+	})
+	bus.Subscribe(event.KindOrderUpdate, func(ctx context.Context, ev event.Event) {
+		e.onOrderUpdate(ctx, ev.(event.OrderUpdateEvent))
+	})
+	bus.Subscribe(event.KindTimer, func(ctx context.Context, ev event.Event) {
+		if timer := ev.(event.TimerEvent); timer.Name == BarFlushTimer {
+			e.flushBars(ctx, timer.At)
+		}
+	})
+}
+
+// publish sends ev to the bus, or handles the engine's own share of it
+// directly when the engine is used without one.
+func (e *Engine) publish(ctx context.Context, ev event.Event) {
+	if e.bus != nil {
+		e.bus.Publish(ctx, ev)
+		return
+	}
+	if update, ok := ev.(event.OrderUpdateEvent); ok {
+		e.onOrderUpdate(ctx, update)
+	}
+}
+
+// storeFor is the state of symbol's position: the hedge leg's own store
+// when trading a pair, the engine's store otherwise.
+func (e *Engine) storeFor(symbol string) *state.Store {
+	if e.pair != nil && symbol == e.cfg.PairSymbol {
+		return e.pair.hedge
+	}
+	return e.state
+}
+
+func (e *Engine) onFill(fill event.FillEvent) {
+	store := e.storeFor(fill.Sym)
+	_, trip := store.ApplyFill(state.Fill{Time: fill.At, Side: fill.Side, Qty: fill.Qty, Price: fill.Price})
+	store.AddOrderFill(fill.ClientOrderID, fill.Qty)
+	// A trade is observed once its position is closed out, however many
+	// fills that took.
+	if observer, ok := e.sizer.(sizing.TradeObserver); ok && trip != nil && trip.Basis.IsPositive() {
+		observer.ObserveTrade(trip.Return())
+	}
+}
+
+func (e *Engine) onOrderUpdate(ctx context.Context, update event.OrderUpdateEvent) {
+	store := e.storeFor(update.Sym)
+	if e.pair != nil {
+		defer e.checkPairLegs(ctx, update)
+	}
+	if !event.OrderOpen(update.Status) {
+		store.RemoveOpenOrder(update.ClientOrderID)
+		return
+	}
+	// The broker reports no quantity for a notional order; keep the shares
+	// it was sized at.
+	qty := update.Qty
+	if qty.IsZero() {
+		qty = store.Snapshot().OpenOrders[update.ClientOrderID].Qty
+	}
+	store.SetOpenOrder(state.OpenOrder{
+		ClientOrderID: update.ClientOrderID,
+		OrderID:       update.OrderID,
+		Status:        update.Status,
+		Side:          update.Side,
+		Qty:           qty,
+		FilledQty:     update.FilledQty,
+	})
+}
+
+// OnBar accepts a 1-minute bar from the stream and evaluates the strategy
+// for every bar of the strategy's timeframe that it completes.
 func (e *Engine) OnBar(ctx context.Context, bar md.Bar) {
+	if e.pair != nil {
+		e.onPairBar(ctx, bar)
+		return
+	}
 	barTime := time.Unix(bar.Timestamp, 0).UTC()
 	slog.Debug("on bar", "symbol", bar.Symbol, "close", bar.Close, "time", barTime.Format(time.RFC3339))
-	e.buffer.Add(bar.Close)
 	e.state.SetLastBarTime(barTime)
 
+	e.framesMu.Lock()
+	defer e.framesMu.Unlock()
+	// Update every timeframe before evaluating so higher-timeframe bars that
+	// close on this same minute are visible to the primary bar's decision.
+	var ready []md.Bar
+	for tf, series := range e.frames {
+		completed := series.add(bar)
+		if tf == e.timeframe {
+			ready = completed
+		}
+	}
+	for _, completed := range ready {
+		e.evaluate(ctx, completed)
+	}
+}
+
+// flushBars completes the session's final bucket of every timeframe once
+// the close has passed and evaluates the strategy's, so a session's last
+// bar is not held until the next session opens.
+func (e *Engine) flushBars(ctx context.Context, now time.Time) {
+	if e.pair != nil {
+		e.flushPairBars(ctx, now)
+		return
+	}
+	e.framesMu.Lock()
+	defer e.framesMu.Unlock()
+	var ready []md.Bar
+	for tf, series := range e.frames {
+		completed := series.flush(now)
+		if tf == e.timeframe {
+			ready = completed
+		}
+	}
+	for _, completed := range ready {
+		e.evaluate(ctx, completed)
+	}
+}
+
+func (e *Engine) evaluate(ctx context.Context, bar md.Bar) {
+	barTime := time.Unix(bar.Timestamp, 0).UTC()
+	e.buffer.Add(bar.Close)
+	e.state.CountBar()
+
 	sma, err := e.buffer.SMA(e.cfg.SMAWindow)
 	if err != nil {
 		// Not enough data for SMA yet, use close price as fallback for strategies that don't need it
@@ -58,46 +270,144 @@ func (e *Engine) OnBar(ctx context.Context, bar md.Bar) {
 		slog.Info("sma not ready", "bar", barTime.Format(time.RFC3339), "close", bar.Close)
 	}
 
+	// Backfilled bars keep the indicator series contiguous but are stale by
+	// the time they arrive, so they never reach the strategy or the broker.
+	if bar.Backfilled {
+		e.decisions.Append(Decision{
+			RunID:      e.runID,
+			Timestamp:  e.clock.Now(),
+			BarTime:    barTime,
+			Symbol:     bar.Symbol,
+			Close:      bar.Close,
+			Backfilled: true,
+			SMA:        sma,
+			Intent:     strategy.Hold,
+			Reason:     "backfilled_bar",
+			Result:     "backfilled",
+		})
+		slog.Debug("backfilled bar recorded", "bar", barTime.Format(time.RFC3339), "close", bar.Close, "sma", sma)
+		return
+	}
+
+	e.observe(bar)
+	top, _ := e.quotes.Latest(bar.Symbol)
 	snapshot := e.state.Snapshot()
-	intent := e.strategy.Decide(strategy.MarketSnapshot{
-		Timestamp:   barTime,
-		Close:       bar.Close,
-		SMA:         sma,
-		PositionQty: snapshot.Position.Qty,
-	})
+	primaryEnd := e.frames[e.timeframe].aggregator.BucketEnd(bar)
+	frames := make(map[md.Timeframe]strategy.Frame, len(e.frames))
+	for tf, series := range e.frames {
+		frames[tf] = series.frame(primaryEnd, e.cfg.SMAWindow)
+	}
 
-	riskCtx := risk.RiskContext{
-		Now:            time.Now().UTC(),
-		Price:          bar.Close,
-		PositionQty:    snapshot.Position.Qty,
-		OpenOrderCount: len(snapshot.OpenOrders),
-		LastTradeTime:  snapshot.LastTradeTime,
-		MaxQty:         e.cfg.MaxQty,
-		MaxNotional:    e.cfg.MaxNotional,
-		Cooldown:       e.cfg.Cooldown,
-		KillSwitch:     e.cfg.KillSwitch,
-		ExtendedHours:  e.cfg.ExtendedHours,
-		OrderType:      e.cfg.OrderType,
-		TimeInForce:    e.cfg.TimeInForce,
-	}
-
-	approved, err := e.gate.Evaluate(intent, riskCtx)
+	ev := evaluation{
+		bar: bar,
+		sma: sma,
+		top: top,
+		snapshot: withPosition(strategy.MarketSnapshot{
+			Timestamp: barTime,
+			Close:     bar.Close,
+			SMA:       sma,
+			Bid:       top.Bid,
+			Ask:       top.Ask,
+			Spread:    top.Spread(),
+			LastTrade: top.LastTrade,
+			Frames:    frames,
+		}, snapshot),
+		queued: time.Now(),
+	}
+	if e.jobs != nil {
+		e.enqueue(ev)
+		return
+	}
+	e.decide(ctx, ev)
+}
+
+// withPosition fills in the position, last fill and open orders from the
+// state store, valuing the position at the snapshot's close.
+func withPosition(ms strategy.MarketSnapshot, snapshot state.Snapshot) strategy.MarketSnapshot {
+	pos := snapshot.Position
+	ms.PositionQty = pos.Qty
+	ms.PendingQty = snapshot.PendingQty()
+	if !pos.Qty.IsZero() {
+		ms.AvgEntry = pos.AvgEntry.InexactFloat64()
+		ms.UnrealizedPnL = money.Notional(pos.Qty, money.FromFloat(ms.Close).Sub(pos.AvgEntry)).InexactFloat64()
+		ms.EntryTime = pos.EntryTime
+		ms.BarsSinceEntry = pos.BarsHeld
+	}
+	if fill := snapshot.LastFill; fill != nil {
+		ms.LastFill = &strategy.Fill{Time: fill.Time, Side: fill.Side, Qty: fill.Qty, Price: fill.Price.InexactFloat64()}
+	}
+	for _, order := range snapshot.OpenOrders {
+		ms.OpenOrders = append(ms.OpenOrders, strategy.OpenOrder{
+			ClientOrderID: order.ClientOrderID,
+			Status:        order.Status,
+			Side:          order.Side,
+			Qty:           order.Qty,
+			FilledQty:     order.FilledQty,
+		})
+	}
+	sort.Slice(ms.OpenOrders, func(i, j int) bool { return ms.OpenOrders[i].ClientOrderID < ms.OpenOrders[j].ClientOrderID })
+	return ms
+}
+
+// decide runs the strategy on ev and acts on the intent. Sizing and the
+// risk gate run here rather than as bus subscribers: both must finish
+// before the order is placed, on the state the strategy saw.
+func (e *Engine) decide(ctx context.Context, ev evaluation) {
+	started := time.Now()
+	intent := e.strategy.Decide(ev.snapshot)
+	finished := time.Now()
+
+	bar, sma, top := ev.bar, ev.sma, ev.top
+	barTime := time.Unix(bar.Timestamp, 0).UTC()
 	decision := Decision{
 		RunID:     e.runID,
-		Timestamp: time.Now().UTC(),
+		Timestamp: e.clock.Now(),
 		BarTime:   barTime,
 		Symbol:    bar.Symbol,
 		Close:     bar.Close,
 		SMA:       sma,
+		Bid:       top.Bid,
+		Ask:       top.Ask,
 		Intent:    intent.Action,
-		IntentQty: intent.Qty,
+		IntentQty: intent.Qty.InexactFloat64(),
+		Strength:  intent.Strength,
 		Reason:    intent.Reason,
+		Children:  intent.Children,
+		LatencyMs: milliseconds(finished.Sub(started)),
+	}
+	if e.jobs != nil {
+		decision.QueueMs = milliseconds(started.Sub(ev.queued))
+	}
+
+	// An async decision may finish after newer bars have arrived. Either
+	// discard it or check it against the latest price instead of the stale one.
+	price := bar.Close
+	if latest := e.latestBar(); latest.Timestamp > bar.Timestamp {
+		decision.Late = true
+		if e.cfg.LateDecisions != config.LateRevalidate {
+			decision.Result = "late_dropped"
+			decision.RejectReason = fmt.Sprintf("newer bar %s arrived", time.Unix(latest.Timestamp, 0).UTC().Format(time.RFC3339))
+			e.decisions.Append(decision)
+			slog.Info("late decision dropped", "bar", barTime.Format(time.RFC3339), "intent", intent.Action, "latency_ms", decision.LatencyMs, "queue_ms", decision.QueueMs)
+			return
+		}
+		price = latest.Close
+		top, _ = e.quotes.Latest(bar.Symbol)
+		decision.RevalidatedClose = price
+		decision.Bid = top.Bid
+		decision.Ask = top.Ask
 	}
 
+	snapshot := e.state.Snapshot()
+	fractional := e.fractional(ctx, bar.Symbol)
+	intent = e.size(intent, price, snapshot, ev.snapshot.Frames[e.timeframe].Bars, fractional, &decision)
+	approved, err := e.gate.Evaluate(intent, e.riskContext(ctx, bar.Symbol, intent, price, top, snapshot))
+
 	if err != nil {
 		decision.Result = "rejected"
 		decision.RejectReason = err.Error()
 		e.decisions.Append(decision)
+		e.publish(ctx, event.RiskTripEvent{At: decision.Timestamp, Sym: bar.Symbol, Intent: string(intent.Action), Reason: err.Error()})
 		slog.Info("trade rejected", "bar", barTime.Format(time.RFC3339), "close", bar.Close, "sma", sma, "intent", intent.Action, "reason", err.Error())
 		return
 	}
@@ -118,7 +428,7 @@ func (e *Engine) OnBar(ctx context.Context, bar md.Bar) {
 		return
 	}
 
-	orderReq, err := e.buildOrder(bar.Symbol, bar.Close, approved.Intent)
+	orderReq, err := e.buildOrder(bar.Symbol, price, top, approved.Intent, fractional)
 	if err != nil {
 		decision.Result = "order_build_failed"
 		decision.RejectReason = err.Error()
@@ -126,6 +436,7 @@ func (e *Engine) OnBar(ctx context.Context, bar md.Bar) {
 		slog.Error("order build failed", "bar", barTime.Format(time.RFC3339), "close", bar.Close, "sma", sma, "intent", intent.Action, "error", err)
 		return
 	}
+	decision.LimitPrice = orderReq.LimitPrice
 
 	orderRef, err := e.broker.PlaceOrder(ctx, orderReq)
 	if err != nil {
@@ -143,16 +454,222 @@ func (e *Engine) OnBar(ctx context.Context, bar md.Bar) {
 	e.decisions.Append(decision)
 	slog.Info("order submitted", "symbol", bar.Symbol, "side", intent.Action, "qty", intent.Qty, "order_id", orderRef.ID, "client_order_id", orderRef.ClientOrderID)
 
-	e.state.SetLastTradeTime(time.Now().UTC())
-	snapshot.OpenOrders[orderRef.ClientOrderID] = state.OpenOrder{
-		ClientOrderID: orderRef.ClientOrderID,
+	e.state.RecordTrade()
+	// A notional order has no share quantity until it fills, so pending
+	// quantities count the shares it was sized at.
+	qty := orderReq.Qty
+	if orderReq.Notional.IsPositive() {
+		qty = intent.Qty
+	}
+	e.publish(ctx, event.OrderUpdateEvent{
+		At:            decision.Timestamp,
+		Sym:           bar.Symbol,
 		OrderID:       orderRef.ID,
+		ClientOrderID: orderRef.ClientOrderID,
 		Status:        orderRef.Status,
+		Side:          string(orderReq.Side),
+		Qty:           qty,
+		FilledQty:     orderRef.FilledQty,
+	})
+}
+
+// riskContext describes an order in symbol for the risk gate.
+func (e *Engine) riskContext(ctx context.Context, symbol string, intent strategy.TradeIntent, price float64, top md.TopOfBook, snapshot state.Snapshot) risk.RiskContext {
+	maxShortQty, maxShortNotional := e.cfg.ShortLimits()
+	return risk.RiskContext{
+		Now:              e.clock.Now(),
+		Price:            money.FromFloat(price),
+		Bid:              money.FromFloat(top.Bid),
+		Ask:              money.FromFloat(top.Ask),
+		PositionQty:      snapshot.Position.Qty,
+		OpenOrderCount:   len(snapshot.OpenOrders),
+		LastTradeTime:    snapshot.LastTradeTime,
+		MaxQty:           e.cfg.MaxQty,
+		MaxNotional:      money.FromFloat(e.cfg.MaxNotional),
+		AllowShort:       e.cfg.AllowShort,
+		Borrowable:       intent.Action == strategy.SellShort && e.cfg.AllowShort && e.locate(ctx, symbol),
+		MaxShortQty:      maxShortQty,
+		MaxShortNotional: money.FromFloat(maxShortNotional),
+		MaxSpreadBps:     e.cfg.MaxSpreadBps,
+		Cooldown:         e.cfg.Cooldown,
+		KillSwitch:       e.cfg.KillSwitch,
+		ExtendedHours:    e.cfg.ExtendedHours,
+		OrderType:        e.cfg.OrderType,
+		TimeInForce:      e.cfg.TimeInForce,
 	}
-	e.state.SetOpenOrders(snapshot.OpenOrders)
 }
 
-func (e *Engine) buildOrder(symbol string, price float64, intent strategy.TradeIntent) (broker.OrderRequest, error) {
+// size turns an intent into an order quantity. Targets become the delta
+// from the position and open orders. Entries (buys and short sales) take
+// their quantity from the sizing method, capped so the position stays within
+// its max-qty and the order within its max-notional; exits default to the
+// whole position. Short sales are always whole shares. The risk gate still
+// checks the result.
+func (e *Engine) size(intent strategy.TradeIntent, price float64, snapshot state.Snapshot, bars []md.Bar, fractional bool, decision *Decision) strategy.TradeIntent {
+	held := snapshot.Position.Qty
+	targeted := intent.Action == strategy.Target
+	if targeted {
+		// Net against orders already working so a target is not chased
+		// twice while an earlier order fills.
+		target := intent.Target.InexactFloat64()
+		pending := snapshot.PendingQty()
+		intent = intent.Resolve(held.Add(pending))
+		decision.Target = &target
+		decision.Sizing = fmt.Sprintf("target %s from position %s with %s pending", intent.Target, held, pending)
+		if intent.Action == strategy.Hold {
+			intent.Reason += "; at_target"
+			decision.Intent = intent.Action
+			decision.IntentQty = 0
+			decision.Reason = intent.Reason
+			return intent
+		}
+		// Below zero a target sells short and above a short it covers. A
+		// target across zero only closes the position on this bar.
+		switch {
+		case intent.Action == strategy.Sell && !held.IsPositive():
+			intent.Action = strategy.SellShort
+		case intent.Action == strategy.Sell && intent.Qty.GreaterThan(held):
+			decision.Sizing += fmt.Sprintf("; reversal: selling %s before shorting", held)
+		case intent.Action == strategy.Buy && held.IsNegative() && intent.Qty.GreaterThan(held.Neg()):
+			intent.Action = strategy.BuyToCover
+			decision.Sizing += fmt.Sprintf("; reversal: covering %s before buying", held.Neg())
+		case intent.Action == strategy.Buy && held.IsNegative():
+			intent.Action = strategy.BuyToCover
+		}
+	}
+	intent = reverse(intent, held, decision)
+
+	switch intent.Action {
+	case strategy.Buy, strategy.SellShort:
+		if targeted {
+			break
+		}
+		short := intent.Action == strategy.SellShort
+		strength := 1.0
+		if intent.Strength != nil {
+			strength = *intent.Strength
+		}
+		qty, note := e.sizer.Size(sizing.Input{
+			Qty:        intent.Qty,
+			Price:      price,
+			Equity:     e.equity(snapshot, price).InexactFloat64(),
+			Strength:   strength,
+			Bars:       bars,
+			Fractional: fractional && !short,
+		})
+		maxQty, maxNotional, exposure := e.cfg.MaxQty, e.cfg.MaxNotional, held
+		if short {
+			maxQty, maxNotional = e.cfg.ShortLimits()
+			exposure = held.Neg()
+		}
+		// Capping to zero would hide which limit was hit, so an order that
+		// cannot fit even a minimal quantity is left for the risk gate to
+		// reject.
+		if room := strategy.Shares(maxQty).Sub(exposure); qty.GreaterThan(room) && room.IsPositive() {
+			qty = room
+			note += fmt.Sprintf("; capped to max-qty room %s", qty)
+		}
+		if price > 0 {
+			if limit := sizing.Floor(maxNotional/price, fractional && !short); qty.GreaterThan(limit) && limit.IsPositive() {
+				qty = limit
+				note += fmt.Sprintf("; capped to max-notional %s", qty)
+			}
+		}
+		intent.Qty = qty
+		decision.Sizing = note
+	case strategy.Sell:
+		if held.IsPositive() && (!intent.Qty.IsPositive() || intent.Qty.GreaterThan(held)) {
+			intent.Qty = held
+		}
+	case strategy.BuyToCover:
+		if held.IsNegative() && (!intent.Qty.IsPositive() || intent.Qty.GreaterThan(held.Neg())) {
+			intent.Qty = held.Neg()
+		}
+	}
+	if intent.Action == strategy.SellShort {
+		intent.Qty = intent.Qty.Floor()
+	}
+	decision.Intent = intent.Action
+	decision.IntentQty = intent.Qty.InexactFloat64()
+	return intent
+}
+
+// reverse turns an entry against an open position into the exit of that
+// position: a buy while short covers and a short sale while long sells. The
+// opposite side is entered on a later bar once flat, since an order that
+// crosses zero is rejected by the broker.
+func reverse(intent strategy.TradeIntent, held decimal.Decimal, decision *Decision) strategy.TradeIntent {
+	switch {
+	case intent.Action == strategy.Buy && held.IsNegative():
+		intent.Action, intent.Qty = strategy.BuyToCover, held.Neg()
+		decision.Sizing = fmt.Sprintf("reversal: covering %s before buying", held.Neg())
+	case intent.Action == strategy.SellShort && held.IsPositive():
+		intent.Action, intent.Qty = strategy.Sell, held
+		decision.Sizing = fmt.Sprintf("reversal: selling %s before shorting", held)
+	}
+	return intent
+}
+
+// fractional reports whether entries in symbol may be sized in fractional
+// shares: enabled by --fractional or --notional-orders and, in paper mode,
+// accepted by the broker for the asset. The broker's answer is cached.
+func (e *Engine) fractional(ctx context.Context, symbol string) bool {
+	if !e.cfg.Fractional && !e.cfg.NotionalOrders {
+		return false
+	}
+	locator, ok := e.broker.(Locator)
+	if !ok || e.cfg.Mode == config.ModeStream {
+		return true
+	}
+	e.mu.Lock()
+	fractionable, cached := e.fractionable[symbol]
+	e.mu.Unlock()
+	if cached {
+		return fractionable
+	}
+	asset, err := locator.Asset(ctx, symbol)
+	if err != nil {
+		slog.Warn("asset lookup failed", "symbol", symbol, "error", err)
+		return false
+	}
+	if !asset.Fractionable {
+		slog.Warn("asset not fractionable, sizing whole shares", "symbol", symbol)
+	}
+	e.mu.Lock()
+	e.fractionable[symbol] = asset.Fractionable
+	e.mu.Unlock()
+	return asset.Fractionable
+}
+
+// locate asks the broker whether symbol can be sold short. A failed lookup
+// counts as not borrowable; dry runs place no orders and skip the check.
+func (e *Engine) locate(ctx context.Context, symbol string) bool {
+	locator, ok := e.broker.(Locator)
+	if !ok || e.cfg.Mode == config.ModeStream {
+		return true
+	}
+	asset, err := locator.Asset(ctx, symbol)
+	if err != nil {
+		slog.Warn("locate failed", "symbol", symbol, "error", err)
+		return false
+	}
+	return asset.Shortable && asset.EasyToBorrow
+}
+
+// equity is the broker's account equity when reconciliation has reported
+// it, otherwise configured capital plus realized and open P&L.
+func (e *Engine) equity(snapshot state.Snapshot, price float64) money.Money {
+	if snapshot.Equity.IsPositive() {
+		return snapshot.Equity
+	}
+	pos := snapshot.Position
+	open := money.Notional(pos.Qty, money.FromFloat(price).Sub(pos.AvgEntry))
+	return money.FromFloat(e.cfg.Capital).Add(snapshot.RealizedPnL).Add(open)
+}
+
+// buildOrder turns an approved intent into an order; fractional is whether
+// it was sized in fractional shares (see fractional).
+func (e *Engine) buildOrder(symbol string, last float64, top md.TopOfBook, intent strategy.TradeIntent, fractional bool) (broker.OrderRequest, error) {
 	orderType, err := parseOrderType(e.cfg.OrderType)
 	if err != nil {
 		return broker.OrderRequest{}, err
@@ -162,7 +679,7 @@ func (e *Engine) buildOrder(symbol string, price float64, intent strategy.TradeI
 		return broker.OrderRequest{}, err
 	}
 	side := alpaca.Buy
-	if intent.Action == strategy.Sell {
+	if intent.Action == strategy.Sell || intent.Action == strategy.SellShort {
 		side = alpaca.Sell
 	}
 
@@ -176,13 +693,61 @@ func (e *Engine) buildOrder(symbol string, price float64, intent strategy.TradeI
 		ExtendedHours: e.cfg.ExtendedHours,
 	}
 
+	// Notional buys let the broker fill the fractional shares that the
+	// dollar amount buys at execution; assets that only trade whole shares
+	// are bought by quantity.
+	if e.cfg.NotionalOrders && fractional && intent.Action == strategy.Buy {
+		req.Notional = money.Cents(money.Notional(intent.Qty, money.FromFloat(last)))
+		req.Qty = decimal.Zero
+	}
+
 	if orderType == alpaca.Limit {
+		price, err := limitPrice(e.cfg.LimitPricing, e.cfg.LimitOffset, side, last, top)
+		if err != nil {
+			return broker.OrderRequest{}, err
+		}
 		req.LimitPrice = &price
 	}
 
 	return req, nil
 }
 
+// limitPrice picks the limit for an order: the last bar close, joining the
+// near touch, the quote midpoint, or crossing the far touch plus an offset.
+func limitPrice(pricing string, offset float64, side alpaca.Side, last float64, top md.TopOfBook) (money.Price, error) {
+	if pricing == "" || pricing == "last" {
+		return onTick(side, last), nil
+	}
+	if !top.HasQuote() {
+		return money.Price{}, fmt.Errorf("no quote available for limit-pricing=%s", pricing)
+	}
+	switch pricing {
+	case "join":
+		if side == alpaca.Buy {
+			return onTick(side, top.Bid), nil
+		}
+		return onTick(side, top.Ask), nil
+	case "mid":
+		return onTick(side, top.Mid()), nil
+	case "cross":
+		if side == alpaca.Buy {
+			return onTick(side, top.Ask+offset), nil
+		}
+		return onTick(side, top.Bid-offset), nil
+	default:
+		return money.Price{}, fmt.Errorf("unsupported limit pricing: %s", pricing)
+	}
+}
+
+// onTick rounds a limit to a valid tick without making it more
+// aggressive: buys round down and sells round up.
+func onTick(side alpaca.Side, price float64) money.Price {
+	if side == alpaca.Buy {
+		return money.FloorTick(money.FromFloat(price))
+	}
+	return money.CeilTick(money.FromFloat(price))
+}
+
 func (e *Engine) nextClientOrderID() string {
 	seq := atomic.AddUint64(&e.orderSeqNum, 1)
 	return fmt.Sprintf("%s-%d", e.runID, seq)
@@ -0,0 +1,73 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+func decideNotional(t *testing.T, brk *lendingBroker, store *state.Store, close float64) Decision {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Mode = config.ModePaper
+	cfg.Symbol = "SPY"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	cfg.MaxQty = 1
+	cfg.MaxNotional = 200
+	cfg.Cooldown = 0
+	cfg.Sizing = config.SizingNotional
+	cfg.NotionalOrders = true
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	sink := &memorySink{}
+	e := New(cfg, fixedStrategy(strategy.TradeIntent{Action: strategy.Buy}), risk.Gate{}, brk, store, sink, nil, md.NewCalendar(nil), clk)
+	e.OnBar(context.Background(), minuteBar(0, close))
+	if len(sink.decisions) != 1 {
+		t.Fatalf("expected one decision, got %+v", sink.decisions)
+	}
+	return sink.decisions[0]
+}
+
+func TestNotionalOrderBuysFractionalSharesWithinMaxNotional(t *testing.T) {
+	brk := &lendingBroker{fractionable: true}
+	store := state.NewStore(nil)
+	d := decideNotional(t, brk, store, 800)
+	if d.Result != "order_submitted" || d.IntentQty != 0.25 {
+		t.Fatalf("expected 0.25 shares submitted, got %+v", d)
+	}
+	order := brk.orders[0]
+	if !order.Notional.Equal(decimal.NewFromInt(200)) || !order.Qty.IsZero() {
+		t.Fatalf("expected a $200 notional order, got qty %s notional %s", order.Qty, order.Notional)
+	}
+	if pending := store.Snapshot().PendingQty(); !pending.Equal(decimal.RequireFromString("0.25")) {
+		t.Fatalf("expected the notional order to count as 0.25 shares pending, got %s", pending)
+	}
+}
+
+func TestNonFractionableAssetIsBoughtByQuantity(t *testing.T) {
+	brk := &lendingBroker{}
+	d := decideNotional(t, brk, state.NewStore(nil), 80)
+	if d.Result != "order_submitted" || d.IntentQty != 1 {
+		t.Fatalf("expected 1 whole share submitted, got %+v", d)
+	}
+	if order := brk.orders[0]; !order.Qty.Equal(decimal.NewFromInt(1)) || !order.Notional.IsZero() {
+		t.Fatalf("expected a 1 share order, got qty %s notional %s", order.Qty, order.Notional)
+	}
+}
+
+func TestNonFractionableAssetSizesWholeShares(t *testing.T) {
+	brk := &lendingBroker{}
+	d := decideNotional(t, brk, state.NewStore(nil), 800)
+	if d.Result != "rejected" || d.RejectReason != "invalid_quantity" || len(brk.orders) != 0 {
+		t.Fatalf("expected no whole share to fit, got %+v", d)
+	}
+}
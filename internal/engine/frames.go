@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"time"
+
+	"ats/internal/indicator"
+	"ats/internal/md"
+	"ats/internal/strategy"
+)
+
+// BarFlushTimer names the TimerEvent on which the engine completes a
+// session's final bar whose last minute never traded (see md.FlushGrace).
+const BarFlushTimer = "bar_flush"
+
+// frameSeries aggregates the 1m stream into one timeframe and keeps a bounded
+// history of completed bars together with the time each one closed.
+type frameSeries struct {
+	aggregator *md.Aggregator
+	bars       []md.Bar
+	ends       []time.Time
+	limit      int
+}
+
+func newFrameSeries(calendar *md.Calendar, timeframe md.Timeframe, limit int) *frameSeries {
+	return &frameSeries{
+		aggregator: md.NewAggregator(calendar, timeframe),
+		limit:      limit,
+	}
+}
+
+// add feeds a 1m bar and returns the bars of this timeframe it completed.
+func (f *frameSeries) add(bar md.Bar) []md.Bar {
+	return f.keep(f.aggregator.Add(bar))
+}
+
+// flush returns the bars of this timeframe whose bucket ended by now.
+func (f *frameSeries) flush(now time.Time) []md.Bar {
+	return f.keep(f.aggregator.Flush(now))
+}
+
+func (f *frameSeries) keep(completed []md.Bar) []md.Bar {
+	for _, c := range completed {
+		f.bars = append(f.bars, c)
+		f.ends = append(f.ends, f.aggregator.BucketEnd(c))
+		if len(f.bars) > f.limit {
+			f.bars = f.bars[1:]
+			f.ends = f.ends[1:]
+		}
+	}
+	return completed
+}
+
+// frame returns the bars that had closed by asOf.
+func (f *frameSeries) frame(asOf time.Time, smaWindow int) strategy.Frame {
+	n := len(f.bars)
+	for n > 0 && f.ends[n-1].After(asOf) {
+		n--
+	}
+	bars := make([]md.Bar, n)
+	copy(bars, f.bars[:n])
+	frame := strategy.Frame{Timeframe: f.aggregator.Timeframe(), Bars: bars}
+	frame.SMA, _ = indicator.SMA(frame.Closes(), smaWindow)
+	return frame
+}
+
+// requestedTimeframes is the primary timeframe plus any the strategy asks for.
+func requestedTimeframes(primary md.Timeframe, strat strategy.Strategy) []md.Timeframe {
+	timeframes := []md.Timeframe{primary}
+	multi, ok := strat.(strategy.MultiTimeframeStrategy)
+	if !ok {
+		return timeframes
+	}
+	for _, tf := range multi.Timeframes() {
+		duplicate := false
+		for _, existing := range timeframes {
+			if existing == tf {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			timeframes = append(timeframes, tf)
+		}
+	}
+	return timeframes
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+)
+
+func TestFlushEvaluatesTheSessionsLastBarAtTheClose(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mode = config.ModeStream
+	cfg.Symbol = "SPY"
+	cfg.Timeframe = "1h"
+	clk := clock.NewManual(time.Date(2024, 1, 2, 20, 0, 0, 0, time.UTC))
+	sink := &memorySink{}
+	e := New(cfg, fixedStrategy{Action: strategy.Hold}, risk.Gate{}, nopBroker{}, state.NewStore(clk), sink, nil, md.NewCalendar(nil), clk)
+	ctx := context.Background()
+
+	// 15:30 and 15:57 New York; the 15:30 bucket's last minutes never trade.
+	for _, minute := range []int{30, 57} {
+		ts := time.Date(2024, 1, 2, 20, minute, 0, 0, time.UTC)
+		e.OnBar(ctx, md.Bar{Symbol: "SPY", Timestamp: ts.Unix(), Open: 100, High: 100, Low: 100, Close: 100})
+	}
+	if len(sink.decisions) != 0 {
+		t.Fatalf("expected the 15:30 bar to be open, got %+v", sink.decisions)
+	}
+	e.flushBars(ctx, time.Date(2024, 1, 2, 21, 0, 0, 0, time.UTC))
+	if len(sink.decisions) != 0 {
+		t.Fatalf("expected no flush before the close's grace, got %+v", sink.decisions)
+	}
+	e.flushBars(ctx, time.Date(2024, 1, 2, 21, 0, 0, 0, time.UTC).Add(md.FlushGrace))
+	if len(sink.decisions) != 1 || !sink.decisions[0].BarTime.Equal(time.Date(2024, 1, 2, 20, 30, 0, 0, time.UTC)) {
+		t.Fatalf("expected the 15:30 bar at the close, got %+v", sink.decisions)
+	}
+}
@@ -0,0 +1,37 @@
+package engine
+
+import (
+	"testing"
+
+	"ats/internal/md"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+)
+
+func TestLimitPriceRoundsToTickAwayFromCrossing(t *testing.T) {
+	top := md.TopOfBook{Bid: 187.12, Ask: 187.13999}
+	cases := []struct {
+		pricing string
+		side    alpaca.Side
+		last    float64
+		top     md.TopOfBook
+		want    string
+	}{
+		{"last", alpaca.Buy, 187.12999999, top, "187.12"},
+		{"last", alpaca.Sell, 187.12999999, top, "187.13"},
+		{"mid", alpaca.Buy, 0, top, "187.12"},
+		{"mid", alpaca.Sell, 0, top, "187.13"},
+		{"join", alpaca.Sell, 0, top, "187.14"},
+		{"last", alpaca.Buy, 0.123456, top, "0.1234"},
+		{"last", alpaca.Sell, 0.123456, top, "0.1235"},
+	}
+	for _, c := range cases {
+		got, err := limitPrice(c.pricing, 0, c.side, c.last, c.top)
+		if err != nil {
+			t.Fatalf("%s %s: %v", c.pricing, c.side, err)
+		}
+		if got.String() != c.want {
+			t.Fatalf("%s %s %v: limit = %s, want %s", c.pricing, c.side, c.last, got, c.want)
+		}
+	}
+}
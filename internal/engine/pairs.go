@@ -0,0 +1,585 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/event"
+	"ats/internal/indicator"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/sizing"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// PairDecision is the pair side of a decision: the hedge leg's price, the
+// strategy's spread statistics, what each leg did and the P&L of both legs
+// together.
+type PairDecision struct {
+	HedgeSymbol   string        `json:"hedge_symbol"`
+	HedgeClose    float64       `json:"hedge_close"`
+	HedgeRatio    float64       `json:"hedge_ratio,omitempty"`
+	Spread        float64       `json:"spread,omitempty"`
+	ZScore        float64       `json:"zscore,omitempty"`
+	Legs          []LegDecision `json:"legs"`
+	RealizedPnL   float64       `json:"realized_pnl"`
+	UnrealizedPnL float64       `json:"unrealized_pnl"`
+}
+
+// LegDecision is one leg of a pair decision. Position is held before the
+// decision; Intent and Qty are the order it led to, if any.
+type LegDecision struct {
+	Symbol              string          `json:"symbol"`
+	Position            float64         `json:"position"`
+	Target              *float64        `json:"target,omitempty"`
+	Intent              strategy.Action `json:"intent,omitempty"`
+	Qty                 float64         `json:"qty,omitempty"`
+	Result              string          `json:"result,omitempty"`
+	RejectReason        string          `json:"reject_reason,omitempty"`
+	OrderID             string          `json:"order_id,omitempty"`
+	ClientOrderID       string          `json:"client_order_id,omitempty"`
+	UnwindClientOrderID string          `json:"unwind_client_order_id,omitempty"`
+}
+
+// pairTrader is the engine's state for a pairs strategy: the hedge leg's
+// position, both legs' bar series, bars waiting for the other leg's bar of
+// the same time, and two-legged orders that are still working.
+type pairTrader struct {
+	strategy strategy.PairStrategy
+	hedge    *state.Store
+
+	// mu guards the bar series and serializes evaluation, since each leg's
+	// bars may arrive on their own goroutine.
+	mu      sync.Mutex
+	series  map[string]*frameSeries
+	closes  map[string]*md.RingBuffer
+	waiting map[string][]md.Bar
+
+	ordersMu sync.Mutex
+	orders   map[string]*pairOrder
+	last     pairBar
+}
+
+// pairWaitLimit bounds the bars one leg may run ahead of the other; the
+// oldest are dropped beyond it.
+const pairWaitLimit = 1000
+
+// pairBar is a bar of each leg for the same time.
+type pairBar struct {
+	y, x             md.Bar
+	yCloses, xCloses []float64
+	sma              float64
+}
+
+// pairOrder tracks both legs of a pair trade until each is done.
+type pairOrder struct {
+	legs [2]*legOrder
+}
+
+type legOrder struct {
+	symbol        string
+	clientOrderID string
+	side          alpaca.Side
+	qty           decimal.Decimal
+	filled        decimal.Decimal
+	done          bool
+}
+
+// fraction is how much of the leg has filled.
+func (l *legOrder) fraction() decimal.Decimal {
+	if !l.qty.IsPositive() {
+		return decimal.Zero
+	}
+	return l.filled.Div(l.qty)
+}
+
+func newPairTrader(strat strategy.PairStrategy, cfg config.Config, calendar *md.Calendar, timeframe md.Timeframe, clk clock.Clock) *pairTrader {
+	if cfg.AsyncStrategy {
+		slog.Warn("pairs strategies are evaluated inline; async-strategy has no effect")
+	}
+	p := &pairTrader{
+		strategy: strat,
+		hedge:    state.NewStore(clk),
+		series:   make(map[string]*frameSeries),
+		closes:   make(map[string]*md.RingBuffer),
+		waiting:  make(map[string][]md.Bar),
+		orders:   make(map[string]*pairOrder),
+	}
+	for _, symbol := range []string{cfg.Symbol, cfg.PairSymbol} {
+		p.series[symbol] = newFrameSeries(calendar, timeframe, cfg.BarsWindow)
+		p.closes[symbol] = md.NewRingBuffer(cfg.BarsWindow)
+	}
+	return p
+}
+
+// HedgeStore is the hedge leg's state when trading a pair, nil otherwise.
+// The caller loads, reconciles and saves it alongside the engine's store.
+func (e *Engine) HedgeStore() *state.Store {
+	if e.pair == nil {
+		return nil
+	}
+	return e.pair.hedge
+}
+
+// onPairBar aggregates a bar of either leg and evaluates the pair for every
+// time at which both legs have completed a bar. Times where one leg has no
+// bar are skipped, so the two close series stay aligned.
+func (e *Engine) onPairBar(ctx context.Context, bar md.Bar) {
+	p := e.pair
+	series, ok := p.series[bar.Symbol]
+	if !ok {
+		slog.Debug("bar outside the pair ignored", "symbol", bar.Symbol)
+		return
+	}
+	e.storeFor(bar.Symbol).SetLastBarTime(time.Unix(bar.Timestamp, 0).UTC())
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.wait(bar.Symbol, series.add(bar))
+	e.matchPairBars(ctx)
+}
+
+// flushPairBars completes both legs' buckets that ended by now.
+func (e *Engine) flushPairBars(ctx context.Context, now time.Time) {
+	p := e.pair
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for symbol, series := range p.series {
+		p.wait(symbol, series.flush(now))
+	}
+	e.matchPairBars(ctx)
+}
+
+// wait queues completed bars of symbol until the other leg's bar of the
+// same time arrives.
+func (p *pairTrader) wait(symbol string, completed []md.Bar) {
+	for _, bar := range completed {
+		queue := append(p.waiting[symbol], bar)
+		if len(queue) > pairWaitLimit {
+			queue = queue[1:]
+		}
+		p.waiting[symbol] = queue
+	}
+}
+
+// matchPairBars evaluates the pair at every time both legs have a bar for.
+// The caller holds p.mu.
+func (e *Engine) matchPairBars(ctx context.Context) {
+	p := e.pair
+	for {
+		ys, xs := p.waiting[e.cfg.Symbol], p.waiting[e.cfg.PairSymbol]
+		if len(ys) == 0 || len(xs) == 0 {
+			return
+		}
+		// Each leg's bars arrive in order, so the older of the two heads
+		// has no partner coming.
+		y, x := ys[0], xs[0]
+		if y.Timestamp != x.Timestamp {
+			if y.Timestamp < x.Timestamp {
+				p.waiting[e.cfg.Symbol] = ys[1:]
+			} else {
+				p.waiting[e.cfg.PairSymbol] = xs[1:]
+			}
+			continue
+		}
+		p.waiting[e.cfg.Symbol], p.waiting[e.cfg.PairSymbol] = ys[1:], xs[1:]
+		p.closes[e.cfg.Symbol].Add(y.Close)
+		p.closes[e.cfg.PairSymbol].Add(x.Close)
+		pb := pairBar{y: y, x: x, yCloses: p.closes[e.cfg.Symbol].Values(), xCloses: p.closes[e.cfg.PairSymbol].Values()}
+		sma, ok := indicator.SMA(pb.yCloses, e.cfg.SMAWindow)
+		if !ok {
+			sma = y.Close
+		}
+		pb.sma = sma
+		p.ordersMu.Lock()
+		p.last = pb
+		p.ordersMu.Unlock()
+		e.evaluatePair(ctx, pb)
+	}
+}
+
+func (e *Engine) evaluatePair(ctx context.Context, pb pairBar) {
+	p := e.pair
+	e.state.CountBar()
+	p.hedge.CountBar()
+	ySnap, xSnap := e.state.Snapshot(), p.hedge.Snapshot()
+	barTime := time.Unix(pb.y.Timestamp, 0).UTC()
+	decision := Decision{
+		RunID:     e.runID,
+		Timestamp: e.clock.Now(),
+		BarTime:   barTime,
+		Symbol:    pb.y.Symbol,
+		Close:     pb.y.Close,
+		SMA:       pb.sma,
+		Pair:      pairDecision(pb, ySnap, xSnap),
+	}
+
+	if pb.y.Backfilled || pb.x.Backfilled {
+		decision.Backfilled = true
+		decision.Intent = strategy.Hold
+		decision.Reason = "backfilled_bar"
+		decision.Result = "backfilled"
+		e.decisions.Append(decision)
+		return
+	}
+
+	snapshot := strategy.PairSnapshot{
+		Timestamp: barTime,
+		Y:         pairLeg(pb.y.Symbol, pb.yCloses, ySnap),
+		X:         pairLeg(pb.x.Symbol, pb.xCloses, xSnap),
+	}
+	started := time.Now()
+	intent := p.strategy.DecidePair(snapshot)
+	decision.LatencyMs = milliseconds(time.Since(started))
+	decision.Intent = intent.Action
+	decision.Reason = intent.Reason
+	decision.Pair.HedgeRatio = intent.HedgeRatio
+	decision.Pair.Spread = intent.Spread
+	decision.Pair.ZScore = intent.ZScore
+
+	if intent.Action != strategy.Target {
+		decision.Result = "hold"
+		e.decisions.Append(decision)
+		slog.Debug("holding pair", "bar", barTime.Format(time.RFC3339), "zscore", intent.ZScore, "reason", intent.Reason)
+		return
+	}
+	e.tradePair(ctx, &decision, pb, intent, ySnap, xSnap)
+}
+
+// pairLegPlan is one leg of a pair trade on its way to the broker.
+type pairLegPlan struct {
+	bar      md.Bar
+	store    *state.Store
+	snapshot state.Snapshot
+	intent   strategy.TradeIntent
+	leg      *LegDecision
+	order    broker.OrderRequest
+	ref      broker.OrderRef
+}
+
+// tradePair moves both legs to their targets. Both legs must pass the risk
+// gate before either is sent. The sale is sent first, since a short sale is
+// the leg most likely to be refused; if the second leg then fails, the
+// first is canceled and whatever it filled is offset, so the pair is never
+// left half on.
+func (e *Engine) tradePair(ctx context.Context, decision *Decision, pb pairBar, intent strategy.PairIntent, ySnap, xSnap state.Snapshot) {
+	plans := []*pairLegPlan{
+		{bar: pb.y, store: e.state, snapshot: ySnap, leg: &decision.Pair.Legs[0]},
+		{bar: pb.x, store: e.pair.hedge, snapshot: xSnap, leg: &decision.Pair.Legs[1]},
+	}
+	targets := []decimal.Decimal{intent.Y, intent.X}
+	var active []*pairLegPlan
+	var rejections []string
+	for i, plan := range plans {
+		target := targets[i].InexactFloat64()
+		plan.leg.Target = &target
+		var scratch Decision
+		plan.intent = e.size(strategy.TargetPosition(targets[i], intent.Reason), plan.bar.Close, plan.snapshot, nil, false, &scratch)
+		plan.leg.Intent = plan.intent.Action
+		plan.leg.Qty = plan.intent.Qty.InexactFloat64()
+		if plan.intent.Action == strategy.Hold {
+			continue
+		}
+		top, _ := e.quotes.Latest(plan.bar.Symbol)
+		if _, err := e.gate.Evaluate(plan.intent, e.riskContext(ctx, plan.bar.Symbol, plan.intent, plan.bar.Close, top, plan.snapshot)); err != nil {
+			plan.leg.Result = "rejected"
+			plan.leg.RejectReason = err.Error()
+			rejections = append(rejections, plan.bar.Symbol+": "+err.Error())
+			continue
+		}
+		active = append(active, plan)
+	}
+
+	barTime := decision.BarTime.Format(time.RFC3339)
+	switch {
+	case len(rejections) > 0:
+		decision.Result = "rejected"
+		decision.RejectReason = strings.Join(rejections, "; ")
+		e.decisions.Append(*decision)
+		e.publish(ctx, event.RiskTripEvent{At: decision.Timestamp, Sym: decision.Symbol, Intent: string(intent.Action), Reason: decision.RejectReason})
+		slog.Info("pair trade rejected", "bar", barTime, "reason", decision.RejectReason)
+		return
+	case len(active) == 0:
+		decision.Result = "hold"
+		e.decisions.Append(*decision)
+		return
+	case e.cfg.Mode == config.ModeStream:
+		for _, plan := range active {
+			plan.leg.Result = "dry_run"
+		}
+		decision.Result = "dry_run"
+		e.decisions.Append(*decision)
+		slog.Info("dry run pair trade", "bar", barTime, "reason", intent.Reason)
+		return
+	}
+
+	for _, plan := range active {
+		top, _ := e.quotes.Latest(plan.bar.Symbol)
+		order, err := e.buildOrder(plan.bar.Symbol, plan.bar.Close, top, plan.intent, false)
+		if err != nil {
+			decision.Result = "order_build_failed"
+			decision.RejectReason = err.Error()
+			e.decisions.Append(*decision)
+			slog.Error("pair order build failed", "bar", barTime, "symbol", plan.bar.Symbol, "error", err)
+			return
+		}
+		plan.order = order
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].order.Side == alpaca.Sell && active[j].order.Side != alpaca.Sell
+	})
+	if len(active) == 2 {
+		// Tracked before sending, since a leg's fills may be streamed back
+		// before the partner is even placed.
+		e.pair.track(active[0], active[1])
+	}
+
+	var placed []*pairLegPlan
+	for _, plan := range active {
+		ref, err := e.broker.PlaceOrder(ctx, plan.order)
+		if err != nil {
+			plan.leg.Result = "order_failed"
+			plan.leg.RejectReason = err.Error()
+			decision.Result = "order_failed"
+			decision.RejectReason = plan.bar.Symbol + ": " + err.Error()
+			slog.Error("pair order placement failed", "bar", barTime, "symbol", plan.bar.Symbol, "error", err)
+			canceled := make([]broker.OrderRef, len(placed))
+			for i, done := range placed {
+				canceled[i] = e.cancelPlaced(ctx, decision, done)
+			}
+			e.decisions.Append(*decision)
+			// Published after the decision, since the update that completes
+			// a leg records its offset as a decision of its own. Legs never
+			// sent are done with nothing filled.
+			if len(active) == 2 {
+				for _, unsent := range active[len(placed):] {
+					e.checkPairLegs(ctx, event.OrderUpdateEvent{At: decision.Timestamp, Sym: unsent.order.Symbol, ClientOrderID: unsent.order.ClientOrderID, Status: "rejected"})
+				}
+			}
+			for i, done := range placed {
+				e.publishPlaced(ctx, decision.Timestamp, done.order, done.ref)
+				if canceled[i].ID != "" {
+					e.publishPlaced(ctx, decision.Timestamp, done.order, canceled[i])
+				}
+			}
+			return
+		}
+		plan.ref = ref
+		plan.leg.Result = "order_submitted"
+		plan.leg.OrderID = ref.ID
+		plan.leg.ClientOrderID = ref.ClientOrderID
+		plan.store.RecordTrade()
+		placed = append(placed, plan)
+	}
+
+	decision.Result = "order_submitted"
+	e.decisions.Append(*decision)
+	for _, plan := range placed {
+		slog.Info("pair leg submitted", "symbol", plan.bar.Symbol, "side", plan.intent.Action, "qty", plan.intent.Qty, "client_order_id", plan.ref.ClientOrderID)
+		e.publishPlaced(ctx, decision.Timestamp, plan.order, plan.ref)
+	}
+}
+
+// cancelPlaced cancels a leg whose partner could not be sent and returns
+// the leg as of the cancel. The partner is done unfilled, so once the
+// broker reports the leg canceled checkPairLegs offsets whatever it filled,
+// as it does when a partner expires. A leg that cannot be canceled keeps
+// working and is offset the same way when it completes.
+func (e *Engine) cancelPlaced(ctx context.Context, decision *Decision, placed *pairLegPlan) broker.OrderRef {
+	symbol := placed.bar.Symbol
+	canceler, ok := e.broker.(Canceler)
+	if !ok {
+		decision.Result = "leg_working"
+		slog.Warn("broker cannot cancel; pair leg left working", "symbol", symbol, "client_order_id", placed.ref.ClientOrderID)
+		return broker.OrderRef{}
+	}
+	ref, err := canceler.CancelOrder(ctx, placed.ref.ID)
+	if err != nil {
+		decision.Result = "leg_cancel_failed"
+		decision.RejectReason += "; cancel " + symbol + ": " + err.Error()
+		e.publish(ctx, event.RiskTripEvent{At: decision.Timestamp, Sym: symbol, Intent: "cancel", Reason: err.Error()})
+		return broker.OrderRef{}
+	}
+	slog.Warn("pair leg canceled", "symbol", symbol, "status", ref.Status, "filled_qty", ref.FilledQty, "client_order_id", ref.ClientOrderID)
+	decision.Result = "leg_canceled"
+	placed.leg.Result = "order_canceled"
+	return ref
+}
+
+// unwind sends a market order that offsets qty of an order on side. A
+// failure is published as a risk trip, since the pair is left half on.
+// Offsets only shrink a half-hedged position, so they skip the risk gate,
+// whose cooldown and open-order checks would hold them behind the pair's
+// own orders; the kill switch still stops them.
+func (e *Engine) unwind(ctx context.Context, at time.Time, symbol string, side alpaca.Side, qty decimal.Decimal) (broker.OrderRef, error) {
+	if e.cfg.KillSwitch {
+		slog.Error("pair leg unwind blocked", "symbol", symbol, "qty", qty, "reason", "kill_switch_enabled")
+		e.publish(ctx, event.RiskTripEvent{At: at, Sym: symbol, Intent: "unwind", Reason: "kill_switch_enabled"})
+		return broker.OrderRef{}, fmt.Errorf("kill_switch_enabled")
+	}
+	tif, err := parseTimeInForce(e.cfg.TimeInForce)
+	if err != nil {
+		return broker.OrderRef{}, err
+	}
+	req := broker.OrderRequest{
+		Symbol:        symbol,
+		Qty:           qty,
+		Side:          alpaca.Buy,
+		Type:          alpaca.Market,
+		TimeInForce:   tif,
+		ClientOrderID: e.nextClientOrderID(),
+	}
+	if side == alpaca.Buy {
+		req.Side = alpaca.Sell
+	}
+	ref, err := e.broker.PlaceOrder(ctx, req)
+	if err != nil {
+		slog.Error("pair leg unwind failed", "symbol", symbol, "side", req.Side, "qty", qty, "error", err)
+		e.publish(ctx, event.RiskTripEvent{At: at, Sym: symbol, Intent: "unwind", Reason: err.Error()})
+		return broker.OrderRef{}, err
+	}
+	slog.Warn("pair leg unwound", "symbol", symbol, "side", req.Side, "qty", qty, "client_order_id", ref.ClientOrderID)
+	e.storeFor(symbol).RecordTrade()
+	e.publishPlaced(ctx, at, req, ref)
+	return ref, nil
+}
+
+func (e *Engine) publishPlaced(ctx context.Context, at time.Time, req broker.OrderRequest, ref broker.OrderRef) {
+	e.publish(ctx, event.OrderUpdateEvent{
+		At:            at,
+		Sym:           req.Symbol,
+		OrderID:       ref.ID,
+		ClientOrderID: ref.ClientOrderID,
+		Status:        ref.Status,
+		Side:          string(req.Side),
+		Qty:           req.Qty,
+		FilledQty:     ref.FilledQty,
+	})
+}
+
+// track follows both legs of a pair trade by client order ID.
+func (p *pairTrader) track(a, b *pairLegPlan) {
+	order := &pairOrder{}
+	for i, plan := range []*pairLegPlan{a, b} {
+		order.legs[i] = &legOrder{symbol: plan.order.Symbol, clientOrderID: plan.order.ClientOrderID, side: plan.order.Side, qty: plan.order.Qty}
+	}
+	p.ordersMu.Lock()
+	defer p.ordersMu.Unlock()
+	for _, leg := range order.legs {
+		p.orders[leg.clientOrderID] = order
+	}
+}
+
+// checkPairLegs follows both legs of a pair trade. Once neither is working,
+// a leg that filled further than its partner (say one filled and the other
+// expired) has the excess offset, so the position stays hedged.
+func (e *Engine) checkPairLegs(ctx context.Context, update event.OrderUpdateEvent) {
+	p := e.pair
+	p.ordersMu.Lock()
+	order, ok := p.orders[update.ClientOrderID]
+	if !ok {
+		p.ordersMu.Unlock()
+		return
+	}
+	for _, leg := range order.legs {
+		if leg.clientOrderID == update.ClientOrderID {
+			// Updates can arrive out of order, e.g. the engine's own "new"
+			// after the stream's fill, so neither goes backwards.
+			leg.filled = decimal.Max(leg.filled, update.FilledQty)
+			leg.done = leg.done || !event.OrderOpen(update.Status)
+		}
+	}
+	if !order.legs[0].done || !order.legs[1].done {
+		p.ordersMu.Unlock()
+		return
+	}
+	for _, leg := range order.legs {
+		delete(p.orders, leg.clientOrderID)
+	}
+	last := p.last
+	p.ordersMu.Unlock()
+
+	over, under := order.legs[0], order.legs[1]
+	if over.fraction().LessThan(under.fraction()) {
+		over, under = under, over
+	}
+	excess := over.filled.Sub(over.qty.Mul(under.fraction())).Round(sizing.FractionalPlaces)
+	if over.qty.IsInteger() {
+		// Whole-share legs are offset in whole shares.
+		excess = excess.Round(0)
+	}
+	if !excess.IsPositive() {
+		return
+	}
+
+	ySnap, xSnap := e.state.Snapshot(), p.hedge.Snapshot()
+	decision := Decision{
+		RunID:     e.runID,
+		Timestamp: e.clock.Now(),
+		BarTime:   time.Unix(last.y.Timestamp, 0).UTC(),
+		Symbol:    last.y.Symbol,
+		Close:     last.y.Close,
+		SMA:       last.sma,
+		IntentQty: excess.InexactFloat64(),
+		Reason:    fmt.Sprintf("leg_risk: %s filled %s of %s, %s filled %s of %s", over.symbol, over.filled, over.qty, under.symbol, under.filled, under.qty),
+		Pair:      pairDecision(last, ySnap, xSnap),
+	}
+	decision.Intent = strategy.Sell
+	if over.side == alpaca.Sell {
+		decision.Intent = strategy.Buy
+	}
+	leg := &decision.Pair.Legs[0]
+	if over.symbol != last.y.Symbol {
+		leg = &decision.Pair.Legs[1]
+	}
+	leg.Intent, leg.Qty = decision.Intent, decision.IntentQty
+	ref, err := e.unwind(ctx, decision.Timestamp, over.symbol, over.side, excess)
+	if err != nil {
+		decision.Result = "leg_unwind_failed"
+		decision.RejectReason = err.Error()
+	} else {
+		decision.Result = "leg_unwound"
+		leg.Result = "order_submitted"
+		leg.UnwindClientOrderID = ref.ClientOrderID
+	}
+	e.decisions.Append(decision)
+}
+
+// pairDecision values both legs at pb's closes.
+func pairDecision(pb pairBar, ySnap, xSnap state.Snapshot) *PairDecision {
+	realized := ySnap.RealizedPnL.Add(xSnap.RealizedPnL)
+	unrealized := money.Notional(ySnap.Position.Qty, money.FromFloat(pb.y.Close).Sub(ySnap.Position.AvgEntry)).
+		Add(money.Notional(xSnap.Position.Qty, money.FromFloat(pb.x.Close).Sub(xSnap.Position.AvgEntry)))
+	return &PairDecision{
+		HedgeSymbol: pb.x.Symbol,
+		HedgeClose:  pb.x.Close,
+		Legs: []LegDecision{
+			{Symbol: pb.y.Symbol, Position: ySnap.Position.Qty.InexactFloat64()},
+			{Symbol: pb.x.Symbol, Position: xSnap.Position.Qty.InexactFloat64()},
+		},
+		RealizedPnL:   realized.InexactFloat64(),
+		UnrealizedPnL: unrealized.InexactFloat64(),
+	}
+}
+
+func pairLeg(symbol string, closes []float64, snapshot state.Snapshot) strategy.PairLeg {
+	return strategy.PairLeg{
+		Symbol:      symbol,
+		Closes:      closes,
+		PositionQty: snapshot.Position.Qty,
+		PendingQty:  snapshot.PendingQty(),
+		AvgEntry:    snapshot.Position.AvgEntry.InexactFloat64(),
+	}
+}
@@ -0,0 +1,248 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/event"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/shopspring/decimal"
+)
+
+type fixedPair strategy.PairIntent
+
+func (s fixedPair) Decide(snapshot strategy.MarketSnapshot) strategy.TradeIntent {
+	return strategy.TradeIntent{Action: strategy.Hold}
+}
+
+func (s fixedPair) DecidePair(snapshot strategy.PairSnapshot) strategy.PairIntent {
+	return strategy.PairIntent(s)
+}
+
+// pairBroker records every order and refuses those in symbol fail. Canceled
+// orders report cancelFilled as filled, and cancelStatus ("canceled" when
+// empty) as their status.
+type pairBroker struct {
+	fail         string
+	orders       []broker.OrderRequest
+	canceled     []string
+	cancelFilled decimal.Decimal
+	cancelStatus string
+}
+
+func (b *pairBroker) PlaceOrder(ctx context.Context, req broker.OrderRequest) (broker.OrderRef, error) {
+	b.orders = append(b.orders, req)
+	if req.Symbol == b.fail {
+		return broker.OrderRef{}, errors.New("insufficient buying power")
+	}
+	return broker.OrderRef{ID: req.ClientOrderID, ClientOrderID: req.ClientOrderID, Status: "new"}, nil
+}
+
+func (b *pairBroker) CancelOrder(ctx context.Context, orderID string) (broker.OrderRef, error) {
+	b.canceled = append(b.canceled, orderID)
+	status := b.cancelStatus
+	if status == "" {
+		status = "canceled"
+	}
+	return broker.OrderRef{ID: orderID, ClientOrderID: orderID, Status: status, FilledQty: b.cancelFilled}, nil
+}
+
+func (b *pairBroker) Asset(ctx context.Context, symbol string) (broker.Asset, error) {
+	return broker.Asset{Symbol: symbol, Shortable: true, EasyToBorrow: true}, nil
+}
+
+// newPairEngine trades KO against PEP with a strategy that sells 2 KO and
+// buys 1 PEP, and feeds it one bar of each.
+func newPairEngine(t *testing.T, brk *pairBroker) (*Engine, *memorySink) {
+	t.Helper()
+	return newPairEngineOn(t, brk, nil)
+}
+
+// newPairEngineOn is newPairEngine with the engine subscribed to bus, when
+// not nil, and the bars published on it.
+func newPairEngineOn(t *testing.T, brk *pairBroker, bus *event.Bus) (*Engine, *memorySink) {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Mode = config.ModePaper
+	cfg.Symbol, cfg.PairSymbol = "KO", "PEP"
+	cfg.MaxQty = 5
+	cfg.MaxNotional = 10000
+	cfg.Cooldown = 0
+	cfg.AllowShort = true
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	sink := &memorySink{}
+	intent := fixedPair{Action: strategy.Target, Y: strategy.Shares(-2), X: strategy.Shares(1), Reason: "short_spread"}
+	e := New(cfg, intent, risk.Gate{}, brk, state.NewStore(clk), sink, nil, md.NewCalendar(nil), clk)
+	onBar := e.OnBar
+	if bus != nil {
+		e.Subscribe(bus)
+		onBar = func(ctx context.Context, bar md.Bar) { bus.Publish(ctx, event.BarEvent{Bar: bar}) }
+	}
+
+	ko, pep := minuteBar(0, 60), minuteBar(0, 170)
+	ko.Symbol, pep.Symbol = "KO", "PEP"
+	onBar(context.Background(), ko)
+	if len(sink.decisions) != 0 {
+		t.Fatalf("expected no decision before the hedge bar, got %+v", sink.decisions)
+	}
+	onBar(context.Background(), pep)
+	if len(sink.decisions) == 0 {
+		t.Fatalf("expected a pair decision")
+	}
+	return e, sink
+}
+
+func TestPairSendsBothLegsShortSaleFirst(t *testing.T) {
+	brk := &pairBroker{}
+	_, sink := newPairEngine(t, brk)
+	d := sink.decisions[0]
+	if d.Result != "order_submitted" || d.Pair == nil || d.Pair.HedgeSymbol != "PEP" || d.Pair.HedgeClose != 170 {
+		t.Fatalf("unexpected decision %+v", d)
+	}
+	if len(brk.orders) != 2 || brk.orders[0].Symbol != "KO" || brk.orders[0].Side != "sell" || brk.orders[1].Symbol != "PEP" {
+		t.Fatalf("expected the KO short sale before the PEP buy, got %+v", brk.orders)
+	}
+	if legs := d.Pair.Legs; legs[0].Intent != strategy.SellShort || legs[0].Qty != 2 || legs[1].Intent != strategy.Buy || legs[1].Qty != 1 {
+		t.Fatalf("unexpected legs %+v", legs)
+	}
+}
+
+func TestPairLegFailureCancelsTheSentLeg(t *testing.T) {
+	brk := &pairBroker{fail: "PEP"}
+	_, sink := newPairEngine(t, brk)
+	d := sink.decisions[0]
+	if d.Result != "leg_canceled" || d.Pair.Legs[0].Result != "order_canceled" || d.Pair.Legs[1].Result != "order_failed" {
+		t.Fatalf("expected the KO leg to be canceled, got %+v", d)
+	}
+	if len(brk.canceled) != 1 || brk.canceled[0] != brk.orders[0].ClientOrderID {
+		t.Fatalf("expected the KO order to be canceled, got %v", brk.canceled)
+	}
+	if len(brk.orders) != 2 || len(sink.decisions) != 1 {
+		t.Fatalf("expected nothing to offset for an unfilled leg, got %+v", brk.orders)
+	}
+}
+
+func TestPairLegFailureOffsetsOnlyTheFilledQty(t *testing.T) {
+	brk := &pairBroker{fail: "PEP", cancelFilled: strategy.Shares(1)}
+	_, sink := newPairEngine(t, brk)
+	if len(sink.decisions) != 2 || sink.decisions[0].Result != "leg_canceled" {
+		t.Fatalf("expected the cancel then the offset, got %+v", sink.decisions)
+	}
+	unwind := brk.orders[len(brk.orders)-1]
+	if len(brk.orders) != 3 || unwind.Symbol != "KO" || unwind.Side != "buy" || unwind.Type != "market" || !unwind.Qty.Equal(strategy.Shares(1)) {
+		t.Fatalf("expected a market buy of the 1 KO filled before the cancel, got %+v", brk.orders)
+	}
+	if d := sink.decisions[1]; d.Result != "leg_unwound" || d.IntentQty != 1 {
+		t.Fatalf("expected a leg_unwound decision for 1 share, got %+v", d)
+	}
+}
+
+func TestPairLegCanceledOnTheStreamIsOffset(t *testing.T) {
+	// Alpaca acknowledges a cancel as pending_cancel; the leg is only done
+	// once the trade-updates stream reports it canceled.
+	brk := &pairBroker{fail: "PEP", cancelStatus: "pending_cancel"}
+	bus := event.NewBus()
+	e, sink := newPairEngineOn(t, brk, bus)
+	if len(sink.decisions) != 1 || sink.decisions[0].Result != "leg_canceled" || len(e.pair.orders) == 0 {
+		t.Fatalf("expected the KO leg to wait for its cancel, got %+v", sink.decisions)
+	}
+
+	ko := brk.orders[0]
+	order := broker.OrderRef{ID: ko.ClientOrderID, ClientOrderID: ko.ClientOrderID, Symbol: "KO", Side: ko.Side, Qty: ko.Qty}
+	at := time.Date(2024, 1, 2, 15, 2, 0, 0, time.UTC)
+	updates := scriptedStream{
+		{At: at, Event: "partial_fill", Order: withStatus(order, "partially_filled", "1"), Qty: strategy.Shares(1), Price: money.FromFloat(60)},
+		{At: at.Add(time.Second), Event: "canceled", Order: withStatus(order, "canceled", "1")},
+	}
+	if err := RunTradeUpdates(context.Background(), bus, updates, "KO", "PEP"); err != nil {
+		t.Fatal(err)
+	}
+
+	unwind := brk.orders[len(brk.orders)-1]
+	if len(brk.orders) != 3 || unwind.Symbol != "KO" || unwind.Side != "buy" || !unwind.Qty.Equal(strategy.Shares(1)) {
+		t.Fatalf("expected a buy of the 1 KO filled before the cancel, got %+v", brk.orders)
+	}
+	if d := sink.decisions[len(sink.decisions)-1]; d.Result != "leg_unwound" || d.IntentQty != 1 {
+		t.Fatalf("expected a leg_unwound decision for 1 share, got %+v", d)
+	}
+	if !e.state.Snapshot().Position.Qty.Equal(strategy.Shares(-1)) || len(e.pair.orders) != 0 {
+		t.Fatalf("expected the streamed fill applied and the pair no longer tracked, got %s with %d tracked", e.state.Snapshot().Position.Qty, len(e.pair.orders))
+	}
+}
+
+func TestPairOneSidedFillIsUnwound(t *testing.T) {
+	brk := &pairBroker{}
+	e, sink := newPairEngine(t, brk)
+	ctx := context.Background()
+	ko, pep := brk.orders[0], brk.orders[1]
+	at := time.Date(2024, 1, 2, 15, 2, 0, 0, time.UTC)
+
+	e.onFill(event.FillEvent{At: at, Sym: "KO", Side: "sell", Qty: ko.Qty, Price: money.FromFloat(60), ClientOrderID: ko.ClientOrderID})
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "KO", ClientOrderID: ko.ClientOrderID, Status: "filled", Side: "sell", Qty: ko.Qty, FilledQty: ko.Qty})
+	if len(brk.orders) != 2 {
+		t.Fatalf("expected no unwind while the PEP leg works, got %+v", brk.orders)
+	}
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "PEP", ClientOrderID: pep.ClientOrderID, Status: "expired", Side: "buy", Qty: pep.Qty})
+
+	if len(brk.orders) != 3 || brk.orders[2].Symbol != "KO" || brk.orders[2].Side != "buy" || !brk.orders[2].Qty.Equal(strategy.Shares(2)) {
+		t.Fatalf("expected the filled KO leg to be bought back, got %+v", brk.orders)
+	}
+	d := sink.decisions[len(sink.decisions)-1]
+	if d.Result != "leg_unwound" || d.Intent != strategy.Buy || d.IntentQty != 2 {
+		t.Fatalf("expected a leg_unwound decision, got %+v", d)
+	}
+	if e.state.Snapshot().Position.Qty.IsZero() || !e.HedgeStore().Snapshot().Position.Qty.IsZero() {
+		t.Fatalf("expected the fill on KO only")
+	}
+}
+
+func TestPairFractionalLegsOffsetTheFractionalExcess(t *testing.T) {
+	brk := &pairBroker{}
+	e, sink := newPairEngine(t, brk)
+	ctx := context.Background()
+	half, quarter := decimal.RequireFromString("0.5"), decimal.RequireFromString("0.25")
+	e.pair.track(
+		&pairLegPlan{order: broker.OrderRequest{Symbol: "KO", Side: "sell", Qty: half, ClientOrderID: "ko"}},
+		&pairLegPlan{order: broker.OrderRequest{Symbol: "PEP", Side: "buy", Qty: strategy.Shares(1), ClientOrderID: "pep"}},
+	)
+	at := time.Date(2024, 1, 2, 15, 2, 0, 0, time.UTC)
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "KO", ClientOrderID: "ko", Status: "filled", Side: "sell", Qty: half, FilledQty: half})
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "PEP", ClientOrderID: "pep", Status: "expired", Side: "buy", Qty: strategy.Shares(1), FilledQty: half})
+
+	// PEP filled half, so half of the KO leg stays hedged.
+	unwind := brk.orders[len(brk.orders)-1]
+	if unwind.Symbol != "KO" || unwind.Side != "buy" || !unwind.Qty.Equal(quarter) {
+		t.Fatalf("expected a buy of 0.25 KO, got %+v", unwind)
+	}
+	if d := sink.decisions[len(sink.decisions)-1]; d.Result != "leg_unwound" || d.IntentQty != 0.25 {
+		t.Fatalf("expected a leg_unwound decision for 0.25, got %+v", d)
+	}
+}
+
+func TestPairUnwindHonoursTheKillSwitch(t *testing.T) {
+	brk := &pairBroker{}
+	e, sink := newPairEngine(t, brk)
+	e.cfg.KillSwitch = true
+	ctx := context.Background()
+	ko, pep := brk.orders[0], brk.orders[1]
+	at := time.Date(2024, 1, 2, 15, 2, 0, 0, time.UTC)
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "KO", ClientOrderID: ko.ClientOrderID, Status: "filled", Side: "sell", Qty: ko.Qty, FilledQty: ko.Qty})
+	e.onOrderUpdate(ctx, event.OrderUpdateEvent{At: at, Sym: "PEP", ClientOrderID: pep.ClientOrderID, Status: "expired", Side: "buy", Qty: pep.Qty})
+
+	if len(brk.orders) != 2 {
+		t.Fatalf("expected no unwind order with the kill switch on, got %+v", brk.orders)
+	}
+	if d := sink.decisions[len(sink.decisions)-1]; d.Result != "leg_unwind_failed" || d.RejectReason != "kill_switch_enabled" {
+		t.Fatalf("expected the unwind to be blocked, got %+v", d)
+	}
+}
@@ -4,40 +4,61 @@ import (
 	"context"
 	"errors"
 	"log/slog"
-	"time"
 
 	"ats/internal/broker"
+	"ats/internal/event"
 	"ats/internal/state"
 	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
 )
 
-func ReconcileLoop(ctx context.Context, brokerClient *broker.Client, store *state.Store, symbol string, interval time.Duration) {
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+// ReconcileTimer names the TimerEvent that triggers reconciliation.
+const ReconcileTimer = "reconcile"
 
-	for {
-		select {
-		case <-ctx.Done():
-			return
-		case <-ticker.C:
+// Reconcilable is the broker state reconciliation reads; *broker.Client
+// implements it.
+type Reconcilable interface {
+	OpenOrders(ctx context.Context) ([]broker.OrderRef, error)
+	Position(ctx context.Context, symbol string) (broker.Position, error)
+	Account(ctx context.Context) (broker.Account, error)
+}
+
+// SubscribeReconciler refreshes symbol's open orders and position from the
+// broker on every ReconcileTimer event (see event.RunTimer).
+func SubscribeReconciler(bus *event.Bus, brokerClient Reconcilable, store *state.Store, symbol string) {
+	bus.Subscribe(event.KindTimer, func(ctx context.Context, ev event.Event) {
+		if ev.(event.TimerEvent).Name == ReconcileTimer {
 			reconcileOnce(ctx, brokerClient, store, symbol)
 		}
-	}
+	})
 }
 
-func reconcileOnce(ctx context.Context, brokerClient *broker.Client, store *state.Store, symbol string) {
+func reconcileOnce(ctx context.Context, brokerClient Reconcilable, store *state.Store, symbol string) {
 	slog.Info("reconciliation started", "symbol", symbol)
 
 	orders, err := brokerClient.OpenOrders(ctx)
 	if err != nil {
 		slog.Error("reconcile open orders failed", "error", err)
 	} else {
+		known := store.Snapshot().OpenOrders
 		openOrders := make(map[string]state.OpenOrder, len(orders))
 		for _, order := range orders {
+			if order.Symbol != symbol {
+				continue
+			}
+			// Notional orders report no quantity; keep the shares they were
+			// sized at. Ones we have no estimate for stay out of pending
+			// quantities.
+			qty := order.Qty
+			if qty.IsZero() && order.Notional.IsPositive() {
+				qty = known[order.ClientOrderID].Qty
+			}
 			openOrders[order.ClientOrderID] = state.OpenOrder{
 				ClientOrderID: order.ClientOrderID,
 				OrderID:       order.ID,
 				Status:        order.Status,
+				Side:          string(order.Side),
+				Qty:           qty,
+				FilledQty:     order.FilledQty,
 			}
 		}
 		store.SetOpenOrders(openOrders)
@@ -49,7 +70,7 @@ func reconcileOnce(ctx context.Context, brokerClient *broker.Client, store *stat
 		var apiErr *alpaca.APIError
 		if errors.As(err, &apiErr) && apiErr.StatusCode == 404 {
 			slog.Info("reconciled position", "symbol", symbol, "qty", 0, "status", "no_position")
-			store.UpdatePosition(state.Position{Qty: 0, AvgEntry: 0})
+			store.UpdatePosition(state.Position{})
 		} else {
 			slog.Error("reconcile position failed", "error", err)
 		}
@@ -62,6 +83,7 @@ func reconcileOnce(ctx context.Context, brokerClient *broker.Client, store *stat
 	if err != nil {
 		slog.Error("reconcile account failed", "error", err)
 	} else {
+		store.SetEquity(account.Equity)
 		slog.Info("reconciled account", "equity", account.Equity, "buying_power", account.BuyingPower)
 	}
 }
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+)
+
+type fixedStrategy strategy.TradeIntent
+
+func (s fixedStrategy) Decide(snapshot strategy.MarketSnapshot) strategy.TradeIntent {
+	return strategy.TradeIntent(s)
+}
+
+// lendingBroker records orders and answers asset lookups.
+type lendingBroker struct {
+	easyToBorrow bool
+	fractionable bool
+	orders       []broker.OrderRequest
+}
+
+func (b *lendingBroker) PlaceOrder(ctx context.Context, req broker.OrderRequest) (broker.OrderRef, error) {
+	b.orders = append(b.orders, req)
+	return broker.OrderRef{ID: "1", ClientOrderID: req.ClientOrderID, Status: "new"}, nil
+}
+
+func (b *lendingBroker) Asset(ctx context.Context, symbol string) (broker.Asset, error) {
+	return broker.Asset{Symbol: symbol, Shortable: true, EasyToBorrow: b.easyToBorrow, Fractionable: b.fractionable}, nil
+}
+
+func decideShort(t *testing.T, intent strategy.TradeIntent, position int, brk *lendingBroker) Decision {
+	t.Helper()
+	cfg := config.Default()
+	cfg.Mode = config.ModePaper
+	cfg.Symbol = "SPY"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	cfg.MaxQty = 5
+	cfg.MaxShortQty = 3
+	cfg.MaxNotional = 10000
+	cfg.Cooldown = 0
+	cfg.AllowShort = true
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	store := state.NewStore(clk)
+	store.UpdatePosition(state.Position{Qty: strategy.Shares(position), AvgEntry: money.FromFloat(100)})
+	sink := &memorySink{}
+	e := New(cfg, fixedStrategy(intent), risk.Gate{}, brk, store, sink, nil, md.NewCalendar(nil), clk)
+	e.OnBar(context.Background(), minuteBar(0, 100))
+	if len(sink.decisions) != 1 {
+		t.Fatalf("expected one decision, got %+v", sink.decisions)
+	}
+	return sink.decisions[0]
+}
+
+func TestShortSaleFromLongClosesTheLongFirst(t *testing.T) {
+	brk := &lendingBroker{easyToBorrow: true}
+	d := decideShort(t, strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, 2, brk)
+	if d.Intent != strategy.Sell || d.IntentQty != 2 || !strings.Contains(d.Sizing, "reversal") {
+		t.Fatalf("expected a reversal sell of 2, got %+v", d)
+	}
+	if len(brk.orders) != 1 || brk.orders[0].Side != "sell" || !brk.orders[0].Qty.Equal(strategy.Shares(2)) {
+		t.Fatalf("expected a sell order for 2, got %+v", brk.orders)
+	}
+}
+
+func TestShortSaleNeedsEasyToBorrow(t *testing.T) {
+	brk := &lendingBroker{}
+	d := decideShort(t, strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, 0, brk)
+	if d.Result != "rejected" || d.RejectReason != "not_easy_to_borrow" || len(brk.orders) != 0 {
+		t.Fatalf("expected a locate rejection, got %+v", d)
+	}
+}
+
+func TestShortSaleIsCappedToMaxShortQty(t *testing.T) {
+	brk := &lendingBroker{easyToBorrow: true}
+	d := decideShort(t, strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(5)}, -1, brk)
+	if d.Intent != strategy.SellShort || d.IntentQty != 2 || d.Result != "order_submitted" {
+		t.Fatalf("expected a short sale of 2 within max-short-qty, got %+v", d)
+	}
+	if brk.orders[0].Side != "sell" {
+		t.Fatalf("expected a sell order, got %+v", brk.orders[0])
+	}
+}
+
+func TestTargetBelowZeroCoversOrShorts(t *testing.T) {
+	brk := &lendingBroker{easyToBorrow: true}
+	d := decideShort(t, strategy.TargetPosition(strategy.Shares(-3), "short_target"), -1, brk)
+	if d.Intent != strategy.SellShort || d.IntentQty != 2 {
+		t.Fatalf("expected to short 2 more, got %+v", d)
+	}
+	d = decideShort(t, strategy.TargetPosition(strategy.Shares(2), "long_target"), -1, brk)
+	if d.Intent != strategy.BuyToCover || d.IntentQty != 1 || !strings.Contains(d.Sizing, "reversal") {
+		t.Fatalf("expected to cover 1 before buying, got %+v", d)
+	}
+}
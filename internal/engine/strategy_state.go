@@ -0,0 +1,47 @@
+package engine
+
+import (
+	"log/slog"
+
+	"ats/internal/strategy"
+)
+
+// restoreStrategyState hands a Stateful strategy the state saved in the
+// store's checkpoint. State saved by a different strategy, or that fails to
+// load, is dropped and the strategy starts fresh.
+func (e *Engine) restoreStrategyState() {
+	stateful, ok := e.strategy.(strategy.Stateful)
+	if !ok {
+		return
+	}
+	saved := e.state.Snapshot().Strategy
+	if saved == nil || len(saved.State) == 0 || string(saved.State) == "null" {
+		return
+	}
+	if saved.Name != e.cfg.Strategy {
+		slog.Warn("strategy state ignored", "saved_strategy", saved.Name, "strategy", e.cfg.Strategy)
+		return
+	}
+	if err := stateful.UnmarshalState(saved.State); err != nil {
+		slog.Error("strategy state restore failed, starting fresh", "strategy", saved.Name, "error", err)
+		return
+	}
+	slog.Info("strategy state restored", "strategy", saved.Name, "bytes", len(saved.State))
+}
+
+// SaveStrategyState records a Stateful strategy's state in the store so
+// the next checkpoint carries it. Call it after Close, once the strategy is
+// no longer deciding.
+func (e *Engine) SaveStrategyState() error {
+	stateful, ok := e.strategy.(strategy.Stateful)
+	if !ok {
+		return nil
+	}
+	data, err := stateful.MarshalState()
+	if err != nil {
+		return err
+	}
+	e.state.SetStrategyState(e.cfg.Strategy, data)
+	slog.Info("strategy state saved", "strategy", e.cfg.Strategy, "bytes", len(data))
+	return nil
+}
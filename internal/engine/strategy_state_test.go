@@ -0,0 +1,59 @@
+package engine
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+)
+
+func TestStrategyStateSurvivesCheckpoint(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mode = config.ModeStream
+	cfg.Symbol = "SPY"
+	cfg.Strategy = "random_noise"
+	cfg.SMAWindow = 2
+	cfg.BarsWindow = 5
+	path := filepath.Join(t.TempDir(), "checkpoint.json")
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+
+	run := func(bars int) (*strategy.RandomNoise, *memorySink) {
+		store := state.NewStore(clk)
+		_ = store.Load(path)
+		strat := strategy.NewRandomNoise(1)
+		sink := &memorySink{}
+		e := New(cfg, strat, risk.Gate{}, nopBroker{}, store, sink, nil, md.NewCalendar(nil), clk)
+		for i := 0; i < bars; i++ {
+			e.OnBar(context.Background(), minuteBar(i, 100))
+		}
+		if err := e.SaveStrategyState(); err != nil {
+			t.Fatalf("save strategy state: %v", err)
+		}
+		if err := store.Save(path); err != nil {
+			t.Fatalf("save checkpoint: %v", err)
+		}
+		return strat, sink
+	}
+
+	run(2)
+	_, sink := run(1)
+	// Fresh, the first bar would be a hold; restored, it is the third step
+	// of the cycle.
+	if len(sink.decisions) != 1 || sink.decisions[0].Reason == "random_hold" {
+		t.Fatalf("expected the restored cycle to continue, got %+v", sink.decisions)
+	}
+
+	// State saved under another strategy name is never restored.
+	cfg.Strategy = "random_alternating"
+	strat, _ := run(0)
+	if data, _ := strat.MarshalState(); string(data) != `{"trade_count":0}` {
+		t.Fatalf("expected state saved by another strategy to be ignored, got %s", data)
+	}
+}
@@ -0,0 +1,72 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/money"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+)
+
+type targetStrategy int
+
+func (s targetStrategy) Decide(snapshot strategy.MarketSnapshot) strategy.TradeIntent {
+	return strategy.TargetPosition(strategy.Shares(int(s)), "fixed_target")
+}
+
+func TestTargetNetsAgainstPositionAndOpenOrders(t *testing.T) {
+	cases := []struct {
+		name    string
+		target  int
+		pending []state.OpenOrder
+		action  strategy.Action
+		qty     int
+		result  string
+		reject  string
+	}{
+		{"buy the difference", 5, nil, strategy.Buy, 3, "dry_run", ""},
+		{"sell down", 1, nil, strategy.Sell, 1, "dry_run", ""},
+		{"in-flight order reaches target", 4, []state.OpenOrder{{ClientOrderID: "a", Side: "buy", Qty: strategy.Shares(3), FilledQty: strategy.Shares(1)}}, strategy.Hold, 0, "hold", ""},
+		{"partially covered by open order", 6, []state.OpenOrder{{ClientOrderID: "a", Side: "buy", Qty: strategy.Shares(3), FilledQty: strategy.Shares(1)}}, strategy.Buy, 2, "rejected", "open_order_exists"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cfg := config.Default()
+			cfg.Mode = config.ModeStream
+			cfg.Symbol = "SPY"
+			cfg.SMAWindow = 2
+			cfg.BarsWindow = 5
+			cfg.MaxQty = 10
+			cfg.MaxNotional = 10000
+			cfg.Cooldown = 0
+			clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+			store := state.NewStore(clk)
+			store.UpdatePosition(state.Position{Qty: strategy.Shares(2), AvgEntry: money.FromFloat(100)})
+			for _, order := range c.pending {
+				store.SetOpenOrder(order)
+			}
+			sink := &memorySink{}
+			e := New(cfg, targetStrategy(c.target), risk.Gate{}, nopBroker{}, store, sink, nil, md.NewCalendar(nil), clk)
+			ctx := context.Background()
+			e.OnBar(ctx, minuteBar(0, 100))
+			e.OnBar(ctx, minuteBar(1, 100))
+
+			if len(sink.decisions) == 0 {
+				t.Fatalf("expected a decision")
+			}
+			d := sink.decisions[len(sink.decisions)-1]
+			if d.Intent != c.action || d.IntentQty != float64(c.qty) || d.Result != c.result || d.RejectReason != c.reject {
+				t.Fatalf("expected %s %d (%s %s), got %s %g (%s %s): %+v", c.action, c.qty, c.result, c.reject, d.Intent, d.IntentQty, d.Result, d.RejectReason, d)
+			}
+			if d.Target == nil || *d.Target != float64(c.target) {
+				t.Fatalf("expected target %d recorded, got %v", c.target, d.Target)
+			}
+		})
+	}
+}
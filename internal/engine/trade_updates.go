@@ -0,0 +1,62 @@
+package engine
+
+import (
+	"context"
+	"log/slog"
+
+	"ats/internal/broker"
+	"ats/internal/event"
+)
+
+// TradeUpdateStream is the broker's feed of account fills and order status
+// changes; *broker.Client implements it.
+type TradeUpdateStream interface {
+	StreamTradeUpdates(ctx context.Context, handler func(broker.TradeUpdate)) error
+}
+
+// RunTradeUpdates publishes the fills and order updates of symbols' orders
+// from stream until ctx is done. It is the live counterpart of the events
+// the backtest simulator publishes.
+func RunTradeUpdates(ctx context.Context, bus *event.Bus, stream TradeUpdateStream, symbols ...string) error {
+	wanted := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		wanted[symbol] = true
+	}
+	return stream.StreamTradeUpdates(ctx, func(update broker.TradeUpdate) {
+		if !wanted[update.Order.Symbol] {
+			return
+		}
+		slog.Info("trade update", "event", update.Event, "symbol", update.Order.Symbol, "status", update.Order.Status, "qty", update.Qty, "price", update.Price, "filled_qty", update.Order.FilledQty, "client_order_id", update.Order.ClientOrderID)
+		for _, ev := range tradeUpdateEvents(update) {
+			bus.Publish(ctx, ev)
+		}
+	})
+}
+
+// tradeUpdateEvents is a FillEvent for an execution, then the order's
+// status after the update.
+func tradeUpdateEvents(update broker.TradeUpdate) []event.Event {
+	order := update.Order
+	var events []event.Event
+	if (update.Event == "fill" || update.Event == "partial_fill") && update.Qty.IsPositive() {
+		events = append(events, event.FillEvent{
+			At:            update.At,
+			Sym:           order.Symbol,
+			Side:          string(order.Side),
+			Qty:           update.Qty,
+			Price:         update.Price,
+			OrderID:       order.ID,
+			ClientOrderID: order.ClientOrderID,
+		})
+	}
+	return append(events, event.OrderUpdateEvent{
+		At:            update.At,
+		Sym:           order.Symbol,
+		OrderID:       order.ID,
+		ClientOrderID: order.ClientOrderID,
+		Status:        order.Status,
+		Side:          string(order.Side),
+		Qty:           order.Qty,
+		FilledQty:     order.FilledQty,
+	})
+}
@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"ats/internal/broker"
+	"ats/internal/clock"
+	"ats/internal/config"
+	"ats/internal/event"
+	"ats/internal/md"
+	"ats/internal/risk"
+	"ats/internal/state"
+	"ats/internal/strategy"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/alpaca"
+	"github.com/shopspring/decimal"
+)
+
+// scriptedStream delivers its updates in order, then ends.
+type scriptedStream []broker.TradeUpdate
+
+func (s scriptedStream) StreamTradeUpdates(ctx context.Context, handler func(broker.TradeUpdate)) error {
+	for _, update := range s {
+		handler(update)
+	}
+	return nil
+}
+
+func TestTradeUpdatesFillTheNotionalOrderTheyBelongTo(t *testing.T) {
+	cfg := config.Default()
+	cfg.Mode = config.ModePaper
+	cfg.Symbol = "SPY"
+	clk := clock.NewManual(time.Date(2024, 1, 2, 15, 0, 0, 0, time.UTC))
+	store := state.NewStore(clk)
+	e := New(cfg, fixedStrategy(strategy.TradeIntent{Action: strategy.Hold}), risk.Gate{}, nopBroker{}, store, &memorySink{}, nil, md.NewCalendar(nil), clk)
+	bus := event.NewBus()
+	e.Subscribe(bus)
+	// The engine's own update for a $200 notional order sized at 2 shares.
+	store.SetOpenOrder(state.OpenOrder{ClientOrderID: "c1", OrderID: "o1", Status: "new", Side: "buy", Qty: decimal.NewFromInt(2)})
+
+	at := clk.Now()
+	order := broker.OrderRef{ID: "o1", ClientOrderID: "c1", Symbol: "SPY", Side: alpaca.Buy, Notional: decimal.NewFromInt(200)}
+	updates := scriptedStream{
+		{At: at, Event: "new", Order: withStatus(order, "new", "0")},
+		{At: at.Add(time.Second), Event: "partial_fill", Order: withStatus(order, "partially_filled", "1"), Qty: decimal.NewFromInt(1), Price: decimal.NewFromInt(100)},
+		{At: at.Add(2 * time.Second), Event: "partial_fill", Order: broker.OrderRef{ID: "o2", ClientOrderID: "c2", Symbol: "QQQ", Side: alpaca.Buy, Status: "partially_filled"}, Qty: decimal.NewFromInt(5), Price: decimal.NewFromInt(400)},
+	}
+	if err := RunTradeUpdates(context.Background(), bus, updates, "SPY"); err != nil {
+		t.Fatal(err)
+	}
+	snap := store.Snapshot()
+	if !snap.Position.Qty.Equal(decimal.NewFromInt(1)) || snap.LastFill == nil || !snap.LastFill.Time.Equal(at.Add(time.Second)) {
+		t.Fatalf("expected the SPY partial fill applied, got %+v last fill %+v", snap.Position, snap.LastFill)
+	}
+	if pending := snap.PendingQty(); !pending.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("expected 1 share still pending on the notional order, got %s", pending)
+	}
+
+	done := scriptedStream{{At: at.Add(3 * time.Second), Event: "fill", Order: withStatus(order, "filled", "2"), Qty: decimal.NewFromInt(1), Price: decimal.NewFromInt(102)}}
+	if err := RunTradeUpdates(context.Background(), bus, done, "SPY"); err != nil {
+		t.Fatal(err)
+	}
+	snap = store.Snapshot()
+	if !snap.Position.Qty.Equal(decimal.NewFromInt(2)) || !snap.Position.AvgEntry.Equal(decimal.NewFromInt(101)) || len(snap.OpenOrders) != 0 {
+		t.Fatalf("expected 2 shares at 101 and no open order, got %+v open %+v", snap.Position, snap.OpenOrders)
+	}
+}
+
+func withStatus(order broker.OrderRef, status, filled string) broker.OrderRef {
+	order.Status = status
+	order.FilledQty = decimal.RequireFromString(filled)
+	return order
+}
@@ -0,0 +1,234 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"ats/internal/clock"
+)
+
+type Handler func(ctx context.Context, ev Event)
+
+// Bus delivers each event to the handlers subscribed to its kind, in
+// subscription order. Events of one symbol are always handled in publish
+// order, and an event published from inside a handler waits until the
+// current event has been fully handled.
+//
+// A new bus dispatches synchronously on the publishing goroutine, which
+// keeps backtests deterministic. After Start, each symbol gets its own
+// worker so a slow symbol does not hold up the others.
+type Bus struct {
+	mu       sync.Mutex
+	handlers map[Kind][]Handler
+
+	// synchronous mode
+	pending     []pendingEvent
+	dispatching bool
+
+	// asynchronous mode
+	ctx    context.Context
+	queues map[string]*queue
+	closed bool
+	wg     sync.WaitGroup
+}
+
+type pendingEvent struct {
+	ctx context.Context
+	ev  Event
+}
+
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[Kind][]Handler)}
+}
+
+// Subscribe registers h for events of kind. Subscribe before publishing.
+func (b *Bus) Subscribe(kind Kind, h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[kind] = append(b.handlers[kind], h)
+}
+
+// Start switches the bus to per-symbol workers that run until Close.
+// Handlers receive ctx.
+func (b *Bus) Start(ctx context.Context) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.ctx = ctx
+	b.queues = make(map[string]*queue)
+}
+
+// Publish queues ev. In synchronous mode it also drains the queue unless a
+// handler further up the stack is already doing so.
+func (b *Bus) Publish(ctx context.Context, ev Event) {
+	b.mu.Lock()
+	if b.queues != nil {
+		q, ok := b.queues[ev.Symbol()]
+		if !ok && !b.closed {
+			q = newQueue()
+			b.queues[ev.Symbol()] = q
+			b.wg.Add(1)
+			go b.work(q)
+		}
+		b.mu.Unlock()
+		if q == nil || !q.push(ev) {
+			// The symbol's worker has finished draining, so nothing can be
+			// queued ahead of ev; handle it here.
+			b.dispatch(ctx, ev)
+		}
+		return
+	}
+
+	b.pending = append(b.pending, pendingEvent{ctx: ctx, ev: ev})
+	if b.dispatching {
+		b.mu.Unlock()
+		return
+	}
+	b.dispatching = true
+	for len(b.pending) > 0 {
+		next := b.pending[0]
+		b.pending = b.pending[1:]
+		b.mu.Unlock()
+		b.dispatch(next.ctx, next.ev)
+		b.mu.Lock()
+	}
+	b.dispatching = false
+	b.mu.Unlock()
+}
+
+// Close waits for every queued event in asynchronous mode to be handled,
+// including events that handlers publish while the queues drain. Events
+// published after that are handled on the publishing goroutine.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	b.closed = true
+	queues := b.queues
+	b.mu.Unlock()
+	for _, q := range queues {
+		q.close()
+	}
+	b.wg.Wait()
+}
+
+func (b *Bus) work(q *queue) {
+	defer b.wg.Done()
+	for {
+		ev, ok := q.pop()
+		if !ok {
+			return
+		}
+		b.dispatch(b.ctx, ev)
+	}
+}
+
+func (b *Bus) dispatch(ctx context.Context, ev Event) {
+	b.mu.Lock()
+	handlers := b.handlers[ev.Kind()]
+	b.mu.Unlock()
+	for _, h := range handlers {
+		h(ctx, ev)
+	}
+}
+
+// queue is an unbounded FIFO, so handlers can publish to their own symbol
+// without deadlocking the worker that is running them.
+type queue struct {
+	mu     sync.Mutex
+	events []Event
+	notify chan struct{}
+	closed bool
+	done   bool
+}
+
+func newQueue() *queue {
+	return &queue{notify: make(chan struct{}, 1)}
+}
+
+// push queues ev and reports false once the worker has exited.
+func (q *queue) push(ev Event) bool {
+	q.mu.Lock()
+	if q.done {
+		q.mu.Unlock()
+		return false
+	}
+	q.events = append(q.events, ev)
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+func (q *queue) pop() (Event, bool) {
+	for {
+		q.mu.Lock()
+		if len(q.events) > 0 {
+			ev := q.events[0]
+			q.events = q.events[1:]
+			q.mu.Unlock()
+			return ev, true
+		}
+		if q.closed {
+			q.done = true
+			q.mu.Unlock()
+			return nil, false
+		}
+		q.mu.Unlock()
+		<-q.notify
+	}
+}
+
+func (q *queue) close() {
+	q.mu.Lock()
+	q.closed = true
+	q.mu.Unlock()
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// RunTimer publishes a TimerEvent named name every interval of clk until
+// ctx is done.
+func RunTimer(ctx context.Context, bus *Bus, clk clock.Clock, name string, interval time.Duration) {
+	ticker := clock.OrReal(clk).NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case at := <-ticker.C():
+			bus.Publish(ctx, TimerEvent{At: at, Name: name})
+		}
+	}
+}
+
+// Counter tallies events by kind, e.g. for a shutdown summary.
+type Counter struct {
+	mu     sync.Mutex
+	counts map[Kind]int
+}
+
+// NewCounter subscribes a counter to every event kind.
+func NewCounter(bus *Bus) *Counter {
+	c := &Counter{counts: make(map[Kind]int)}
+	for _, kind := range []Kind{KindBar, KindQuote, KindTrade, KindFill, KindOrderUpdate, KindTimer, KindRiskTrip} {
+		bus.Subscribe(kind, func(ctx context.Context, ev Event) {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.counts[ev.Kind()]++
+		})
+	}
+	return c
+}
+
+func (c *Counter) Counts() map[Kind]int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts := make(map[Kind]int, len(c.counts))
+	for kind, n := range c.counts {
+		counts[kind] = n
+	}
+	return counts
+}
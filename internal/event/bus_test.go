@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"ats/internal/md"
+)
+
+func bar(symbol string, ts int64) BarEvent {
+	return BarEvent{Bar: md.Bar{Symbol: symbol, Timestamp: ts}}
+}
+
+func TestSynchronousBusHandlesNestedPublishAfterCurrentEvent(t *testing.T) {
+	bus := NewBus()
+	var order []string
+	bus.Subscribe(KindBar, func(ctx context.Context, ev Event) {
+		order = append(order, "bar-a")
+		bus.Publish(ctx, OrderUpdateEvent{Sym: ev.Symbol(), Status: "new"})
+	})
+	bus.Subscribe(KindBar, func(ctx context.Context, ev Event) {
+		order = append(order, "bar-b")
+	})
+	bus.Subscribe(KindOrderUpdate, func(ctx context.Context, ev Event) {
+		order = append(order, "order")
+	})
+
+	bus.Publish(context.Background(), bar("SPY", 60))
+	want := []string{"bar-a", "bar-b", "order"}
+	if len(order) != len(want) {
+		t.Fatalf("expected %v, got %v", want, order)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, order)
+		}
+	}
+}
+
+func TestAsyncBusKeepsPerSymbolOrder(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	seen := map[string][]int64{}
+	bus.Subscribe(KindBar, func(ctx context.Context, ev Event) {
+		if ev.Symbol() == "SLOW" {
+			time.Sleep(time.Millisecond)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		seen[ev.Symbol()] = append(seen[ev.Symbol()], ev.(BarEvent).Bar.Timestamp)
+	})
+	counter := NewCounter(bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.Start(ctx)
+	for ts := int64(1); ts <= 50; ts++ {
+		bus.Publish(ctx, bar("SLOW", ts))
+		bus.Publish(ctx, bar("FAST", ts))
+	}
+	bus.Close()
+
+	for _, symbol := range []string{"SLOW", "FAST"} {
+		got := seen[symbol]
+		if len(got) != 50 {
+			t.Fatalf("%s: expected 50 bars, got %d", symbol, len(got))
+		}
+		for i, ts := range got {
+			if ts != int64(i+1) {
+				t.Fatalf("%s: out of order at %d: %v", symbol, i, got)
+			}
+		}
+	}
+	if counter.Counts()[KindBar] != 100 {
+		t.Fatalf("expected 100 counted bars, got %v", counter.Counts())
+	}
+}
+
+func TestOrderOpen(t *testing.T) {
+	if !OrderOpen("new") || !OrderOpen("partially_filled") || OrderOpen("filled") || OrderOpen("expired") {
+		t.Fatalf("unexpected order status classification")
+	}
+}
+
+func TestAsyncBusHandlesPublishesDuringAndAfterClose(t *testing.T) {
+	bus := NewBus()
+	var mu sync.Mutex
+	var updates int
+	bus.Subscribe(KindBar, func(ctx context.Context, ev Event) {
+		bus.Publish(ctx, OrderUpdateEvent{Sym: ev.Symbol(), Status: "new"})
+	})
+	bus.Subscribe(KindOrderUpdate, func(ctx context.Context, ev Event) {
+		mu.Lock()
+		defer mu.Unlock()
+		updates++
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	bus.Start(ctx)
+	for ts := int64(1); ts <= 10; ts++ {
+		bus.Publish(ctx, bar("SPY", ts))
+	}
+	bus.Close()
+	bus.Publish(ctx, OrderUpdateEvent{Sym: "SPY", Status: "filled"})
+	bus.Publish(ctx, OrderUpdateEvent{Sym: "QQQ", Status: "filled"})
+
+	if updates != 12 {
+		t.Fatalf("expected 12 order updates, got %d", updates)
+	}
+}
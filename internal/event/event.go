@@ -0,0 +1,123 @@
+// Package event defines the engine's typed events and the bus that
+// dispatches them in order per symbol.
+package event
+
+import (
+	"time"
+
+	"ats/internal/md"
+	"ats/internal/money"
+
+	"github.com/shopspring/decimal"
+)
+
+type Kind string
+
+const (
+	KindBar         Kind = "bar"
+	KindQuote       Kind = "quote"
+	KindTrade       Kind = "trade"
+	KindFill        Kind = "fill"
+	KindOrderUpdate Kind = "order_update"
+	KindTimer       Kind = "timer"
+	KindRiskTrip    Kind = "risk_trip"
+)
+
+// Event is anything published on the bus. Events with the same Symbol are
+// delivered in publish order; symbol-less events (timers) share a queue.
+type Event interface {
+	Kind() Kind
+	Symbol() string
+	Time() time.Time
+}
+
+type BarEvent struct {
+	Bar md.Bar
+}
+
+func (e BarEvent) Kind() Kind      { return KindBar }
+func (e BarEvent) Symbol() string  { return e.Bar.Symbol }
+func (e BarEvent) Time() time.Time { return time.Unix(e.Bar.Timestamp, 0).UTC() }
+
+type QuoteEvent struct {
+	Quote md.Quote
+}
+
+func (e QuoteEvent) Kind() Kind      { return KindQuote }
+func (e QuoteEvent) Symbol() string  { return e.Quote.Symbol }
+func (e QuoteEvent) Time() time.Time { return time.Unix(e.Quote.Timestamp, 0).UTC() }
+
+// TradeEvent is a market trade print, not one of our fills.
+type TradeEvent struct {
+	Trade md.Trade
+}
+
+func (e TradeEvent) Kind() Kind      { return KindTrade }
+func (e TradeEvent) Symbol() string  { return e.Trade.Symbol }
+func (e TradeEvent) Time() time.Time { return time.Unix(e.Trade.Timestamp, 0).UTC() }
+
+// FillEvent is an execution of one of our orders. Qty is positive; Side
+// is "buy" or "sell".
+type FillEvent struct {
+	At            time.Time
+	Sym           string
+	Side          string
+	Qty           decimal.Decimal
+	Price         money.Price
+	OrderID       string
+	ClientOrderID string
+}
+
+func (e FillEvent) Kind() Kind      { return KindFill }
+func (e FillEvent) Symbol() string  { return e.Sym }
+func (e FillEvent) Time() time.Time { return e.At }
+
+// OrderUpdateEvent reports an order status change, e.g. "new", "filled",
+// "canceled" or "expired". Side ("buy"/"sell"), Qty and FilledQty describe
+// the order as of the update.
+type OrderUpdateEvent struct {
+	At            time.Time
+	Sym           string
+	OrderID       string
+	ClientOrderID string
+	Status        string
+	Side          string
+	Qty           decimal.Decimal
+	FilledQty     decimal.Decimal
+}
+
+func (e OrderUpdateEvent) Kind() Kind      { return KindOrderUpdate }
+func (e OrderUpdateEvent) Symbol() string  { return e.Sym }
+func (e OrderUpdateEvent) Time() time.Time { return e.At }
+
+// TimerEvent fires on a named schedule (see RunTimer).
+type TimerEvent struct {
+	At   time.Time
+	Name string
+}
+
+func (e TimerEvent) Kind() Kind      { return KindTimer }
+func (e TimerEvent) Symbol() string  { return "" }
+func (e TimerEvent) Time() time.Time { return e.At }
+
+// RiskTripEvent is published when the risk gate rejects an intent.
+type RiskTripEvent struct {
+	At     time.Time
+	Sym    string
+	Intent string
+	Reason string
+}
+
+func (e RiskTripEvent) Kind() Kind      { return KindRiskTrip }
+func (e RiskTripEvent) Symbol() string  { return e.Sym }
+func (e RiskTripEvent) Time() time.Time { return e.At }
+
+// OrderOpen reports whether an order status still rests at the broker.
+func OrderOpen(status string) bool {
+	switch status {
+	case "filled", "canceled", "expired", "rejected":
+		return false
+	default:
+		return true
+	}
+}
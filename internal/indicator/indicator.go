@@ -0,0 +1,146 @@
+// Package indicator implements technical indicators over oldest-first series.
+// Each function returns ok=false when there is not enough data.
+package indicator
+
+import "math"
+
+// SMA is the simple moving average of the last window values.
+func SMA(values []float64, window int) (float64, bool) {
+	if window <= 0 || len(values) < window {
+		return 0, false
+	}
+	sum := 0.0
+	for _, v := range values[len(values)-window:] {
+		sum += v
+	}
+	return sum / float64(window), true
+}
+
+// EMA is the exponential moving average seeded with the SMA of the first
+// window values and smoothed with alpha = 2/(window+1).
+func EMA(values []float64, window int) (float64, bool) {
+	if window <= 0 || len(values) < window {
+		return 0, false
+	}
+	ema, _ := SMA(values[:window], window)
+	alpha := 2 / float64(window+1)
+	for _, v := range values[window:] {
+		ema = alpha*v + (1-alpha)*ema
+	}
+	return ema, true
+}
+
+// StdDev is the population standard deviation of the last window values.
+func StdDev(values []float64, window int) (float64, bool) {
+	mean, ok := SMA(values, window)
+	if !ok {
+		return 0, false
+	}
+	sum := 0.0
+	for _, v := range values[len(values)-window:] {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(window)), true
+}
+
+// RSI is Wilder's relative strength index over period changes.
+func RSI(values []float64, period int) (float64, bool) {
+	if period <= 0 || len(values) < period+1 {
+		return 0, false
+	}
+	gain, loss := 0.0, 0.0
+	for i := 1; i <= period; i++ {
+		change := values[i] - values[i-1]
+		if change > 0 {
+			gain += change
+		} else {
+			loss -= change
+		}
+	}
+	gain /= float64(period)
+	loss /= float64(period)
+	for i := period + 1; i < len(values); i++ {
+		change := values[i] - values[i-1]
+		up, down := 0.0, 0.0
+		if change > 0 {
+			up = change
+		} else {
+			down = -change
+		}
+		gain = (gain*float64(period-1) + up) / float64(period)
+		loss = (loss*float64(period-1) + down) / float64(period)
+	}
+	if loss == 0 {
+		return 100, true
+	}
+	rs := gain / loss
+	return 100 - 100/(1+rs), true
+}
+
+// ATR is Wilder's average true range. The three slices must be aligned.
+func ATR(highs, lows, closes []float64, period int) (float64, bool) {
+	n := len(closes)
+	if period <= 0 || n < period+1 || len(highs) != n || len(lows) != n {
+		return 0, false
+	}
+	trueRange := func(i int) float64 {
+		tr := highs[i] - lows[i]
+		tr = math.Max(tr, math.Abs(highs[i]-closes[i-1]))
+		return math.Max(tr, math.Abs(lows[i]-closes[i-1]))
+	}
+	atr := 0.0
+	for i := 1; i <= period; i++ {
+		atr += trueRange(i)
+	}
+	atr /= float64(period)
+	for i := period + 1; i < n; i++ {
+		atr = (atr*float64(period-1) + trueRange(i)) / float64(period)
+	}
+	return atr, true
+}
+
+// Highest is the maximum of the last window values.
+func Highest(values []float64, window int) (float64, bool) {
+	if window <= 0 || len(values) < window {
+		return 0, false
+	}
+	high := math.Inf(-1)
+	for _, v := range values[len(values)-window:] {
+		high = math.Max(high, v)
+	}
+	return high, true
+}
+
+// Lowest is the minimum of the last window values.
+func Lowest(values []float64, window int) (float64, bool) {
+	if window <= 0 || len(values) < window {
+		return 0, false
+	}
+	low := math.Inf(1)
+	for _, v := range values[len(values)-window:] {
+		low = math.Min(low, v)
+	}
+	return low, true
+}
+
+// OLS regresses the last window values of y on those of x, returning the
+// intercept and slope of y = alpha + beta*x. The series must be aligned and
+// x must vary over the window.
+func OLS(x, y []float64, window int) (alpha, beta float64, ok bool) {
+	if window < 2 || len(x) < window || len(y) < window {
+		return 0, 0, false
+	}
+	x, y = x[len(x)-window:], y[len(y)-window:]
+	meanX, _ := SMA(x, window)
+	meanY, _ := SMA(y, window)
+	var cov, varX float64
+	for i := range x {
+		cov += (x[i] - meanX) * (y[i] - meanY)
+		varX += (x[i] - meanX) * (x[i] - meanX)
+	}
+	if varX == 0 {
+		return 0, 0, false
+	}
+	beta = cov / varX
+	return meanY - beta*meanX, beta, true
+}
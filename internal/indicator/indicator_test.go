@@ -0,0 +1,59 @@
+package indicator
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6
+}
+
+func TestSMAAndInsufficientData(t *testing.T) {
+	values := []float64{1, 2, 3, 4, 5}
+	if v, ok := SMA(values, 3); !ok || !almostEqual(v, 4) {
+		t.Fatalf("expected SMA 4, got %f ok=%v", v, ok)
+	}
+	if _, ok := SMA(values, 6); ok {
+		t.Fatalf("expected insufficient data")
+	}
+}
+
+func TestEMAConstantSeries(t *testing.T) {
+	values := []float64{10, 10, 10, 10, 10, 10}
+	if v, ok := EMA(values, 3); !ok || !almostEqual(v, 10) {
+		t.Fatalf("expected EMA 10, got %f", v)
+	}
+}
+
+func TestRSIExtremes(t *testing.T) {
+	rising := []float64{1, 2, 3, 4, 5, 6}
+	if v, ok := RSI(rising, 5); !ok || v != 100 {
+		t.Fatalf("expected RSI 100 for rising series, got %f", v)
+	}
+	falling := []float64{6, 5, 4, 3, 2, 1}
+	if v, ok := RSI(falling, 5); !ok || !almostEqual(v, 0) {
+		t.Fatalf("expected RSI 0 for falling series, got %f", v)
+	}
+}
+
+func TestATRUsesTrueRange(t *testing.T) {
+	highs := []float64{11, 12, 13}
+	lows := []float64{9, 10, 11}
+	closes := []float64{10, 11, 12}
+	if v, ok := ATR(highs, lows, closes, 2); !ok || !almostEqual(v, 2) {
+		t.Fatalf("expected ATR 2, got %f ok=%v", v, ok)
+	}
+}
+
+func TestOLSRecoversLine(t *testing.T) {
+	x := []float64{99, 1, 2, 3, 4, 5}
+	y := []float64{0, 3.5, 5.5, 7.5, 9.5, 11.5}
+	alpha, beta, ok := OLS(x, y, 5)
+	if !ok || !almostEqual(alpha, 1.5) || !almostEqual(beta, 2) {
+		t.Fatalf("expected y = 1.5 + 2x, got alpha=%f beta=%f ok=%v", alpha, beta, ok)
+	}
+	if _, _, ok := OLS([]float64{1, 1, 1}, []float64{1, 2, 3}, 3); ok {
+		t.Fatalf("expected no fit for a constant x")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"strings"
 	"text/template"
+
+	"github.com/shopspring/decimal"
 )
 
 //go:embed system.md
@@ -18,7 +20,7 @@ type DecisionData struct {
 	Timestamp   string
 	Close       float64
 	SMA         float64
-	PositionQty int
+	PositionQty decimal.Decimal
 	MaxQty      int
 }
 
@@ -4,6 +4,8 @@ import (
 	"os"
 	"strings"
 	"testing"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestRenderDecisionPrompt_DefaultTemplate(t *testing.T) {
@@ -12,7 +14,7 @@ func TestRenderDecisionPrompt_DefaultTemplate(t *testing.T) {
 		Timestamp:   "2024-01-01T00:00:00Z",
 		Close:       101.25,
 		SMA:         100.0,
-		PositionQty: 2,
+		PositionQty: decimal.NewFromInt(2),
 		MaxQty:      5,
 	})
 	if err != nil {
@@ -0,0 +1,197 @@
+package md
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"time"
+)
+
+// Timeframe is a bar resolution built from the 1-minute stream.
+type Timeframe string
+
+const (
+	OneMinute      Timeframe = "1m"
+	FiveMinutes    Timeframe = "5m"
+	FifteenMinutes Timeframe = "15m"
+	OneHour        Timeframe = "1h"
+	OneDay         Timeframe = "1d"
+)
+
+func ParseTimeframe(value string) (Timeframe, error) {
+	switch tf := Timeframe(value); tf {
+	case OneMinute, FiveMinutes, FifteenMinutes, OneHour, OneDay:
+		return tf, nil
+	case "":
+		return OneMinute, nil
+	default:
+		return "", fmt.Errorf("unsupported timeframe: %s", value)
+	}
+}
+
+// Duration is the nominal bucket length. Daily bars span the whole session
+// instead, so OneDay reports 24h only for ordering purposes.
+func (t Timeframe) Duration() time.Duration {
+	switch t {
+	case FiveMinutes:
+		return 5 * time.Minute
+	case FifteenMinutes:
+		return 15 * time.Minute
+	case OneHour:
+		return time.Hour
+	case OneDay:
+		return 24 * time.Hour
+	default:
+		return time.Minute
+	}
+}
+
+// FlushGrace is how long after the session close Flush waits for the
+// final minute's bar, which the stream delivers shortly after it ends.
+const FlushGrace = time.Minute
+
+// Aggregator builds session-aligned bars of one timeframe from 1-minute bars.
+// Intraday buckets start at the session open (so 1h bars are 9:30, 10:30, ...
+// with a short final bucket), and daily bars cover the whole session.
+type Aggregator struct {
+	calendar  *Calendar
+	timeframe Timeframe
+	pending   map[string]*pendingBar
+	// emitted is the end of each symbol's last completed bucket; bars
+	// before it arrived too late and are dropped.
+	emitted map[string]time.Time
+}
+
+type pendingBar struct {
+	bar   Bar
+	start time.Time
+	end   time.Time
+}
+
+func NewAggregator(calendar *Calendar, timeframe Timeframe) *Aggregator {
+	return &Aggregator{
+		calendar:  calendar,
+		timeframe: timeframe,
+		pending:   make(map[string]*pendingBar),
+		emitted:   make(map[string]time.Time),
+	}
+}
+
+func (a *Aggregator) Timeframe() Timeframe {
+	return a.timeframe
+}
+
+// Add folds a 1-minute bar into the current bucket and returns any bars that
+// completed as a result, oldest first. A bucket completes when its final
+// minute arrives, or when a later bar shows it was cut short. A bar for a
+// bucket already completed is dropped rather than reopening it.
+func (a *Aggregator) Add(bar Bar) []Bar {
+	if a.timeframe == OneMinute || a.timeframe == "" {
+		return []Bar{bar}
+	}
+
+	barTime := time.Unix(bar.Timestamp, 0).UTC()
+	if barTime.Before(a.emitted[bar.Symbol]) {
+		slog.Warn("late bar dropped", "symbol", bar.Symbol, "timeframe", a.timeframe, "bar", barTime.Format(time.RFC3339))
+		return nil
+	}
+	var completed []Bar
+	current := a.pending[bar.Symbol]
+	if current != nil && !barTime.Before(current.end) {
+		completed = append(completed, a.complete(current))
+		current = nil
+	}
+
+	start, end, ok := a.bucket(barTime)
+	if !ok {
+		return completed
+	}
+	if current != nil && !current.start.Equal(start) {
+		completed = append(completed, a.complete(current))
+		current = nil
+	}
+
+	if current == nil {
+		current = &pendingBar{
+			bar: Bar{
+				Symbol:    bar.Symbol,
+				Timestamp: start.Unix(),
+				Open:      bar.Open,
+				High:      bar.High,
+				Low:       bar.Low,
+			},
+			start: start,
+			end:   end,
+		}
+		a.pending[bar.Symbol] = current
+	}
+
+	agg := &current.bar
+	if bar.High > agg.High {
+		agg.High = bar.High
+	}
+	if bar.Low < agg.Low || agg.Low == 0 {
+		agg.Low = bar.Low
+	}
+	agg.Close = bar.Close
+	agg.Volume += bar.Volume
+	agg.Backfilled = bar.Backfilled
+
+	if !barTime.Add(time.Minute).Before(end) {
+		completed = append(completed, a.complete(current))
+	}
+	return completed
+}
+
+// complete ends a pending bucket and returns its bar.
+func (a *Aggregator) complete(current *pendingBar) Bar {
+	delete(a.pending, current.bar.Symbol)
+	a.emitted[current.bar.Symbol] = current.end
+	return current.bar
+}
+
+// Flush completes a session's final bucket whose last minute never traded,
+// so it is not held until the next session's first bar. It waits until
+// FlushGrace after the close; earlier buckets complete on the bars that
+// follow them. Bars are ordered by symbol.
+func (a *Aggregator) Flush(now time.Time) []Bar {
+	var completed []Bar
+	for _, current := range a.pending {
+		session, ok := a.calendar.Session(current.start)
+		if ok && !now.Before(session.Close.Add(FlushGrace)) {
+			completed = append(completed, a.complete(current))
+		}
+	}
+	sort.Slice(completed, func(i, j int) bool { return completed[i].Symbol < completed[j].Symbol })
+	return completed
+}
+
+// BucketEnd is the time at which an aggregated bar became complete.
+func (a *Aggregator) BucketEnd(bar Bar) time.Time {
+	start := time.Unix(bar.Timestamp, 0).UTC()
+	if a.timeframe == OneMinute || a.timeframe == "" {
+		return start.Add(time.Minute)
+	}
+	if _, end, ok := a.bucket(start); ok {
+		return end
+	}
+	return start.Add(a.timeframe.Duration())
+}
+
+func (a *Aggregator) bucket(t time.Time) (time.Time, time.Time, bool) {
+	session, ok := a.calendar.Session(t)
+	if !ok || t.Before(session.Open) || !t.Before(session.Close) {
+		return time.Time{}, time.Time{}, false
+	}
+	if a.timeframe == OneDay {
+		return session.Open.UTC(), session.Close.UTC(), true
+	}
+	size := a.timeframe.Duration()
+	offset := t.Sub(session.Open) / size
+	start := session.Open.Add(offset * size)
+	end := start.Add(size)
+	if end.After(session.Close) {
+		end = session.Close
+	}
+	return start.UTC(), end.UTC(), true
+}
@@ -0,0 +1,129 @@
+package md
+
+import (
+	"testing"
+	"time"
+)
+
+func minuteBar(start time.Time, offset int, price float64) Bar {
+	return Bar{
+		Symbol:    "AAPL",
+		Timestamp: start.Add(time.Duration(offset) * time.Minute).Unix(),
+		Open:      price,
+		High:      price + 1,
+		Low:       price - 1,
+		Close:     price,
+		Volume:    10,
+	}
+}
+
+func TestAggregatorBuildsFiveMinuteBars(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), FiveMinutes)
+
+	var completed []Bar
+	for i := 0; i < 5; i++ {
+		completed = append(completed, agg.Add(minuteBar(open, i, 100+float64(i)))...)
+	}
+
+	if len(completed) != 1 {
+		t.Fatalf("expected one completed bar, got %d", len(completed))
+	}
+	bar := completed[0]
+	if bar.Timestamp != open.Unix() {
+		t.Fatalf("expected bar aligned to session open")
+	}
+	if bar.Open != 100 || bar.Close != 104 || bar.High != 105 || bar.Low != 99 || bar.Volume != 50 {
+		t.Fatalf("unexpected aggregate %+v", bar)
+	}
+}
+
+func TestAggregatorHourBarsAlignToSessionOpen(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), OneHour)
+
+	if got := agg.Add(minuteBar(open, 59, 100)); len(got) != 1 {
+		t.Fatalf("expected 09:30 hour bar to complete at 10:29, got %d bars", len(got))
+	}
+	if got := agg.Add(minuteBar(open, 60, 100)); len(got) != 0 {
+		t.Fatalf("expected 10:30 bar to open a new bucket")
+	}
+}
+
+func TestAggregatorFlushesBucketCutShortByGap(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), FiveMinutes)
+
+	agg.Add(minuteBar(open, 0, 100))
+	agg.Add(minuteBar(open, 1, 101))
+	completed := agg.Add(minuteBar(open, 6, 106))
+
+	if len(completed) != 1 || completed[0].Close != 101 {
+		t.Fatalf("expected partial bucket to flush on next bucket, got %+v", completed)
+	}
+}
+
+func TestAggregatorDailyBarCompletesAtClose(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), OneDay)
+
+	if got := agg.Add(minuteBar(open, 0, 100)); len(got) != 0 {
+		t.Fatalf("expected daily bar to stay open")
+	}
+	got := agg.Add(minuteBar(open, 389, 105))
+	if len(got) != 1 || got[0].Open != 100 || got[0].Close != 105 {
+		t.Fatalf("expected daily bar on last minute, got %+v", got)
+	}
+}
+
+func TestAggregatorFlushesFinalBucketAtSessionClose(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), OneHour)
+
+	// The 15:30 bucket's last trade is at 15:57; nothing trades after it.
+	agg.Add(minuteBar(open, 360, 100))
+	agg.Add(minuteBar(open, 387, 101))
+	if got := agg.Flush(marketTime(t, "2024-03-12 16:00")); len(got) != 0 {
+		t.Fatalf("expected the bucket to wait out the grace after the close, got %+v", got)
+	}
+	got := agg.Flush(marketTime(t, "2024-03-12 16:00").Add(FlushGrace))
+	if len(got) != 1 || got[0].Timestamp != marketTime(t, "2024-03-12 15:30").Unix() || got[0].Close != 101 {
+		t.Fatalf("expected the 15:30 bar at the close, got %+v", got)
+	}
+	if got := agg.Add(minuteBar(marketTime(t, "2024-03-13 09:30"), 0, 102)); len(got) != 0 {
+		t.Fatalf("expected nothing left for the next session, got %+v", got)
+	}
+}
+
+func TestAggregatorFlushLeavesIntradayBucketsToTheirBars(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), FiveMinutes)
+
+	agg.Add(minuteBar(open, 0, 100))
+	agg.Add(minuteBar(open, 3, 103))
+	// The 9:34 bar has not arrived when a flush ticks at 9:35.
+	if got := agg.Flush(marketTime(t, "2024-03-12 09:35")); len(got) != 0 {
+		t.Fatalf("expected the 9:30 bucket to wait for its bars, got %+v", got)
+	}
+	got := agg.Add(minuteBar(open, 4, 104))
+	if len(got) != 1 || got[0].Close != 104 {
+		t.Fatalf("expected the 9:30 bar on its last minute, got %+v", got)
+	}
+}
+
+func TestAggregatorDropsLateBarsForCompletedBuckets(t *testing.T) {
+	open := marketTime(t, "2024-03-12 09:30")
+	agg := NewAggregator(NewCalendar(nil), FiveMinutes)
+
+	agg.Add(minuteBar(open, 0, 100))
+	if got := agg.Add(minuteBar(open, 5, 105)); len(got) != 1 {
+		t.Fatalf("expected the 9:30 bar cut short by the 9:35 bar, got %+v", got)
+	}
+	if got := agg.Add(minuteBar(open, 4, 104)); len(got) != 0 {
+		t.Fatalf("expected the late 9:34 bar to be dropped, got %+v", got)
+	}
+	got := agg.Add(minuteBar(open, 9, 109))
+	if len(got) != 1 || got[0].Timestamp != open.Add(5*time.Minute).Unix() || got[0].Open != 105 {
+		t.Fatalf("expected only the 9:35 bar next, got %+v", got)
+	}
+}
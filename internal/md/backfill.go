@@ -0,0 +1,128 @@
+package md
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
+)
+
+// HistoricalBars fetches 1-minute bars for [start, end] inclusive.
+type HistoricalBars interface {
+	Bars(ctx context.Context, symbol string, start, end time.Time) ([]Bar, error)
+}
+
+// Historical serves bars from Alpaca's historical market data API.
+type Historical struct {
+	client *marketdata.Client
+	feed   marketdata.Feed
+}
+
+func NewHistorical(apiKey, apiSecret, feed string) *Historical {
+	return &Historical{
+		client: marketdata.NewClient(marketdata.ClientOpts{
+			APIKey:    apiKey,
+			APISecret: apiSecret,
+		}),
+		feed: parseFeed(feed),
+	}
+}
+
+func (h *Historical) Bars(ctx context.Context, symbol string, start, end time.Time) ([]Bar, error) {
+	bars, err := h.client.GetBars(symbol, marketdata.GetBarsRequest{
+		TimeFrame: marketdata.OneMin,
+		Start:     start,
+		End:       end,
+		Feed:      h.feed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("fetch historical bars: %w", err)
+	}
+	result := make([]Bar, 0, len(bars))
+	for _, bar := range bars {
+		result = append(result, Bar{
+			Symbol:    symbol,
+			Timestamp: bar.Timestamp.Unix(),
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
+			Close:     bar.Close,
+			Volume:    bar.Volume,
+		})
+	}
+	return result, nil
+}
+
+// GapFiller sits between the stream and the engine. When a bar arrives after
+// one or more in-session bars were missed (typically across a reconnect), it
+// replays the missing bars from history, marked Backfilled, before the live bar.
+type GapFiller struct {
+	calendar *Calendar
+	history  HistoricalBars
+	interval time.Duration
+	handler  BarHandler
+	mu       sync.Mutex
+	last     map[string]time.Time
+}
+
+func NewGapFiller(calendar *Calendar, history HistoricalBars, interval time.Duration, handler BarHandler) *GapFiller {
+	return &GapFiller{
+		calendar: calendar,
+		history:  history,
+		interval: interval,
+		handler:  handler,
+		last:     make(map[string]time.Time),
+	}
+}
+
+func (g *GapFiller) OnBar(ctx context.Context, bar Bar) {
+	barTime := time.Unix(bar.Timestamp, 0).UTC()
+	g.mu.Lock()
+	last, seen := g.last[bar.Symbol]
+	if seen && !barTime.After(last) {
+		g.mu.Unlock()
+		slog.Warn("dropping stale bar", "symbol", bar.Symbol, "bar", barTime.Format(time.RFC3339), "last", last.Format(time.RFC3339))
+		return
+	}
+	g.last[bar.Symbol] = barTime
+	g.mu.Unlock()
+
+	// The history fetch runs without the lock so other symbols are not held
+	// up behind it; the stream delivers each symbol's bars in turn.
+	if seen {
+		missing := g.calendar.MissingBars(last, barTime, g.interval)
+		if len(missing) > 0 {
+			g.backfill(ctx, bar.Symbol, last, barTime, missing)
+		}
+	}
+	g.handler(bar)
+}
+
+func (g *GapFiller) backfill(ctx context.Context, symbol string, last, next time.Time, missing []time.Time) {
+	start := missing[0]
+	end := missing[len(missing)-1]
+	slog.Warn("bar gap detected", "symbol", symbol, "missing", len(missing), "from", start.Format(time.RFC3339), "to", end.Format(time.RFC3339))
+
+	bars, err := g.history.Bars(ctx, symbol, start, end)
+	if err != nil {
+		slog.Error("backfill failed", "symbol", symbol, "error", err)
+		return
+	}
+
+	filled := 0
+	for _, bar := range bars {
+		barTime := time.Unix(bar.Timestamp, 0).UTC()
+		if !barTime.After(last) || !barTime.Before(next) {
+			continue
+		}
+		bar.Symbol = symbol
+		bar.Backfilled = true
+		g.handler(bar)
+		last = barTime
+		filled++
+	}
+	slog.Info("backfill complete", "symbol", symbol, "missing", len(missing), "filled", filled)
+}
@@ -0,0 +1,129 @@
+package md
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type fakeHistory struct {
+	bars  []Bar
+	calls int
+}
+
+func (f *fakeHistory) Bars(ctx context.Context, symbol string, start, end time.Time) ([]Bar, error) {
+	f.calls++
+	return f.bars, nil
+}
+
+func marketTime(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.ParseInLocation("2006-01-02 15:04", value, marketLocation())
+	if err != nil {
+		t.Fatalf("parse time: %v", err)
+	}
+	return parsed
+}
+
+func TestCalendarMissingBarsSkipsOvernight(t *testing.T) {
+	cal := NewCalendar(nil)
+	last := marketTime(t, "2024-03-08 15:59") // Friday
+	next := marketTime(t, "2024-03-11 09:30") // Monday
+
+	if missing := cal.MissingBars(last, next, time.Minute); len(missing) != 0 {
+		t.Fatalf("expected no missing bars across weekend, got %d", len(missing))
+	}
+}
+
+func TestCalendarHolidayIsClosed(t *testing.T) {
+	cal := NewCalendar([]Session{
+		{Open: marketTime(t, "2024-07-03 09:30"), Close: marketTime(t, "2024-07-03 13:00")},
+		{Open: marketTime(t, "2024-07-05 09:30"), Close: marketTime(t, "2024-07-05 16:00")},
+	})
+
+	if cal.InSession(marketTime(t, "2024-07-04 10:00")) {
+		t.Fatalf("expected holiday to be closed")
+	}
+	if cal.InSession(marketTime(t, "2024-07-03 13:30")) {
+		t.Fatalf("expected early close to be honored")
+	}
+}
+
+func TestGapFillerBackfillsMissingBars(t *testing.T) {
+	base := marketTime(t, "2024-03-12 10:00")
+	history := &fakeHistory{bars: []Bar{
+		{Timestamp: base.Add(time.Minute).Unix(), Close: 101},
+		{Timestamp: base.Add(2 * time.Minute).Unix(), Close: 102},
+	}}
+
+	var received []Bar
+	filler := NewGapFiller(NewCalendar(nil), history, time.Minute, func(bar Bar) {
+		received = append(received, bar)
+	})
+
+	ctx := context.Background()
+	filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Unix(), Close: 100})
+	filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Add(3 * time.Minute).Unix(), Close: 103})
+
+	if history.calls != 1 {
+		t.Fatalf("expected one history call, got %d", history.calls)
+	}
+	if len(received) != 4 {
+		t.Fatalf("expected 4 bars, got %d", len(received))
+	}
+	if !received[1].Backfilled || !received[2].Backfilled {
+		t.Fatalf("expected middle bars to be marked backfilled")
+	}
+	if received[3].Backfilled || received[3].Close != 103 {
+		t.Fatalf("expected live bar last, got %+v", received[3])
+	}
+}
+
+func TestGapFillerDropsStaleBars(t *testing.T) {
+	base := marketTime(t, "2024-03-12 10:00")
+	var received []Bar
+	filler := NewGapFiller(NewCalendar(nil), &fakeHistory{}, time.Minute, func(bar Bar) {
+		received = append(received, bar)
+	})
+
+	ctx := context.Background()
+	filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Unix()})
+	filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Unix()})
+
+	if len(received) != 1 {
+		t.Fatalf("expected duplicate bar to be dropped, got %d bars", len(received))
+	}
+}
+
+type blockingHistory struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (b *blockingHistory) Bars(ctx context.Context, symbol string, start, end time.Time) ([]Bar, error) {
+	close(b.started)
+	<-b.release
+	return nil, nil
+}
+
+func TestGapFillerFetchDoesNotBlockOtherSymbols(t *testing.T) {
+	base := marketTime(t, "2024-03-12 10:00")
+	history := &blockingHistory{started: make(chan struct{}), release: make(chan struct{})}
+	delivered := make(chan Bar, 4)
+	filler := NewGapFiller(NewCalendar(nil), history, time.Minute, func(bar Bar) { delivered <- bar })
+
+	ctx := context.Background()
+	filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Unix()})
+	<-delivered
+	go filler.OnBar(ctx, Bar{Symbol: "AAPL", Timestamp: base.Add(5 * time.Minute).Unix()})
+	<-history.started
+
+	filler.OnBar(ctx, Bar{Symbol: "MSFT", Timestamp: base.Unix()})
+	if bar := <-delivered; bar.Symbol != "MSFT" {
+		t.Fatalf("expected MSFT while AAPL backfills, got %+v", bar)
+	}
+	close(history.release)
+	if bar := <-delivered; bar.Symbol != "AAPL" {
+		t.Fatalf("expected the live AAPL bar after the backfill, got %+v", bar)
+	}
+}
@@ -0,0 +1,82 @@
+package md
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BarCache serves historical bars from CSV files on disk, one file per
+// symbol and UTC day, fetching and storing days it does not have. Today's
+// bars are never cached since the day is still filling in.
+type BarCache struct {
+	dir    string
+	source HistoricalBars
+	now    func() time.Time
+}
+
+func NewBarCache(dir string, source HistoricalBars) *BarCache {
+	return &BarCache{dir: dir, source: source, now: time.Now}
+}
+
+func (c *BarCache) Bars(ctx context.Context, symbol string, start, end time.Time) ([]Bar, error) {
+	start, end = start.UTC(), end.UTC()
+	today := truncateDay(c.now().UTC())
+	var result []Bar
+	for day := truncateDay(start); !day.After(end); day = day.AddDate(0, 0, 1) {
+		bars, err := c.day(ctx, symbol, day, !day.Before(today))
+		if err != nil {
+			return nil, err
+		}
+		for _, bar := range bars {
+			if bar.Timestamp >= start.Unix() && bar.Timestamp <= end.Unix() {
+				result = append(result, bar)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (c *BarCache) day(ctx context.Context, symbol string, day time.Time, live bool) ([]Bar, error) {
+	path := filepath.Join(c.dir, symbol, day.Format("2006-01-02")+".csv")
+	if !live {
+		bars, err := LoadBarsCSV(path, symbol)
+		if err == nil {
+			return bars, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read bar cache: %w", err)
+		}
+	}
+	if c.source == nil {
+		return nil, fmt.Errorf("bars for %s on %s are not cached", symbol, day.Format("2006-01-02"))
+	}
+
+	bars, err := c.source.Bars(ctx, symbol, day, day.Add(24*time.Hour-time.Second))
+	if err != nil {
+		return nil, err
+	}
+	if live {
+		return bars, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("create bar cache: %w", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("create bar cache: %w", err)
+	}
+	if err := WriteBarsCSV(file, bars); err != nil {
+		_ = file.Close()
+		return nil, fmt.Errorf("write bar cache: %w", err)
+	}
+	return bars, file.Close()
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,108 @@
+package md
+
+import (
+	"time"
+	_ "time/tzdata"
+)
+
+const dateLayout = "2006-01-02"
+
+// Session is a single trading day's regular session in absolute time.
+type Session struct {
+	Open  time.Time
+	Close time.Time
+}
+
+// Calendar answers whether a bar timestamp falls inside a trading session.
+// Dates covered by the loaded sessions are authoritative (missing dates are
+// holidays); dates outside that range fall back to regular weekday hours.
+type Calendar struct {
+	loc      *time.Location
+	sessions map[string]Session
+	first    string
+	last     string
+}
+
+func NewCalendar(sessions []Session) *Calendar {
+	c := &Calendar{
+		loc:      marketLocation(),
+		sessions: make(map[string]Session, len(sessions)),
+	}
+	for _, s := range sessions {
+		key := s.Open.In(c.loc).Format(dateLayout)
+		c.sessions[key] = s
+		if c.first == "" || key < c.first {
+			c.first = key
+		}
+		if key > c.last {
+			c.last = key
+		}
+	}
+	return c
+}
+
+// Location returns the exchange time zone used for session boundaries.
+func (c *Calendar) Location() *time.Location {
+	return c.loc
+}
+
+// Session returns the trading session for the exchange date containing t.
+func (c *Calendar) Session(t time.Time) (Session, bool) {
+	local := t.In(c.loc)
+	key := local.Format(dateLayout)
+
+	session, ok := c.sessions[key]
+	covered := c.first != "" && key >= c.first && key <= c.last
+
+	if ok {
+		return session, true
+	}
+	if covered {
+		return Session{}, false
+	}
+	return regularSession(local)
+}
+
+// InSession reports whether t falls in [open, close) of its trading day.
+func (c *Calendar) InSession(t time.Time) bool {
+	session, ok := c.Session(t)
+	if !ok {
+		return false
+	}
+	return !t.Before(session.Open) && t.Before(session.Close)
+}
+
+// MissingBars lists the in-session bar start times strictly between last and
+// next, stepping by interval. Overnight and weekend gaps yield nothing.
+func (c *Calendar) MissingBars(last, next time.Time, interval time.Duration) []time.Time {
+	if interval <= 0 || !next.After(last) {
+		return nil
+	}
+	var missing []time.Time
+	for t := last.Add(interval); t.Before(next); t = t.Add(interval) {
+		if c.InSession(t) {
+			missing = append(missing, t)
+		}
+	}
+	return missing
+}
+
+func regularSession(local time.Time) (Session, bool) {
+	switch local.Weekday() {
+	case time.Saturday, time.Sunday:
+		return Session{}, false
+	}
+	year, month, day := local.Date()
+	return Session{
+		Open:  time.Date(year, month, day, 9, 30, 0, 0, local.Location()),
+		Close: time.Date(year, month, day, 16, 0, 0, 0, local.Location()),
+	}, true
+}
+
+func marketLocation() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
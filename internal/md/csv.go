@@ -0,0 +1,89 @@
+package md
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+var csvHeader = []string{"timestamp", "open", "high", "low", "close", "volume"}
+
+// LoadBarsCSV reads bars written by WriteBarsCSV: an RFC3339 timestamp
+// followed by open, high, low, close and volume.
+func LoadBarsCSV(path, symbol string) ([]Bar, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	return ReadBarsCSV(file, symbol)
+}
+
+func ReadBarsCSV(r io.Reader, symbol string) ([]Bar, error) {
+	reader := csv.NewReader(r)
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read bars csv: %w", err)
+	}
+	bars := make([]Bar, 0, len(rows))
+	for i, row := range rows {
+		if i == 0 && len(row) > 0 && row[0] == csvHeader[0] {
+			continue
+		}
+		if len(row) < len(csvHeader) {
+			return nil, fmt.Errorf("bars csv line %d: expected %d columns, got %d", i+1, len(csvHeader), len(row))
+		}
+		ts, err := time.Parse(time.RFC3339, row[0])
+		if err != nil {
+			return nil, fmt.Errorf("bars csv line %d: %w", i+1, err)
+		}
+		var values [4]float64
+		for j := range values {
+			values[j], err = strconv.ParseFloat(row[j+1], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bars csv line %d: %w", i+1, err)
+			}
+		}
+		volume, err := strconv.ParseUint(row[5], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bars csv line %d: %w", i+1, err)
+		}
+		bars = append(bars, Bar{
+			Symbol:    symbol,
+			Timestamp: ts.Unix(),
+			Open:      values[0],
+			High:      values[1],
+			Low:       values[2],
+			Close:     values[3],
+			Volume:    volume,
+		})
+	}
+	return bars, nil
+}
+
+func WriteBarsCSV(w io.Writer, bars []Bar) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, bar := range bars {
+		row := []string{
+			time.Unix(bar.Timestamp, 0).UTC().Format(time.RFC3339),
+			strconv.FormatFloat(bar.Open, 'f', -1, 64),
+			strconv.FormatFloat(bar.High, 'f', -1, 64),
+			strconv.FormatFloat(bar.Low, 'f', -1, 64),
+			strconv.FormatFloat(bar.Close, 'f', -1, 64),
+			strconv.FormatUint(bar.Volume, 10),
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,61 @@
+package md
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBarsCSVRoundTrip(t *testing.T) {
+	bars := []Bar{
+		{Symbol: "SPY", Timestamp: 1704205800, Open: 100, High: 101.5, Low: 99.25, Close: 101, Volume: 1200},
+		{Symbol: "SPY", Timestamp: 1704205860, Open: 101, High: 102, Low: 100.5, Close: 100.75, Volume: 800},
+	}
+	var buf bytes.Buffer
+	if err := WriteBarsCSV(&buf, bars); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	got, err := ReadBarsCSV(&buf, "SPY")
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if len(got) != len(bars) {
+		t.Fatalf("expected %d bars, got %d", len(bars), len(got))
+	}
+	for i := range bars {
+		if got[i] != bars[i] {
+			t.Fatalf("bar %d: expected %+v, got %+v", i, bars[i], got[i])
+		}
+	}
+}
+
+func TestReadBarsCSVReportsLine(t *testing.T) {
+	input := "timestamp,open,high,low,close,volume\n2024-01-02T14:30:00Z,100,101,99,x,10\n"
+	if _, err := ReadBarsCSV(bytes.NewBufferString(input), "SPY"); err == nil {
+		t.Fatalf("expected parse error")
+	}
+}
+
+func TestBarCacheFetchesOncePerDay(t *testing.T) {
+	day := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	open := day.Add(14*time.Hour + 30*time.Minute)
+	history := &fakeHistory{bars: []Bar{
+		{Symbol: "SPY", Timestamp: open.Unix(), Open: 1, High: 1, Low: 1, Close: 1, Volume: 1},
+		{Symbol: "SPY", Timestamp: open.Add(time.Minute).Unix(), Open: 2, High: 2, Low: 2, Close: 2, Volume: 1},
+	}}
+	cache := NewBarCache(t.TempDir(), history)
+
+	for i := 0; i < 2; i++ {
+		bars, err := cache.Bars(context.Background(), "SPY", open, open.Add(time.Minute))
+		if err != nil {
+			t.Fatalf("bars: %v", err)
+		}
+		if len(bars) != 2 || bars[1].Close != 2 {
+			t.Fatalf("unexpected bars: %+v", bars)
+		}
+	}
+	if history.calls != 1 {
+		t.Fatalf("expected one fetch, got %d", history.calls)
+	}
+}
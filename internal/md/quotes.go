@@ -0,0 +1,109 @@
+package md
+
+import (
+	"sync"
+	"time"
+)
+
+type Quote struct {
+	Symbol    string
+	Timestamp int64
+	BidPrice  float64
+	BidSize   uint32
+	AskPrice  float64
+	AskSize   uint32
+}
+
+type Trade struct {
+	Symbol    string
+	Timestamp int64
+	Price     float64
+	Size      uint32
+}
+
+type QuoteHandler func(Quote)
+
+type TradeHandler func(Trade)
+
+// TopOfBook is the latest NBBO and last trade seen for a symbol.
+type TopOfBook struct {
+	Bid           float64
+	BidSize       uint32
+	Ask           float64
+	AskSize       uint32
+	QuoteTime     time.Time
+	LastTrade     float64
+	LastTradeSize uint32
+	TradeTime     time.Time
+}
+
+// HasQuote reports whether both sides of the book are populated and uncrossed.
+func (t TopOfBook) HasQuote() bool {
+	return t.Bid > 0 && t.Ask > 0 && t.Ask >= t.Bid
+}
+
+func (t TopOfBook) Spread() float64 {
+	if !t.HasQuote() {
+		return 0
+	}
+	return t.Ask - t.Bid
+}
+
+func (t TopOfBook) Mid() float64 {
+	if !t.HasQuote() {
+		return 0
+	}
+	return (t.Bid + t.Ask) / 2
+}
+
+// SpreadBps is the quoted spread in basis points of the mid price.
+func (t TopOfBook) SpreadBps() float64 {
+	mid := t.Mid()
+	if mid == 0 {
+		return 0
+	}
+	return t.Spread() / mid * 10000
+}
+
+// QuoteBook keeps the latest TopOfBook per symbol. It is updated from the
+// stream goroutine and read by the engine, so all access is synchronized.
+type QuoteBook struct {
+	mu    sync.RWMutex
+	books map[string]TopOfBook
+}
+
+func NewQuoteBook() *QuoteBook {
+	return &QuoteBook{books: make(map[string]TopOfBook)}
+}
+
+func (b *QuoteBook) UpdateQuote(q Quote) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	top := b.books[q.Symbol]
+	top.Bid = q.BidPrice
+	top.BidSize = q.BidSize
+	top.Ask = q.AskPrice
+	top.AskSize = q.AskSize
+	top.QuoteTime = time.Unix(q.Timestamp, 0).UTC()
+	b.books[q.Symbol] = top
+}
+
+func (b *QuoteBook) UpdateTrade(t Trade) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	top := b.books[t.Symbol]
+	top.LastTrade = t.Price
+	top.LastTradeSize = t.Size
+	top.TradeTime = time.Unix(t.Timestamp, 0).UTC()
+	b.books[t.Symbol] = top
+}
+
+func (b *QuoteBook) Latest(symbol string) (TopOfBook, bool) {
+	if b == nil {
+		return TopOfBook{}, false
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	top, ok := b.books[symbol]
+	return top, ok
+}
@@ -0,0 +1,33 @@
+package md
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuoteBookTracksLatestQuoteAndTrade(t *testing.T) {
+	book := NewQuoteBook()
+	book.UpdateQuote(Quote{Symbol: "AAPL", BidPrice: 99.98, AskPrice: 100.02})
+	book.UpdateTrade(Trade{Symbol: "AAPL", Price: 100.01, Size: 50})
+
+	top, ok := book.Latest("AAPL")
+	if !ok {
+		t.Fatalf("expected book for AAPL")
+	}
+	if top.LastTrade != 100.01 || top.LastTradeSize != 50 {
+		t.Fatalf("unexpected last trade %+v", top)
+	}
+	if math.Abs(top.Mid()-100) > 1e-9 {
+		t.Fatalf("expected mid 100, got %f", top.Mid())
+	}
+	if math.Abs(top.SpreadBps()-4) > 1e-6 {
+		t.Fatalf("expected spread 4bps, got %f", top.SpreadBps())
+	}
+}
+
+func TestTopOfBookIgnoresCrossedQuote(t *testing.T) {
+	top := TopOfBook{Bid: 100.05, Ask: 100}
+	if top.HasQuote() || top.Spread() != 0 || top.Mid() != 0 {
+		t.Fatalf("expected crossed quote to be treated as missing")
+	}
+}
@@ -4,17 +4,26 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"time"
 
 	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata"
 	"github.com/alpacahq/alpaca-trade-api-go/v3/marketdata/stream"
 )
 
 type Bar struct {
-	Symbol    string
-	Timestamp int64
-	Close     float64
+	Symbol     string
+	Timestamp  int64
+	Open       float64
+	High       float64
+	Low        float64
+	Close      float64
+	Volume     uint64
+	Backfilled bool
 }
 
+// BarInterval is the resolution of bars delivered by the stream.
+const BarInterval = time.Minute
+
 type BarHandler func(Bar)
 
 // SDKLogger wraps slog to satisfy the Alpaca SDK's logger interface
@@ -44,12 +53,43 @@ func (l SDKLogger) Warnf(format string, v ...any) {
 	)
 }
 
-func StartStream(ctx context.Context, apiKey, apiSecret, feed, symbol string, handler BarHandler) error {
+// StreamOption enables optional subscriptions alongside bars.
+type StreamOption func(*streamOptions)
+
+type streamOptions struct {
+	quotes QuoteHandler
+	trades TradeHandler
+}
+
+func WithQuotes(handler QuoteHandler) StreamOption {
+	return func(o *streamOptions) {
+		o.quotes = handler
+	}
+}
+
+func WithTrades(handler TradeHandler) StreamOption {
+	return func(o *streamOptions) {
+		o.trades = handler
+	}
+}
+
+func StartStream(ctx context.Context, apiKey, apiSecret, feed string, symbols []string, handler BarHandler, opts ...StreamOption) error {
+	var options streamOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+
 	feedType := parseFeed(feed)
 	client := stream.NewStocksClient(
 		feedType,
 		stream.WithCredentials(apiKey, apiSecret),
 		stream.WithLogger(&SDKLogger{}),
+		stream.WithConnectCallback(func() {
+			slog.Info("market data stream connected", "symbols", symbols)
+		}),
+		stream.WithDisconnectCallback(func() {
+			slog.Warn("market data stream disconnected", "symbols", symbols)
+		}),
 	)
 
 	// Note: Connect must be called BEFORE subscribing in this SDK version
@@ -57,20 +97,54 @@ func StartStream(ctx context.Context, apiKey, apiSecret, feed, symbol string, ha
 		return fmt.Errorf("connect market data stream: %w", err)
 	}
 
-	slog.Debug("connected to stream, subscribing to bars", "symbol", symbol)
+	slog.Debug("connected to stream, subscribing to bars", "symbols", symbols)
 
 	if err := client.SubscribeToBars(func(bar stream.Bar) {
 		slog.Debug("received bar", "symbol", bar.Symbol, "timestamp", bar.Timestamp, "close", bar.Close)
 		handler(Bar{
 			Symbol:    bar.Symbol,
 			Timestamp: bar.Timestamp.Unix(),
+			Open:      bar.Open,
+			High:      bar.High,
+			Low:       bar.Low,
 			Close:     bar.Close,
+			Volume:    bar.Volume,
 		})
-	}, symbol); err != nil {
+	}, symbols...); err != nil {
 		return fmt.Errorf("subscribe to bars: %w", err)
 	}
 
-	slog.Debug("subscribed to bars", "symbol", symbol)
+	slog.Debug("subscribed to bars", "symbols", symbols)
+
+	if options.quotes != nil {
+		if err := client.SubscribeToQuotes(func(q stream.Quote) {
+			options.quotes(Quote{
+				Symbol:    q.Symbol,
+				Timestamp: q.Timestamp.Unix(),
+				BidPrice:  q.BidPrice,
+				BidSize:   q.BidSize,
+				AskPrice:  q.AskPrice,
+				AskSize:   q.AskSize,
+			})
+		}, symbols...); err != nil {
+			return fmt.Errorf("subscribe to quotes: %w", err)
+		}
+		slog.Debug("subscribed to quotes", "symbols", symbols)
+	}
+
+	if options.trades != nil {
+		if err := client.SubscribeToTrades(func(t stream.Trade) {
+			options.trades(Trade{
+				Symbol:    t.Symbol,
+				Timestamp: t.Timestamp.Unix(),
+				Price:     t.Price,
+				Size:      t.Size,
+			})
+		}, symbols...); err != nil {
+			return fmt.Errorf("subscribe to trades: %w", err)
+		}
+		slog.Debug("subscribed to trades", "symbols", symbols)
+	}
 
 	<-ctx.Done()
 	return ctx.Err()
@@ -0,0 +1,281 @@
+package md
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Synthetic price models accepted by SyntheticConfig.Model.
+const (
+	ModelGBM        = "gbm"
+	ModelOU         = "ou"
+	ModelRegime     = "regime"
+	ModelJump       = "jump"
+	ModelFlashCrash = "flash_crash"
+	ModelGapOpen    = "gap_open"
+)
+
+// minutesPerYear converts annualized drift/volatility to per-bar values
+// (252 sessions of 390 minutes).
+const minutesPerYear = 252 * 390
+
+type SyntheticConfig struct {
+	Model      string
+	Seed       int64
+	StartPrice float64
+	// Volatility and Drift are annualized; each model scales them per bar.
+	Volatility float64
+	Drift      float64
+	// Start is the first bar time; bars only fall inside calendar sessions.
+	Start time.Time
+	// Bars stops the feed after this many bars (0 runs until ctx is done).
+	Bars int
+	// Speed is bars emitted per second of wall time (0 emits without pausing).
+	Speed    float64
+	Calendar *Calendar
+	// PairSymbol, when set, adds a second symbol cointegrated with the
+	// first (see PairedGenerator) whose price is about 1/PairRatio of it.
+	PairSymbol string
+	PairRatio  float64
+}
+
+func ValidSyntheticModel(model string) bool {
+	switch model {
+	case ModelGBM, ModelOU, ModelRegime, ModelJump, ModelFlashCrash, ModelGapOpen:
+		return true
+	default:
+		return false
+	}
+}
+
+// StartSynthetic generates bars offline with the same handler contract as
+// StartStream. Quote and trade options receive a synthetic NBBO around each
+// close. It returns nil once cfg.Bars have been emitted.
+func StartSynthetic(ctx context.Context, cfg SyntheticConfig, symbol string, handler BarHandler, opts ...StreamOption) error {
+	var options streamOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	gen, err := NewSyntheticGenerator(cfg, symbol)
+	if err != nil {
+		return err
+	}
+	var paired *PairedGenerator
+	if cfg.PairSymbol != "" {
+		paired = NewPairedGenerator(cfg.PairSymbol, cfg.PairRatio, cfg.Seed+1)
+	}
+
+	var ticker *time.Ticker
+	if cfg.Speed > 0 {
+		ticker = time.NewTicker(time.Duration(float64(time.Second) / cfg.Speed))
+		defer ticker.Stop()
+	}
+
+	slog.Info("synthetic feed started", "symbol", symbol, "model", cfg.Model, "seed", cfg.Seed, "speed", cfg.Speed, "bars", cfg.Bars)
+	for i := 0; cfg.Bars == 0 || i < cfg.Bars; i++ {
+		if ticker != nil {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-ticker.C:
+			}
+		} else if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		bar := gen.Next()
+		emitSynthetic(bar, options, handler)
+		if paired != nil {
+			emitSynthetic(paired.Next(bar), options, handler)
+		}
+	}
+	slog.Info("synthetic feed finished", "symbol", symbol, "bars", cfg.Bars)
+	return nil
+}
+
+func emitSynthetic(bar Bar, options streamOptions, handler BarHandler) {
+	if options.trades != nil {
+		options.trades(Trade{Symbol: bar.Symbol, Timestamp: bar.Timestamp, Price: bar.Close, Size: 100})
+	}
+	if options.quotes != nil {
+		half := math.Max(0.01, bar.Close*0.0001)
+		options.quotes(Quote{
+			Symbol:    bar.Symbol,
+			Timestamp: bar.Timestamp,
+			BidPrice:  math.Round((bar.Close-half)*100) / 100,
+			BidSize:   1,
+			AskPrice:  math.Round((bar.Close+half)*100) / 100,
+			AskSize:   1,
+		})
+	}
+	handler(bar)
+}
+
+// SyntheticGenerator produces a deterministic bar sequence for a seed.
+type SyntheticGenerator struct {
+	cfg    SyntheticConfig
+	symbol string
+	rng    *rand.Rand
+	price  float64
+	next   time.Time
+	step   int
+	// regime model state: true while in the high-volatility bear regime
+	stressed bool
+}
+
+func NewSyntheticGenerator(cfg SyntheticConfig, symbol string) (*SyntheticGenerator, error) {
+	if cfg.Model == "" {
+		cfg.Model = ModelGBM
+	}
+	if !ValidSyntheticModel(cfg.Model) {
+		return nil, fmt.Errorf("unknown synthetic model: %s", cfg.Model)
+	}
+	if cfg.StartPrice <= 0 {
+		cfg.StartPrice = 100
+	}
+	if cfg.Volatility <= 0 {
+		cfg.Volatility = 0.3
+	}
+	if cfg.Calendar == nil {
+		cfg.Calendar = NewCalendar(nil)
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = time.Date(2024, 1, 2, 9, 30, 0, 0, cfg.Calendar.Location())
+	}
+	return &SyntheticGenerator{
+		cfg:    cfg,
+		symbol: symbol,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		price:  cfg.StartPrice,
+		next:   cfg.Start.UTC(),
+	}, nil
+}
+
+// Next returns the next in-session 1-minute bar.
+func (g *SyntheticGenerator) Next() Bar {
+	barTime, newSession := g.advance()
+	open := g.price
+	if newSession && g.step > 0 && g.cfg.Model == ModelGapOpen {
+		open = g.price * math.Exp(g.rng.NormFloat64()*0.02)
+	}
+	closePrice := math.Max(0.01, g.nextPrice(open))
+
+	sigma := g.cfg.Volatility / math.Sqrt(minutesPerYear)
+	high := math.Max(open, closePrice) * (1 + math.Abs(g.rng.NormFloat64())*sigma/2)
+	low := math.Min(open, closePrice) * (1 - math.Abs(g.rng.NormFloat64())*sigma/2)
+
+	g.price = closePrice
+	g.step++
+	return Bar{
+		Symbol:    g.symbol,
+		Timestamp: barTime.Unix(),
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     closePrice,
+		Volume:    uint64(1000 + g.rng.Intn(9000)),
+	}
+}
+
+// advance returns the next session minute and whether it opens a session.
+func (g *SyntheticGenerator) advance() (time.Time, bool) {
+	t := g.next
+	skipped := false
+	for !g.cfg.Calendar.InSession(t) {
+		t = t.Add(time.Minute)
+		skipped = true
+	}
+	g.next = t.Add(time.Minute)
+	return t, skipped
+}
+
+func (g *SyntheticGenerator) nextPrice(price float64) float64 {
+	sigma := g.cfg.Volatility / math.Sqrt(minutesPerYear)
+	mu := g.cfg.Drift / minutesPerYear
+	z := g.rng.NormFloat64()
+	gbm := func(mu, sigma float64) float64 {
+		return price * math.Exp((mu-sigma*sigma/2)+sigma*z)
+	}
+
+	switch g.cfg.Model {
+	case ModelOU:
+		// Pull toward the start price with a half-life of ~60 bars.
+		theta := math.Ln2 / 60
+		return price + theta*(g.cfg.StartPrice-price) + sigma*price*z
+	case ModelRegime:
+		if g.rng.Float64() < 0.01 {
+			g.stressed = !g.stressed
+		}
+		if g.stressed {
+			return gbm(mu-3*sigma*sigma, sigma*3)
+		}
+		return gbm(mu, sigma)
+	case ModelJump:
+		next := gbm(mu, sigma)
+		if g.rng.Float64() < 0.005 {
+			next *= math.Exp(g.rng.NormFloat64() * 0.03)
+		}
+		return next
+	case ModelFlashCrash:
+		// Scripted: drop ~8% over 5 bars starting at bar 120, then recover
+		// most of it over the following 30 bars.
+		switch {
+		case g.step >= 120 && g.step < 125:
+			return price * math.Pow(0.92, 1.0/5)
+		case g.step >= 125 && g.step < 155:
+			return price * math.Pow(1/0.92, 0.8/30) * math.Exp(sigma*z)
+		}
+		return gbm(mu, sigma)
+	default:
+		return gbm(mu, sigma)
+	}
+}
+
+// PairedGenerator derives bars of a second symbol from a base series so the
+// two are cointegrated: each close is the base close divided by the ratio
+// plus a spread that reverts to zero with a half-life of ~60 bars and
+// typically stays within 0.5% of the price.
+type PairedGenerator struct {
+	symbol string
+	ratio  float64
+	rng    *rand.Rand
+	spread float64
+	last   float64
+}
+
+// NewPairedGenerator defaults a ratio <= 0 to 2.
+func NewPairedGenerator(symbol string, ratio float64, seed int64) *PairedGenerator {
+	if ratio <= 0 {
+		ratio = 2
+	}
+	return &PairedGenerator{symbol: symbol, ratio: ratio, rng: rand.New(rand.NewSource(seed))}
+}
+
+// Next returns the paired bar for base, at the same time.
+func (g *PairedGenerator) Next(base Bar) Bar {
+	theta := math.Ln2 / 60
+	fair := base.Close / g.ratio
+	g.spread += -theta*g.spread + 0.005*fair*math.Sqrt(2*theta)*g.rng.NormFloat64()
+	closePrice := math.Max(0.01, fair+g.spread)
+	open := g.last
+	if open == 0 {
+		open = base.Open / g.ratio
+	}
+	g.last = closePrice
+	// Keep the base bar's range around the open and close.
+	high := math.Max(open, closePrice) * base.High / math.Max(base.Open, base.Close)
+	low := math.Min(open, closePrice) * base.Low / math.Min(base.Open, base.Close)
+	return Bar{
+		Symbol:    g.symbol,
+		Timestamp: base.Timestamp,
+		Open:      open,
+		High:      high,
+		Low:       low,
+		Close:     closePrice,
+		Volume:    base.Volume,
+	}
+}
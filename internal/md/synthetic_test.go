@@ -0,0 +1,83 @@
+package md
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestSyntheticGeneratorIsDeterministic(t *testing.T) {
+	cfg := SyntheticConfig{Model: ModelJump, Seed: 7}
+	a, err := NewSyntheticGenerator(cfg, "SYN")
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	b, _ := NewSyntheticGenerator(cfg, "SYN")
+
+	for i := 0; i < 100; i++ {
+		if x, y := a.Next(), b.Next(); x != y {
+			t.Fatalf("bar %d differs: %+v vs %+v", i, x, y)
+		}
+	}
+}
+
+func TestSyntheticBarsStayInSession(t *testing.T) {
+	cal := NewCalendar(nil)
+	gen, _ := NewSyntheticGenerator(SyntheticConfig{Model: ModelGapOpen, Seed: 1, Calendar: cal}, "SYN")
+
+	var prev int64
+	for i := 0; i < 800; i++ {
+		bar := gen.Next()
+		if !cal.InSession(time.Unix(bar.Timestamp, 0)) {
+			t.Fatalf("bar %d outside session: %s", i, time.Unix(bar.Timestamp, 0))
+		}
+		if bar.Timestamp <= prev || bar.Low > bar.High {
+			t.Fatalf("bar %d malformed: %+v", i, bar)
+		}
+		prev = bar.Timestamp
+	}
+}
+
+func TestSyntheticFlashCrashDrops(t *testing.T) {
+	gen, _ := NewSyntheticGenerator(SyntheticConfig{Model: ModelFlashCrash, Seed: 3}, "SYN")
+	var closes []float64
+	for i := 0; i < 130; i++ {
+		closes = append(closes, gen.Next().Close)
+	}
+	drop := closes[124] / closes[119]
+	if drop > 0.93 || drop < 0.91 {
+		t.Fatalf("expected ~8%% crash, got ratio %.4f", drop)
+	}
+}
+
+func TestStartSyntheticEmitsBarsAndQuotes(t *testing.T) {
+	book := NewQuoteBook()
+	bars := 0
+	err := StartSynthetic(context.Background(), SyntheticConfig{Seed: 1, Bars: 25}, "SYN", func(Bar) {
+		bars++
+	}, WithQuotes(book.UpdateQuote))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if bars != 25 {
+		t.Fatalf("expected 25 bars, got %d", bars)
+	}
+	if top, ok := book.Latest("SYN"); !ok || !top.HasQuote() {
+		t.Fatalf("expected synthetic quote, got %+v", top)
+	}
+}
+
+func TestPairedGeneratorTracksTheBase(t *testing.T) {
+	gen, _ := NewSyntheticGenerator(SyntheticConfig{Seed: 5, Volatility: 2}, "KO")
+	paired := NewPairedGenerator("PEP", 2, 6)
+	for i := 0; i < 2000; i++ {
+		base := gen.Next()
+		bar := paired.Next(base)
+		if bar.Symbol != "PEP" || bar.Timestamp != base.Timestamp || bar.Low > bar.High {
+			t.Fatalf("bar %d malformed: %+v", i, bar)
+		}
+		if ratio := base.Close / bar.Close; ratio < 1.9 || ratio > 2.1 {
+			t.Fatalf("bar %d: price ratio %.3f strayed from 2", i, ratio)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package money holds decimal prices and dollar amounts, so order prices,
+// notional checks and P&L never carry binary floating-point noise such as
+// 187.12999999. Market data stays float64; values cross into decimals at
+// the point they are priced or accounted.
+package money
+
+import "github.com/shopspring/decimal"
+
+// Price is a per-share price.
+type Price = decimal.Decimal
+
+// Money is a dollar amount: notional, P&L or equity.
+type Money = decimal.Decimal
+
+var (
+	cent      = decimal.New(1, -2)
+	subDollar = decimal.New(1, -4)
+	dollar    = decimal.NewFromInt(1)
+)
+
+// FromFloat converts a float price or amount using its shortest decimal
+// representation, so 187.13 stays 187.13.
+func FromFloat(f float64) decimal.Decimal {
+	return decimal.NewFromFloat(f)
+}
+
+// Tick is the minimum price increment for orders at price: a cent at or
+// above $1.00 and $0.0001 below (Reg NMS Rule 612).
+func Tick(price Price) Price {
+	if price.LessThan(dollar) {
+		return subDollar
+	}
+	return cent
+}
+
+// FloorTick rounds price down to a valid tick, for buy limits that must not
+// pay more than intended.
+func FloorTick(price Price) Price {
+	tick := Tick(price)
+	return price.Div(tick).Floor().Mul(tick)
+}
+
+// CeilTick rounds price up to a valid tick, for sell limits that must not
+// accept less than intended.
+func CeilTick(price Price) Price {
+	tick := Tick(price)
+	return price.Div(tick).Ceil().Mul(tick)
+}
+
+// RoundTick rounds price to the nearest valid tick.
+func RoundTick(price Price) Price {
+	tick := Tick(price)
+	return price.Div(tick).Round(0).Mul(tick)
+}
+
+// Cents rounds an amount down to whole cents, as notional orders require.
+func Cents(amount Money) Money {
+	return amount.RoundDown(2)
+}
+
+// Notional is the value of qty shares at price.
+func Notional(qty decimal.Decimal, price Price) Money {
+	return qty.Mul(price)
+}
@@ -0,0 +1,41 @@
+package money
+
+import (
+	"testing"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestTickRoundingByPriceBand(t *testing.T) {
+	cases := []struct {
+		price             string
+		floor, ceil, near string
+	}{
+		{"187.12999999", "187.12", "187.13", "187.13"},
+		{"187.135", "187.13", "187.14", "187.14"},
+		{"0.123456", "0.1234", "0.1235", "0.1235"},
+		{"0.99996", "0.9999", "1", "1"},
+		{"42", "42", "42", "42"},
+	}
+	for _, c := range cases {
+		price := decimal.RequireFromString(c.price)
+		if got := FloorTick(price); !got.Equal(decimal.RequireFromString(c.floor)) {
+			t.Fatalf("FloorTick(%s): expected %s, got %s", c.price, c.floor, got)
+		}
+		if got := CeilTick(price); !got.Equal(decimal.RequireFromString(c.ceil)) {
+			t.Fatalf("CeilTick(%s): expected %s, got %s", c.price, c.ceil, got)
+		}
+		if got := RoundTick(price); !got.Equal(decimal.RequireFromString(c.near)) {
+			t.Fatalf("RoundTick(%s): expected %s, got %s", c.price, c.near, got)
+		}
+	}
+}
+
+func TestFromFloatKeepsShortestRepresentation(t *testing.T) {
+	if got := FromFloat(187.13); got.String() != "187.13" {
+		t.Fatalf("expected 187.13, got %s", got)
+	}
+	if got := Cents(Notional(decimal.RequireFromString("0.333"), FromFloat(600.01))); got.String() != "199.8" {
+		t.Fatalf("expected notional rounded down to 199.80, got %s", got)
+	}
+}
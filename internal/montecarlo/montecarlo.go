@@ -0,0 +1,312 @@
+// Package montecarlo stress-tests a backtest by resampling and perturbing
+// its trades, turning one equity path into a distribution of outcomes.
+package montecarlo
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"ats/internal/report"
+)
+
+// Resampling methods accepted by Options.Method.
+const (
+	MethodBootstrap = "bootstrap"
+	MethodBlock     = "block"
+)
+
+type Options struct {
+	Simulations int
+	Method      string
+	// BlockSize is the run length for block bootstrap, which keeps streaks
+	// of correlated trades together.
+	BlockSize int
+	// SlippageBps is the scale of extra half-normal slippage charged on
+	// both entry and exit of every trade.
+	SlippageBps float64
+	// TimingJitter shifts each entry and exit by up to this many bars in
+	// either direction and re-prices the trade at those bars' closes.
+	TimingJitter int
+	// RuinLoss is the loss of initial capital (0.5 = half) that counts as
+	// ruin if touched at any point along a path.
+	RuinLoss float64
+	Seed     int64
+	Capital  float64
+}
+
+// Distribution summarizes one metric across simulations.
+type Distribution struct {
+	Mean float64 `json:"mean"`
+	Std  float64 `json:"std"`
+	Min  float64 `json:"min"`
+	P1   float64 `json:"p1"`
+	P5   float64 `json:"p5"`
+	P25  float64 `json:"p25"`
+	P50  float64 `json:"p50"`
+	P75  float64 `json:"p75"`
+	P95  float64 `json:"p95"`
+	P99  float64 `json:"p99"`
+	Max  float64 `json:"max"`
+}
+
+type Result struct {
+	Simulations int `json:"simulations"`
+	Trades      int `json:"trades"`
+	// TradesPerDay groups each resampled path into pseudo-days for the
+	// worst-day loss, since resampling discards the original dates.
+	TradesPerDay      float64      `json:"trades_per_day"`
+	FinalReturn       Distribution `json:"final_return"`
+	MaxDrawdown       Distribution `json:"max_drawdown"`
+	WorstDayLoss      Distribution `json:"worst_day_loss"`
+	ProbabilityOfRuin float64      `json:"probability_of_ruin"`
+	// Original is the unperturbed path, for comparison.
+	OriginalReturn   float64 `json:"original_return"`
+	OriginalDrawdown float64 `json:"original_drawdown"`
+}
+
+// Run simulates opts.Simulations paths from the trades in a report input.
+// The equity curve supplies bar closes for timing jitter.
+func Run(in report.Input, opts Options) (Result, error) {
+	trades := report.RoundTrips(in.Fills)
+	if len(trades) == 0 {
+		return Result{}, fmt.Errorf("no completed trades to resample")
+	}
+	if opts.Simulations <= 0 {
+		return Result{}, fmt.Errorf("simulations must be positive")
+	}
+	if opts.Method == "" {
+		opts.Method = MethodBootstrap
+	}
+	if opts.Method != MethodBootstrap && opts.Method != MethodBlock {
+		return Result{}, fmt.Errorf("unknown resampling method: %s", opts.Method)
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = 5
+	}
+	capital := opts.Capital
+	if capital <= 0 {
+		capital = in.InitialCapital
+	}
+	if capital <= 0 {
+		return Result{}, fmt.Errorf("initial capital must be positive")
+	}
+
+	perDay := tradesPerDay(trades)
+	pnls := make([]float64, len(trades))
+	for i, t := range trades {
+		pnls[i] = t.PnL
+	}
+	original := simulatePath(pnls, capital, perDay, opts.RuinLoss)
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	prices := newPriceIndex(in.Equity)
+	finals := make([]float64, opts.Simulations)
+	drawdowns := make([]float64, opts.Simulations)
+	worstDays := make([]float64, opts.Simulations)
+	ruined := 0
+	for sim := 0; sim < opts.Simulations; sim++ {
+		perturbed := make([]float64, len(trades))
+		for i, t := range trades {
+			perturbed[i] = perturb(t, prices, opts, rng)
+		}
+		path := simulatePath(resample(perturbed, opts, rng), capital, perDay, opts.RuinLoss)
+		finals[sim] = path.finalReturn
+		drawdowns[sim] = path.maxDrawdown
+		worstDays[sim] = path.worstDay
+		if path.ruined {
+			ruined++
+		}
+	}
+
+	return Result{
+		Simulations:       opts.Simulations,
+		Trades:            len(trades),
+		TradesPerDay:      perDay,
+		FinalReturn:       Summarize(finals),
+		MaxDrawdown:       Summarize(drawdowns),
+		WorstDayLoss:      Summarize(worstDays),
+		ProbabilityOfRuin: float64(ruined) / float64(opts.Simulations),
+		OriginalReturn:    original.finalReturn,
+		OriginalDrawdown:  original.maxDrawdown,
+	}, nil
+}
+
+// perturb returns a trade's P&L after timing jitter and extra slippage.
+func perturb(t report.Trade, prices priceIndex, opts Options, rng *rand.Rand) float64 {
+	pnl := t.PnL
+	sign := 1.0
+	if t.Direction == "short" {
+		sign = -1
+	}
+	entry, exit := t.EntryPrice, t.ExitPrice
+	if opts.TimingJitter > 0 && prices.len() > 1 {
+		entryIdx := prices.at(t.EntryTime) + jitter(opts.TimingJitter, rng)
+		exitIdx := prices.at(t.ExitTime) + jitter(opts.TimingJitter, rng)
+		entryIdx = clamp(entryIdx, 0, prices.len()-2)
+		exitIdx = clamp(exitIdx, entryIdx+1, prices.len()-1)
+		entry, exit = prices.price(entryIdx), prices.price(exitIdx)
+		// Keep the fees and swap only the price move.
+		pnl += sign * t.Qty * ((exit - entry) - (t.ExitPrice - t.EntryPrice))
+	}
+	if opts.SlippageBps > 0 {
+		bps := math.Abs(rng.NormFloat64()) * opts.SlippageBps
+		pnl -= t.Qty * (entry + exit) * bps / 10000
+	}
+	return pnl
+}
+
+func resample(pnls []float64, opts Options, rng *rand.Rand) []float64 {
+	n := len(pnls)
+	out := make([]float64, 0, n)
+	if opts.Method == MethodBlock {
+		block := min(opts.BlockSize, n)
+		for len(out) < n {
+			start := rng.Intn(n - block + 1)
+			for i := start; i < start+block && len(out) < n; i++ {
+				out = append(out, pnls[i])
+			}
+		}
+		return out
+	}
+	for i := 0; i < n; i++ {
+		out = append(out, pnls[rng.Intn(n)])
+	}
+	return out
+}
+
+type path struct {
+	finalReturn float64
+	maxDrawdown float64
+	worstDay    float64
+	ruined      bool
+}
+
+// simulatePath adds trade P&L to capital in sequence. Losses are reported as
+// positive fractions; worstDay is the deepest loss over consecutive chunks
+// of perDay trades, relative to equity at the start of the chunk.
+func simulatePath(pnls []float64, capital, perDay, ruinLoss float64) path {
+	var p path
+	equity, peak := capital, capital
+	dayStart, inDay := capital, 0.0
+	ruinLevel := capital * (1 - ruinLoss)
+	for _, pnl := range pnls {
+		equity += pnl
+		peak = math.Max(peak, equity)
+		if peak > 0 {
+			p.maxDrawdown = math.Max(p.maxDrawdown, 1-equity/peak)
+		}
+		if ruinLoss > 0 && equity <= ruinLevel {
+			p.ruined = true
+		}
+		inDay++
+		if inDay >= perDay {
+			p.worstDay = math.Max(p.worstDay, dayLoss(dayStart, equity))
+			dayStart, inDay = equity, 0
+		}
+	}
+	if inDay > 0 {
+		p.worstDay = math.Max(p.worstDay, dayLoss(dayStart, equity))
+	}
+	p.finalReturn = equity/capital - 1
+	return p
+}
+
+func dayLoss(start, end float64) float64 {
+	if start <= 0 || end >= start {
+		return 0
+	}
+	return 1 - end/start
+}
+
+func tradesPerDay(trades []report.Trade) float64 {
+	days := map[string]bool{}
+	for _, t := range trades {
+		days[t.ExitTime.UTC().Format("2006-01-02")] = true
+	}
+	return math.Max(1, float64(len(trades))/float64(len(days)))
+}
+
+// Summarize computes mean, standard deviation and linear-interpolated
+// percentiles.
+func Summarize(values []float64) Distribution {
+	if len(values) == 0 {
+		return Distribution{}
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	sum := 0.0
+	for _, v := range sorted {
+		sum += v
+	}
+	mean := sum / float64(len(sorted))
+	ss := 0.0
+	for _, v := range sorted {
+		ss += (v - mean) * (v - mean)
+	}
+	return Distribution{
+		Mean: mean,
+		Std:  math.Sqrt(ss / float64(len(sorted))),
+		Min:  sorted[0],
+		P1:   percentile(sorted, 0.01),
+		P5:   percentile(sorted, 0.05),
+		P25:  percentile(sorted, 0.25),
+		P50:  percentile(sorted, 0.50),
+		P75:  percentile(sorted, 0.75),
+		P95:  percentile(sorted, 0.95),
+		P99:  percentile(sorted, 0.99),
+		Max:  sorted[len(sorted)-1],
+	}
+}
+
+func percentile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	frac := pos - float64(lo)
+	return sorted[lo]*(1-frac) + sorted[hi]*frac
+}
+
+// priceIndex maps trade times onto the bar closes of the equity curve.
+type priceIndex struct {
+	times  []time.Time
+	prices []float64
+}
+
+func newPriceIndex(equity []report.EquityPoint) priceIndex {
+	idx := priceIndex{times: make([]time.Time, len(equity)), prices: make([]float64, len(equity))}
+	for i, p := range equity {
+		idx.times[i] = p.Time
+		idx.prices[i] = p.Price
+	}
+	return idx
+}
+
+func (p priceIndex) len() int {
+	return len(p.times)
+}
+
+// at returns the first bar at or after t.
+func (p priceIndex) at(t time.Time) int {
+	return sort.Search(len(p.times), func(i int) bool { return !p.times[i].Before(t) })
+}
+
+func (p priceIndex) price(i int) float64 {
+	return p.prices[i]
+}
+
+func jitter(maxBars int, rng *rand.Rand) int {
+	return rng.Intn(2*maxBars+1) - maxBars
+}
+
+func clamp(v, lo, hi int) int {
+	if v < lo {
+		return lo
+	}
+	if v > hi {
+		return hi
+	}
+	return v
+}
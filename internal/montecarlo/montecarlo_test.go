@@ -0,0 +1,72 @@
+package montecarlo
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"ats/internal/report"
+)
+
+var t0 = time.Date(2024, 1, 2, 14, 30, 0, 0, time.UTC)
+
+func roundTrips(pnls ...float64) report.Input {
+	var fills []report.Fill
+	for i, pnl := range pnls {
+		entry := t0.Add(time.Duration(2*i) * time.Minute)
+		fills = append(fills,
+			report.Fill{Time: entry, Symbol: "SPY", Side: "BUY", Qty: 1, Price: 100},
+			report.Fill{Time: entry.Add(time.Minute), Symbol: "SPY", Side: "SELL", Qty: 1, Price: 100 + pnl},
+		)
+	}
+	return report.Input{Fills: fills, InitialCapital: 1000}
+}
+
+func TestRunIsDeterministicPerSeed(t *testing.T) {
+	in := roundTrips(5, -3, 2, -8, 4, 1, -2)
+	opts := Options{Simulations: 200, Method: MethodBlock, BlockSize: 3, SlippageBps: 5, Seed: 9}
+	a, err := Run(in, opts)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	b, _ := Run(in, opts)
+	if a.FinalReturn != b.FinalReturn || a.MaxDrawdown != b.MaxDrawdown {
+		t.Fatalf("same seed gave different results")
+	}
+	if a.Trades != 7 || !almost(a.OriginalReturn, -0.001) {
+		t.Fatalf("unexpected original path: trades=%d return=%f", a.Trades, a.OriginalReturn)
+	}
+}
+
+func TestProbabilityOfRuin(t *testing.T) {
+	winners, err := Run(roundTrips(5, 5, 5), Options{Simulations: 50, RuinLoss: 0.1})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if winners.ProbabilityOfRuin != 0 || winners.MaxDrawdown.Max != 0 {
+		t.Fatalf("winning trades cannot ruin: %+v", winners)
+	}
+	losers, _ := Run(roundTrips(-60, -60), Options{Simulations: 50, RuinLoss: 0.1})
+	if losers.ProbabilityOfRuin != 1 {
+		t.Fatalf("expected certain ruin, got %f", losers.ProbabilityOfRuin)
+	}
+}
+
+func TestSlippageOnlyLowersReturns(t *testing.T) {
+	in := roundTrips(1, 1, 1, 1)
+	result, _ := Run(in, Options{Simulations: 100, SlippageBps: 10, Seed: 2})
+	if result.FinalReturn.Max > result.OriginalReturn+1e-12 {
+		t.Fatalf("slippage must not improve the path: max %f > original %f", result.FinalReturn.Max, result.OriginalReturn)
+	}
+}
+
+func TestSummarizePercentiles(t *testing.T) {
+	d := Summarize([]float64{4, 1, 3, 2, 5})
+	if d.P50 != 3 || d.Min != 1 || d.Max != 5 || !almost(d.P25, 2) || !almost(d.Mean, 3) {
+		t.Fatalf("unexpected summary: %+v", d)
+	}
+}
+
+func almost(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,327 @@
+// Package optimize sweeps strategy parameters over parallel backtests.
+package optimize
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"ats/internal/backtest"
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/report"
+	"ats/internal/strategy"
+)
+
+// SMAWindowParam tunes config.SMAWindow rather than a strategy field, since
+// the engine computes the SMA every strategy sees.
+const SMAWindowParam = "sma_window"
+
+// Objectives accepted by Score. max_drawdown is minimized, the rest maximized.
+const (
+	ObjectiveSharpe       = "sharpe"
+	ObjectiveSortino      = "sortino"
+	ObjectiveCalmar       = "calmar"
+	ObjectiveTotalReturn  = "total_return"
+	ObjectiveProfitFactor = "profit_factor"
+	ObjectiveMaxDrawdown  = "max_drawdown"
+)
+
+func ValidObjective(objective string) bool {
+	switch objective {
+	case ObjectiveSharpe, ObjectiveSortino, ObjectiveCalmar, ObjectiveTotalReturn, ObjectiveProfitFactor, ObjectiveMaxDrawdown:
+		return true
+	default:
+		return false
+	}
+}
+
+// Score returns the objective oriented so that higher is always better.
+func Score(r report.Report, objective string) float64 {
+	switch objective {
+	case ObjectiveSortino:
+		return r.Sortino
+	case ObjectiveCalmar:
+		return r.Calmar
+	case ObjectiveTotalReturn:
+		return r.TotalReturn
+	case ObjectiveProfitFactor:
+		return r.ProfitFactor
+	case ObjectiveMaxDrawdown:
+		return -r.MaxDrawdown
+	default:
+		return r.Sharpe
+	}
+}
+
+// Param is one dimension of the search space. A grid dimension lists
+// Values; a random dimension samples uniformly from [Min, Max].
+type Param struct {
+	Name   string
+	Values []float64
+	Min    float64
+	Max    float64
+}
+
+// ParseSpace reads "name=v1,v2,v3;name2=min:max". Comma lists form a grid;
+// min:max ranges are only valid for random search.
+func ParseSpace(spec string) ([]Param, error) {
+	var params []Param
+	seen := map[string]bool{}
+	for _, part := range strings.Split(spec, ";") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		name, values, ok := strings.Cut(part, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid parameter %q: expected name=values", part)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("parameter %s listed twice", name)
+		}
+		seen[name] = true
+
+		param := Param{Name: name}
+		if lo, hi, isRange := strings.Cut(values, ":"); isRange {
+			min, err := strconv.ParseFloat(strings.TrimSpace(lo), 64)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", name, err)
+			}
+			max, err := strconv.ParseFloat(strings.TrimSpace(hi), 64)
+			if err != nil {
+				return nil, fmt.Errorf("parameter %s: %w", name, err)
+			}
+			if max < min {
+				return nil, fmt.Errorf("parameter %s: range %v:%v is reversed", name, min, max)
+			}
+			param.Min, param.Max = min, max
+		} else {
+			for _, raw := range strings.Split(values, ",") {
+				v, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+				if err != nil {
+					return nil, fmt.Errorf("parameter %s: %w", name, err)
+				}
+				param.Values = append(param.Values, v)
+			}
+		}
+		params = append(params, param)
+	}
+	if len(params) == 0 {
+		return nil, fmt.Errorf("empty parameter space")
+	}
+	return params, nil
+}
+
+// Grid expands every combination of the listed values, first parameter
+// varying slowest.
+func Grid(params []Param) ([]map[string]float64, error) {
+	combos := []map[string]float64{{}}
+	for _, p := range params {
+		if len(p.Values) == 0 {
+			return nil, fmt.Errorf("parameter %s has a range; grid search needs explicit values", p.Name)
+		}
+		next := make([]map[string]float64, 0, len(combos)*len(p.Values))
+		for _, combo := range combos {
+			for _, v := range p.Values {
+				c := make(map[string]float64, len(combo)+1)
+				for k, existing := range combo {
+					c[k] = existing
+				}
+				c[p.Name] = v
+				next = append(next, c)
+			}
+		}
+		combos = next
+	}
+	return combos, nil
+}
+
+// Random draws n parameter sets. Value lists are sampled uniformly from the
+// list, ranges uniformly from [Min, Max]. The same seed gives the same trials.
+func Random(params []Param, n int, seed int64) []map[string]float64 {
+	rng := rand.New(rand.NewSource(seed))
+	combos := make([]map[string]float64, n)
+	for i := range combos {
+		c := make(map[string]float64, len(params))
+		for _, p := range params {
+			if len(p.Values) > 0 {
+				c[p.Name] = p.Values[rng.Intn(len(p.Values))]
+			} else {
+				c[p.Name] = p.Min + rng.Float64()*(p.Max-p.Min)
+			}
+		}
+		combos[i] = c
+	}
+	return combos
+}
+
+type Options struct {
+	Config    config.Config
+	Strategy  string
+	Capital   float64
+	Objective string
+	// Workers bounds concurrent backtests (0 uses every CPU).
+	Workers  int
+	Calendar *md.Calendar
+}
+
+// Trial is one backtest of the sweep.
+type Trial struct {
+	Index  int
+	Params map[string]float64
+	Report report.Report
+	Score  float64
+	Err    error
+}
+
+// Run backtests every parameter set on the same bars and returns the trials
+// ranked best first. Failed trials sort last and keep their error.
+func Run(ctx context.Context, opts Options, bars []md.Bar, combos []map[string]float64) ([]Trial, error) {
+	if !ValidObjective(opts.Objective) {
+		return nil, fmt.Errorf("unknown objective: %s", opts.Objective)
+	}
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+
+	trials := make([]Trial, len(combos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				trials[i] = runTrial(ctx, opts, bars, i, combos[i])
+			}
+		}()
+	}
+	for i := range combos {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	Rank(trials)
+	return trials, nil
+}
+
+func runTrial(ctx context.Context, opts Options, bars []md.Bar, index int, params map[string]float64) Trial {
+	trial := Trial{Index: index, Params: params}
+	cfg, strat, err := trialSetup(opts, params)
+	if err != nil {
+		trial.Err = err
+		return trial
+	}
+	result, err := backtest.Run(ctx, backtest.Options{
+		Config:   cfg,
+		Capital:  opts.Capital,
+		Calendar: opts.Calendar,
+		RunID:    fmt.Sprintf("trial-%d", index),
+	}, strat, bars)
+	if err != nil {
+		trial.Err = err
+		return trial
+	}
+	trial.Report = result.Report
+	trial.Score = Score(result.Report, opts.Objective)
+	return trial
+}
+
+// trialSetup applies a parameter set: sma_window goes to the config, the
+// rest to a fresh strategy instance.
+func trialSetup(opts Options, params map[string]float64) (config.Config, strategy.Strategy, error) {
+	cfg := opts.Config
+	strategyParams := make(map[string]float64, len(params))
+	for name, v := range params {
+		if name == SMAWindowParam {
+			cfg.SMAWindow = int(v)
+			if cfg.BarsWindow < cfg.SMAWindow {
+				cfg.BarsWindow = cfg.SMAWindow
+			}
+			continue
+		}
+		strategyParams[name] = v
+	}
+	if cfg.SMAWindow <= 1 {
+		return cfg, nil, fmt.Errorf("invalid %s: %d", SMAWindowParam, cfg.SMAWindow)
+	}
+	strat, err := strategy.Build(opts.Strategy, cfg, strategyParams)
+	return cfg, strat, err
+}
+
+// Rank sorts by score, best first; ties keep trial order and failed or
+// non-finite trials go last.
+func Rank(trials []Trial) {
+	sort.SliceStable(trials, func(i, j int) bool {
+		a, b := rankable(trials[i]), rankable(trials[j])
+		if a != b {
+			return a
+		}
+		if !a {
+			return trials[i].Index < trials[j].Index
+		}
+		return trials[i].Score > trials[j].Score
+	})
+}
+
+func rankable(t Trial) bool {
+	return t.Err == nil && !math.IsNaN(t.Score) && !math.IsInf(t.Score, 0)
+}
+
+// WriteCSV writes one row per trial: rank, parameters in the given order,
+// score and the headline metrics.
+func WriteCSV(w io.Writer, names []string, trials []Trial) error {
+	writer := csv.NewWriter(w)
+	header := append([]string{"rank", "trial"}, names...)
+	header = append(header, "score", "total_return", "sharpe", "sortino", "calmar", "max_drawdown", "profit_factor", "win_rate", "trades", "error")
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	format := func(v float64) string { return strconv.FormatFloat(v, 'g', 8, 64) }
+	for i, t := range trials {
+		row := []string{strconv.Itoa(i + 1), strconv.Itoa(t.Index)}
+		for _, name := range names {
+			row = append(row, format(t.Params[name]))
+		}
+		errText := ""
+		if t.Err != nil {
+			errText = t.Err.Error()
+		}
+		r := t.Report
+		row = append(row,
+			format(t.Score),
+			format(r.TotalReturn),
+			format(r.Sharpe),
+			format(r.Sortino),
+			format(r.Calmar),
+			format(r.MaxDrawdown),
+			format(r.ProfitFactor),
+			format(r.WinRate),
+			strconv.Itoa(r.TradeCount),
+			errText,
+		)
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
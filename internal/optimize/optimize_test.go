@@ -0,0 +1,112 @@
+package optimize
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+	"ats/internal/md"
+)
+
+func TestParseSpaceAndGrid(t *testing.T) {
+	params, err := ParseSpace("band_pct=0.01,0.02; min_bars=10,20,30")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	combos, err := Grid(params)
+	if err != nil {
+		t.Fatalf("grid: %v", err)
+	}
+	if len(combos) != 6 {
+		t.Fatalf("expected 6 combinations, got %d", len(combos))
+	}
+	if combos[0]["band_pct"] != 0.01 || combos[0]["min_bars"] != 10 || combos[5]["band_pct"] != 0.02 || combos[5]["min_bars"] != 30 {
+		t.Fatalf("unexpected grid order: %v", combos)
+	}
+}
+
+func TestGridRejectsRanges(t *testing.T) {
+	params, err := ParseSpace("band_pct=0.01:0.05")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if _, err := Grid(params); err == nil {
+		t.Fatalf("expected grid search to reject a range")
+	}
+	combos := Random(params, 20, 3)
+	for _, c := range combos {
+		if c["band_pct"] < 0.01 || c["band_pct"] > 0.05 {
+			t.Fatalf("sample out of range: %v", c)
+		}
+	}
+}
+
+func TestRankOrdersByScoreAndFailuresLast(t *testing.T) {
+	trials := []Trial{
+		{Index: 0, Score: 1},
+		{Index: 1, Err: errors.New("boom")},
+		{Index: 2, Score: 3},
+		{Index: 3, Score: 2},
+	}
+	Rank(trials)
+	order := []int{trials[0].Index, trials[1].Index, trials[2].Index, trials[3].Index}
+	if order[0] != 2 || order[1] != 3 || order[2] != 0 || order[3] != 1 {
+		t.Fatalf("unexpected ranking: %v", order)
+	}
+}
+
+func TestRunWritesEveryTrial(t *testing.T) {
+	gen, err := md.NewSyntheticGenerator(md.SyntheticConfig{Seed: 11}, "SPY")
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	bars := make([]md.Bar, 300)
+	for i := range bars {
+		bars[i] = gen.Next()
+	}
+	params, _ := ParseSpace("band_pct=0.001,0.005;sma_window=5,10")
+	combos, _ := Grid(params)
+
+	trials, err := Run(context.Background(), Options{
+		Config:    config.Default(),
+		Strategy:  "mean_reversion",
+		Capital:   10000,
+		Objective: ObjectiveTotalReturn,
+		Workers:   2,
+	}, bars, combos)
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	for i := 1; i < len(trials); i++ {
+		if trials[i].Score > trials[i-1].Score {
+			t.Fatalf("trials not ranked: %v > %v", trials[i].Score, trials[i-1].Score)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, []string{"band_pct", "sma_window"}, trials); err != nil {
+		t.Fatalf("csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(combos)+1 {
+		t.Fatalf("expected header plus %d rows, got %d lines", len(combos), len(lines))
+	}
+}
+
+func TestRunRejectsUnknownParameter(t *testing.T) {
+	trials, err := Run(context.Background(), Options{
+		Config:    config.Default(),
+		Strategy:  "sma",
+		Capital:   10000,
+		Objective: ObjectiveSharpe,
+	}, []md.Bar{{Symbol: "SPY", Timestamp: 1704205800, Open: 1, High: 1, Low: 1, Close: 1}}, []map[string]float64{{"band_pct": 1}})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if trials[0].Err == nil {
+		t.Fatalf("expected the trial to fail on an unknown parameter")
+	}
+}
@@ -0,0 +1,196 @@
+package optimize
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"ats/internal/backtest"
+	"ats/internal/md"
+	"ats/internal/report"
+)
+
+type WalkForwardOptions struct {
+	Options
+	// InSample and OutOfSample are window lengths in bars. Step defaults to
+	// OutOfSample so test windows tile without overlap.
+	InSample    int
+	OutOfSample int
+	Step        int
+	// Anchored keeps every in-sample window starting at the first bar
+	// instead of rolling it forward.
+	Anchored bool
+}
+
+// Window is one optimize-then-test cycle.
+type Window struct {
+	Index    int
+	ISStart  time.Time
+	ISEnd    time.Time
+	OOSStart time.Time
+	OOSEnd   time.Time
+	Best     map[string]float64
+	ISScore  float64
+	OOSScore float64
+	OOS      report.Report
+}
+
+// ParamStability summarizes how the chosen value of one parameter moved
+// across windows. CV is std/|mean|; a low CV means the optimum is stable.
+type ParamStability struct {
+	Name     string
+	Mean     float64
+	Std      float64
+	CV       float64
+	Min      float64
+	Max      float64
+	Distinct int
+}
+
+type WalkForwardResult struct {
+	Windows   []Window
+	Stability []ParamStability
+	// Input and Report cover the stitched out-of-sample segments, each
+	// starting flat from the marked equity the previous one ended with.
+	Input  report.Input
+	Report report.Report
+	// Efficiency is mean OOS score over mean IS score; well below 1 points
+	// to an overfit in-sample optimum.
+	Efficiency float64
+}
+
+// WalkForward optimizes on each in-sample window, then backtests the best
+// parameters on the following out-of-sample window. The in-sample bars warm
+// up the out-of-sample run, so indicators are ready when the test starts but
+// no trade is carried across.
+func WalkForward(ctx context.Context, opts WalkForwardOptions, bars []md.Bar, combos []map[string]float64) (WalkForwardResult, error) {
+	if opts.InSample <= 0 || opts.OutOfSample <= 0 {
+		return WalkForwardResult{}, fmt.Errorf("walk-forward needs positive in-sample and out-of-sample windows")
+	}
+	step := opts.Step
+	if step <= 0 {
+		step = opts.OutOfSample
+	}
+	if len(bars) < opts.InSample+opts.OutOfSample {
+		return WalkForwardResult{}, fmt.Errorf("walk-forward needs at least %d bars, got %d", opts.InSample+opts.OutOfSample, len(bars))
+	}
+
+	var result WalkForwardResult
+	equity := opts.Capital
+	var isScores, oosScores []float64
+	for start := 0; start+opts.InSample+opts.OutOfSample <= len(bars); start += step {
+		isStart := start
+		if opts.Anchored {
+			isStart = 0
+		}
+		isEnd := start + opts.InSample
+		oosEnd := isEnd + opts.OutOfSample
+		inSample := bars[isStart:isEnd]
+
+		trials, err := Run(ctx, opts.Options, inSample, combos)
+		if err != nil {
+			return WalkForwardResult{}, err
+		}
+		best := trials[0]
+		if best.Err != nil {
+			return WalkForwardResult{}, fmt.Errorf("window %d: no trial succeeded: %w", len(result.Windows), best.Err)
+		}
+
+		cfg, strat, err := trialSetup(opts.Options, best.Params)
+		if err != nil {
+			return WalkForwardResult{}, err
+		}
+		oos, err := backtest.Run(ctx, backtest.Options{
+			Config:   cfg,
+			Capital:  equity,
+			Calendar: opts.Calendar,
+			RunID:    fmt.Sprintf("oos-%d", len(result.Windows)),
+			Warmup:   len(inSample),
+		}, strat, bars[isStart:oosEnd])
+		if err != nil {
+			return WalkForwardResult{}, err
+		}
+
+		window := Window{
+			Index:    len(result.Windows),
+			ISStart:  barTime(inSample[0]),
+			ISEnd:    barTime(inSample[len(inSample)-1]),
+			OOSStart: barTime(bars[isEnd]),
+			OOSEnd:   barTime(bars[oosEnd-1]),
+			Best:     best.Params,
+			ISScore:  best.Score,
+			OOSScore: Score(oos.Report, opts.Objective),
+			OOS:      oos.Report,
+		}
+		result.Windows = append(result.Windows, window)
+		isScores = append(isScores, window.ISScore)
+		oosScores = append(oosScores, window.OOSScore)
+
+		result.Input.Fills = append(result.Input.Fills, oos.Input.Fills...)
+		result.Input.Equity = append(result.Input.Equity, oos.Input.Equity...)
+		if n := len(oos.Input.Equity); n > 0 {
+			equity = oos.Input.Equity[n-1].Equity
+		}
+	}
+
+	result.Input.InitialCapital = opts.Capital
+	result.Report = report.Compute(result.Input)
+	result.Stability = Stability(result.Windows)
+	if isMean := mean(isScores); isMean != 0 {
+		result.Efficiency = mean(oosScores) / isMean
+	}
+	return result, nil
+}
+
+// Stability reports, per parameter, the spread of the chosen values.
+func Stability(windows []Window) []ParamStability {
+	values := map[string][]float64{}
+	for _, w := range windows {
+		for name, v := range w.Best {
+			values[name] = append(values[name], v)
+		}
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	stability := make([]ParamStability, 0, len(names))
+	for _, name := range names {
+		vs := values[name]
+		s := ParamStability{Name: name, Mean: mean(vs), Min: math.Inf(1), Max: math.Inf(-1)}
+		distinct := map[float64]bool{}
+		ss := 0.0
+		for _, v := range vs {
+			s.Min = math.Min(s.Min, v)
+			s.Max = math.Max(s.Max, v)
+			distinct[v] = true
+			ss += (v - s.Mean) * (v - s.Mean)
+		}
+		s.Std = math.Sqrt(ss / float64(len(vs)))
+		if s.Mean != 0 {
+			s.CV = s.Std / math.Abs(s.Mean)
+		}
+		s.Distinct = len(distinct)
+		stability = append(stability, s)
+	}
+	return stability
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func barTime(bar md.Bar) time.Time {
+	return time.Unix(bar.Timestamp, 0).UTC()
+}
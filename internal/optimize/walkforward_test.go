@@ -0,0 +1,62 @@
+package optimize
+
+import (
+	"context"
+	"testing"
+
+	"ats/internal/config"
+	"ats/internal/md"
+)
+
+func TestWalkForwardStitchesOutOfSampleWindows(t *testing.T) {
+	gen, err := md.NewSyntheticGenerator(md.SyntheticConfig{Seed: 5}, "SPY")
+	if err != nil {
+		t.Fatalf("generator: %v", err)
+	}
+	bars := make([]md.Bar, 1000)
+	for i := range bars {
+		bars[i] = gen.Next()
+	}
+	params, _ := ParseSpace("band_pct=0.001,0.003;sma_window=5,10")
+	combos, _ := Grid(params)
+
+	result, err := WalkForward(context.Background(), WalkForwardOptions{
+		Options: Options{
+			Config:    config.Default(),
+			Strategy:  "mean_reversion",
+			Capital:   10000,
+			Objective: ObjectiveSharpe,
+			Workers:   2,
+		},
+		InSample:    400,
+		OutOfSample: 200,
+	}, bars, combos)
+	if err != nil {
+		t.Fatalf("walk-forward: %v", err)
+	}
+	if len(result.Windows) != 3 {
+		t.Fatalf("expected 3 windows, got %d", len(result.Windows))
+	}
+	if len(result.Input.Equity) != 3*200 {
+		t.Fatalf("expected 600 stitched out-of-sample points, got %d", len(result.Input.Equity))
+	}
+	for i, w := range result.Windows {
+		if !w.OOSStart.After(w.ISEnd) {
+			t.Fatalf("window %d tests inside its in-sample period", i)
+		}
+		if i > 0 && !w.OOSStart.After(result.Windows[i-1].OOSEnd) {
+			t.Fatalf("window %d overlaps the previous test window", i)
+		}
+	}
+	if len(result.Stability) != 2 || result.Stability[0].Name != "band_pct" {
+		t.Fatalf("unexpected stability: %+v", result.Stability)
+	}
+}
+
+func TestStabilityOfConstantParameter(t *testing.T) {
+	windows := []Window{{Best: map[string]float64{"x": 2}}, {Best: map[string]float64{"x": 2}}}
+	s := Stability(windows)
+	if len(s) != 1 || s[0].Std != 0 || s[0].CV != 0 || s[0].Distinct != 1 {
+		t.Fatalf("unexpected stability: %+v", s)
+	}
+}
@@ -0,0 +1,119 @@
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// PricePoint is one observation of a benchmark series.
+type PricePoint struct {
+	Time  time.Time `json:"time"`
+	Price float64   `json:"price"`
+}
+
+// Benchmark compares the strategy with buy-and-hold of a benchmark over the
+// same equity points. Alpha is annualized; the risk-free rate is taken as 0.
+type Benchmark struct {
+	Symbol           string  `json:"symbol"`
+	TotalReturn      float64 `json:"total_return"`
+	RelativeReturn   float64 `json:"relative_return"`
+	Alpha            float64 `json:"alpha"`
+	Beta             float64 `json:"beta"`
+	Correlation      float64 `json:"correlation"`
+	TrackingError    float64 `json:"tracking_error"`
+	InformationRatio float64 `json:"information_ratio"`
+}
+
+// BenchmarkFromEquity uses the traded symbol's own closes, i.e. holding the
+// instrument instead of trading it.
+func BenchmarkFromEquity(equity []EquityPoint) []PricePoint {
+	points := make([]PricePoint, 0, len(equity))
+	for _, p := range equity {
+		points = append(points, PricePoint{Time: p.Time, Price: p.Price})
+	}
+	return points
+}
+
+// AlignBenchmark samples the benchmark at each equity point, carrying the
+// last price at or before it forward. Points before the first benchmark
+// price are NaN.
+func AlignBenchmark(equity []EquityPoint, benchmark []PricePoint) []float64 {
+	sorted := append([]PricePoint(nil), benchmark...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Time.Before(sorted[j].Time) })
+	aligned := make([]float64, len(equity))
+	next := 0
+	last := math.NaN()
+	for i, p := range equity {
+		for next < len(sorted) && !sorted[next].Time.After(p.Time) {
+			last = sorted[next].Price
+			next++
+		}
+		aligned[i] = last
+	}
+	return aligned
+}
+
+// CompareBenchmark computes relative performance over the equity points
+// where the benchmark has a price.
+func CompareBenchmark(symbol string, equity []EquityPoint, benchmark []PricePoint) Benchmark {
+	b := Benchmark{Symbol: symbol}
+	prices := AlignBenchmark(equity, benchmark)
+	var strat, bench []float64
+	first, last := -1, -1
+	for i := range equity {
+		if math.IsNaN(prices[i]) || prices[i] <= 0 {
+			continue
+		}
+		if first < 0 {
+			first = i
+		} else if equity[last].Equity > 0 {
+			strat = append(strat, equity[i].Equity/equity[last].Equity-1)
+			bench = append(bench, prices[i]/prices[last]-1)
+		}
+		last = i
+	}
+	if first < 0 || last == first {
+		return b
+	}
+
+	b.TotalReturn = prices[last]/prices[first] - 1
+	if equity[first].Equity > 0 {
+		b.RelativeReturn = (equity[last].Equity/equity[first].Equity - 1) - b.TotalReturn
+	}
+
+	periods := PeriodsPerYear(equity)
+	stratMean, stratStd := meanStd(strat)
+	benchMean, benchStd := meanStd(bench)
+	cov := covariance(strat, bench, stratMean, benchMean)
+	if benchStd > 0 {
+		b.Beta = cov / (benchStd * benchStd)
+		if stratStd > 0 {
+			b.Correlation = cov / (stratStd * benchStd)
+		}
+	}
+	b.Alpha = (stratMean - b.Beta*benchMean) * periods
+
+	active := make([]float64, len(strat))
+	for i := range strat {
+		active[i] = strat[i] - bench[i]
+	}
+	activeMean, activeStd := meanStd(active)
+	b.TrackingError = activeStd * math.Sqrt(periods)
+	if activeStd > 0 {
+		b.InformationRatio = activeMean / activeStd * math.Sqrt(periods)
+	}
+	return b
+}
+
+// covariance is the sample covariance, matching meanStd's n-1 divisor.
+func covariance(a, b []float64, meanA, meanB float64) float64 {
+	if len(a) < 2 {
+		return 0
+	}
+	sum := 0.0
+	for i := range a {
+		sum += (a[i] - meanA) * (b[i] - meanB)
+	}
+	return sum / float64(len(a)-1)
+}
@@ -0,0 +1,197 @@
+package report
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// DecisionRecord is the subset of an engine decision log line that the
+// report needs. It is decoded independently so any decisions.ndjson, from
+// live, stream or backtest runs, can be evaluated.
+type DecisionRecord struct {
+	RunID      string    `json:"run_id"`
+	BarTime    time.Time `json:"bar_time"`
+	Symbol     string    `json:"symbol"`
+	Close      float64   `json:"close"`
+	SMA        float64   `json:"sma"`
+	Intent     string    `json:"intent"`
+	IntentQty  float64   `json:"intent_qty"`
+	Reason     string    `json:"reason"`
+	Result     string    `json:"result"`
+	Backfilled bool      `json:"backfilled"`
+	// Pair is set by pairs strategies, whose decisions price both legs.
+	Pair *PairRecord `json:"pair,omitempty"`
+}
+
+// PairRecord is the hedge leg of a pair decision and the order each leg led
+// to.
+type PairRecord struct {
+	HedgeSymbol string      `json:"hedge_symbol"`
+	HedgeClose  float64     `json:"hedge_close"`
+	Legs        []LegRecord `json:"legs"`
+}
+
+type LegRecord struct {
+	Symbol string  `json:"symbol"`
+	Intent string  `json:"intent"`
+	Qty    float64 `json:"qty"`
+	Result string  `json:"result"`
+}
+
+// LoadDecisions reads a decisions.ndjson file. Blank lines are skipped.
+func LoadDecisions(path string) ([]DecisionRecord, error) {
+	var records []DecisionRecord
+	err := readNDJSON(path, func(line []byte) error {
+		var record DecisionRecord
+		if err := json.Unmarshal(line, &record); err != nil {
+			return err
+		}
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+// LoadFills reads an ndjson file of Fill records.
+func LoadFills(path string) ([]Fill, error) {
+	var fills []Fill
+	err := readNDJSON(path, func(line []byte) error {
+		var fill Fill
+		if err := json.Unmarshal(line, &fill); err != nil {
+			return err
+		}
+		fills = append(fills, fill)
+		return nil
+	})
+	return fills, err
+}
+
+// FilterRun keeps the decisions of one run. An empty runID selects the last
+// run in the file, since the decision log is appended across runs.
+func FilterRun(records []DecisionRecord, runID string) []DecisionRecord {
+	if runID == "" && len(records) > 0 {
+		runID = records[len(records)-1].RunID
+	}
+	filtered := make([]DecisionRecord, 0, len(records))
+	for _, r := range records {
+		if r.RunID == runID {
+			filtered = append(filtered, r)
+		}
+	}
+	return filtered
+}
+
+// FromDecisions builds report input from a run's decisions. When fills is
+// nil, fills are simulated at the bar close for every decision that would
+// have sent an order (dry_run or order_submitted).
+func FromDecisions(records []DecisionRecord, fills []Fill, capital float64) Input {
+	if fills == nil {
+		fills = SimulatedFills(records)
+	}
+	return Input{
+		Fills:          fills,
+		Equity:         EquityCurve(records, fills, capital),
+		InitialCapital: capital,
+	}
+}
+
+func SimulatedFills(records []DecisionRecord) []Fill {
+	var fills []Fill
+	for _, r := range records {
+		if r.Result != "dry_run" && r.Result != "order_submitted" {
+			continue
+		}
+		if r.Pair != nil {
+			fills = append(fills, pairFills(r)...)
+			continue
+		}
+		if r.Intent == "HOLD" || r.IntentQty == 0 {
+			continue
+		}
+		fills = append(fills, Fill{Time: r.BarTime, Symbol: r.Symbol, Side: r.Intent, Qty: r.IntentQty, Price: r.Close})
+	}
+	return fills
+}
+
+// pairFills simulates the legs of a pair decision, each at its own close.
+func pairFills(r DecisionRecord) []Fill {
+	var fills []Fill
+	for _, leg := range r.Pair.Legs {
+		if leg.Result != r.Result || leg.Qty == 0 {
+			continue
+		}
+		price := r.Close
+		if leg.Symbol == r.Pair.HedgeSymbol {
+			price = r.Pair.HedgeClose
+		}
+		fills = append(fills, Fill{Time: r.BarTime, Symbol: leg.Symbol, Side: leg.Intent, Qty: leg.Qty, Price: price})
+	}
+	return fills
+}
+
+// EquityCurve marks cash plus positions to each decision's close. Fills are
+// applied once the bar at or after their time is reached.
+func EquityCurve(records []DecisionRecord, fills []Fill, capital float64) []EquityPoint {
+	cash := capital
+	positions := map[string]float64{}
+	prices := map[string]float64{}
+	curve := make([]EquityPoint, 0, len(records))
+	next := 0
+	for _, r := range records {
+		for next < len(fills) && !fills[next].Time.After(r.BarTime) {
+			f := fills[next]
+			signed := f.Qty
+			if isSell(f.Side) {
+				signed = -signed
+			}
+			positions[f.Symbol] += signed
+			cash -= signed*f.Price + f.Fee
+			next++
+		}
+		prices[r.Symbol] = r.Close
+		if r.Pair != nil {
+			prices[r.Pair.HedgeSymbol] = r.Pair.HedgeClose
+		}
+		equity := cash
+		for symbol, qty := range positions {
+			equity += qty * prices[symbol]
+		}
+		curve = append(curve, EquityPoint{
+			Time:     r.BarTime,
+			Equity:   equity,
+			Price:    r.Close,
+			SMA:      r.SMA,
+			Position: positions[r.Symbol],
+		})
+	}
+	return curve
+}
+
+func readNDJSON(path string, decode func([]byte) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if err := decode([]byte(line)); err != nil {
+			return fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+	}
+	return scanner.Err()
+}
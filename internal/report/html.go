@@ -0,0 +1,296 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	chartWidth  = 960
+	chartHeight = 240
+	chartPad    = 40
+)
+
+// MonthlyReturn is the compounded return of one calendar month.
+type MonthlyReturn struct {
+	Year   int
+	Month  time.Month
+	Return float64
+}
+
+// MonthlyReturns chains month-end equity, starting from initial capital.
+func MonthlyReturns(equity []EquityPoint, initial float64) []MonthlyReturn {
+	var months []MonthlyReturn
+	prev := initial
+	for i, p := range equity {
+		last := i == len(equity)-1
+		if !last {
+			next := equity[i+1].Time
+			if next.Year() == p.Time.Year() && next.Month() == p.Time.Month() {
+				continue
+			}
+		}
+		ret := 0.0
+		if prev > 0 {
+			ret = p.Equity/prev - 1
+		}
+		months = append(months, MonthlyReturn{Year: p.Time.Year(), Month: p.Time.Month(), Return: ret})
+		prev = p.Equity
+	}
+	return months
+}
+
+// WriteHTML renders a self-contained tearsheet: metrics, equity, drawdown,
+// price with SMA and fill markers, a monthly heatmap and the trade list.
+// Everything is inline SVG/CSS so the file can be archived or emailed.
+func WriteHTML(w io.Writer, title string, r Report, in Input) error {
+	data := tearsheet{
+		Title:    title,
+		Report:   r,
+		Equity:   template.HTML(equityChart(in.Equity, in.Benchmark)),
+		Drawdown: template.HTML(drawdownChart(in.Equity)),
+		Price:    template.HTML(priceChart(in.Equity, in.Fills)),
+		Heatmap:  template.HTML(heatmap(MonthlyReturns(in.Equity, in.InitialCapital))),
+	}
+	return tearsheetTemplate.Execute(w, data)
+}
+
+type tearsheet struct {
+	Title    string
+	Report   Report
+	Equity   template.HTML
+	Drawdown template.HTML
+	Price    template.HTML
+	Heatmap  template.HTML
+}
+
+var tearsheetTemplate = template.Must(template.New("tearsheet").Funcs(template.FuncMap{
+	"pct":  Percent,
+	"num":  func(v float64) string { return fmt.Sprintf("%.2f", v) },
+	"time": func(t time.Time) string { return t.Format("2006-01-02 15:04") },
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;margin:24px;color:#222}
+h1{font-size:20px}h2{font-size:16px;margin-top:28px}
+table{border-collapse:collapse;font-size:13px}td,th{padding:4px 10px;border-bottom:1px solid #eee;text-align:right}
+th{background:#f6f6f6}td:first-child,th:first-child{text-align:left}
+.metrics{display:grid;grid-template-columns:repeat(4,auto);gap:4px 24px;font-size:13px;max-width:960px}
+.metrics b{display:block;font-size:16px}
+svg{background:#fcfcfc;border:1px solid #eee}
+</style></head><body>
+<h1>{{.Title}}</h1>
+<p>{{time .Report.Start}} → {{time .Report.End}} · {{.Report.Bars}} bars · capital {{num .Report.InitialCapital}}</p>
+<div class="metrics">
+<div>Total return<b>{{pct .Report.TotalReturn}}</b></div>
+<div>Annualized return<b>{{pct .Report.AnnualizedReturn}}</b></div>
+<div>Sharpe<b>{{num .Report.Sharpe}}</b></div>
+<div>Sortino<b>{{num .Report.Sortino}}</b></div>
+<div>Max drawdown<b>{{pct .Report.MaxDrawdown}}</b></div>
+<div>Drawdown duration<b>{{.Report.MaxDrawdownDuration}}</b></div>
+<div>Calmar<b>{{num .Report.Calmar}}</b></div>
+<div>Trades<b>{{.Report.TradeCount}}</b></div>
+<div>Win rate<b>{{pct .Report.WinRate}}</b></div>
+<div>Profit factor<b>{{num .Report.ProfitFactor}}</b></div>
+<div>Exposure<b>{{pct .Report.Exposure}}</b></div>
+<div>Turnover<b>{{num .Report.Turnover}}x</b></div>
+</div>
+{{with .Report.Benchmark}}<h2>Versus {{.Symbol}}</h2>
+<div class="metrics">
+<div>Benchmark return<b>{{pct .TotalReturn}}</b></div>
+<div>Relative return<b>{{pct .RelativeReturn}}</b></div>
+<div>Alpha (annualized)<b>{{pct .Alpha}}</b></div>
+<div>Beta<b>{{num .Beta}}</b></div>
+<div>Correlation<b>{{num .Correlation}}</b></div>
+<div>Tracking error<b>{{pct .TrackingError}}</b></div>
+<div>Information ratio<b>{{num .InformationRatio}}</b></div>
+</div>{{end}}
+<h2>Equity</h2>{{.Equity}}
+<h2>Drawdown</h2>{{.Drawdown}}
+<h2>Price, SMA and fills</h2>{{.Price}}
+<h2>Monthly returns</h2>{{.Heatmap}}
+<h2>Trades</h2>
+<table><tr><th>Symbol</th><th>Side</th><th>Entry</th><th>Exit</th><th>Qty</th><th>Entry px</th><th>Exit px</th><th>P&amp;L</th><th>Return</th></tr>
+{{range .Report.Trades}}<tr><td>{{.Symbol}}</td><td>{{.Direction}}</td><td>{{time .EntryTime}}</td><td>{{time .ExitTime}}</td><td>{{.Qty}}</td><td>{{num .EntryPrice}}</td><td>{{num .ExitPrice}}</td><td>{{num .PnL}}</td><td>{{pct .Return}}</td></tr>
+{{end}}</table>
+</body></html>
+`))
+
+// scale maps values onto the chart's vertical axis. NaN marks a missing
+// point and is skipped by both the scale and the polyline.
+type scale struct {
+	min, max float64
+}
+
+func newScale(values ...[]float64) scale {
+	s := scale{min: math.Inf(1), max: math.Inf(-1)}
+	for _, series := range values {
+		for _, v := range series {
+			if math.IsNaN(v) {
+				continue
+			}
+			s.min = math.Min(s.min, v)
+			s.max = math.Max(s.max, v)
+		}
+	}
+	if math.IsInf(s.min, 1) {
+		s.min, s.max = 0, 1
+	}
+	if s.max == s.min {
+		s.max = s.min + 1
+	}
+	return s
+}
+
+func (s scale) y(v float64) float64 {
+	return chartPad + (1-(v-s.min)/(s.max-s.min))*(chartHeight-2*chartPad)
+}
+
+func xAt(i, n int) float64 {
+	if n <= 1 {
+		return chartPad
+	}
+	return chartPad + float64(i)/float64(n-1)*(chartWidth-2*chartPad)
+}
+
+func polyline(values []float64, s scale, color string) string {
+	var points strings.Builder
+	for i, v := range values {
+		if math.IsNaN(v) {
+			continue
+		}
+		fmt.Fprintf(&points, "%.1f,%.1f ", xAt(i, len(values)), s.y(v))
+	}
+	return fmt.Sprintf(`<polyline fill="none" stroke="%s" stroke-width="1.5" points="%s"/>`, color, points.String())
+}
+
+func svg(body string, s scale) string {
+	return fmt.Sprintf(`<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+
+		`<text x="4" y="%.0f" font-size="11" fill="#888">%.2f</text><text x="4" y="%.0f" font-size="11" fill="#888">%.2f</text>%s</svg>`,
+		chartWidth, chartHeight, chartWidth, chartHeight, s.y(s.max)+4, s.max, s.y(s.min), s.min, body)
+}
+
+// equityChart draws the equity curve and, with a benchmark, buy-and-hold of
+// the benchmark scaled to the same starting equity.
+func equityChart(equity []EquityPoint, benchmark []PricePoint) string {
+	values := make([]float64, len(equity))
+	for i, p := range equity {
+		values[i] = p.Equity
+	}
+	if len(benchmark) == 0 || len(equity) == 0 {
+		s := newScale(values)
+		return svg(polyline(values, s, "#1f77b4"), s)
+	}
+
+	held := AlignBenchmark(equity, benchmark)
+	base := math.NaN()
+	for i, price := range held {
+		if math.IsNaN(base) && !math.IsNaN(price) && price > 0 {
+			base = price / equity[i].Equity
+		}
+		held[i] = price / base
+	}
+	s := newScale(values, held)
+	return svg(polyline(held, s, "#999")+polyline(values, s, "#1f77b4"), s)
+}
+
+func drawdownChart(equity []EquityPoint) string {
+	values := make([]float64, len(equity))
+	peak := 0.0
+	for i, p := range equity {
+		peak = math.Max(peak, p.Equity)
+		if peak > 0 {
+			values[i] = -(1 - p.Equity/peak) * 100
+		}
+	}
+	s := newScale(values, []float64{0})
+	var area strings.Builder
+	fmt.Fprintf(&area, "%.1f,%.1f ", xAt(0, len(values)), s.y(0))
+	for i, v := range values {
+		fmt.Fprintf(&area, "%.1f,%.1f ", xAt(i, len(values)), s.y(v))
+	}
+	fmt.Fprintf(&area, "%.1f,%.1f", xAt(len(values)-1, len(values)), s.y(0))
+	return svg(fmt.Sprintf(`<polygon fill="#d62728" fill-opacity="0.35" stroke="#d62728" points="%s"/>`, area.String()), s)
+}
+
+func priceChart(equity []EquityPoint, fills []Fill) string {
+	prices := make([]float64, len(equity))
+	smas := make([]float64, len(equity))
+	for i, p := range equity {
+		prices[i] = p.Price
+		smas[i] = p.SMA
+		if p.SMA == 0 {
+			smas[i] = math.NaN() // not ready yet
+		}
+	}
+	s := newScale(prices, smas)
+	body := polyline(prices, s, "#444") + polyline(smas, s, "#ff7f0e")
+
+	next := 0
+	var markers strings.Builder
+	for i, p := range equity {
+		for next < len(fills) && !fills[next].Time.After(p.Time) {
+			f := fills[next]
+			x, y := xAt(i, len(equity)), s.y(f.Price)
+			// Fills may come from a user-supplied file, so text is escaped.
+			side := html.EscapeString(f.Side)
+			if isSell(f.Side) {
+				fmt.Fprintf(&markers, `<path d="M%.1f %.1f l-5 -8 h10 z" fill="#d62728"><title>%s %.4g @ %.2f</title></path>`, x, y, side, f.Qty, f.Price)
+			} else {
+				fmt.Fprintf(&markers, `<path d="M%.1f %.1f l-5 8 h10 z" fill="#2ca02c"><title>%s %.4g @ %.2f</title></path>`, x, y, side, f.Qty, f.Price)
+			}
+			next++
+		}
+	}
+	return svg(body+markers.String(), s)
+}
+
+func heatmap(months []MonthlyReturn) string {
+	if len(months) == 0 {
+		return "<p>No data.</p>"
+	}
+	var b strings.Builder
+	b.WriteString(`<table><tr><th>Year</th>`)
+	for m := time.January; m <= time.December; m++ {
+		fmt.Fprintf(&b, "<th>%s</th>", m.String()[:3])
+	}
+	b.WriteString("</tr>")
+	byYear := map[int]map[time.Month]float64{}
+	var years []int
+	for _, m := range months {
+		if _, ok := byYear[m.Year]; !ok {
+			byYear[m.Year] = map[time.Month]float64{}
+			years = append(years, m.Year)
+		}
+		byYear[m.Year][m.Month] = m.Return
+	}
+	for _, year := range years {
+		fmt.Fprintf(&b, "<tr><td>%d</td>", year)
+		for m := time.January; m <= time.December; m++ {
+			ret, ok := byYear[year][m]
+			if !ok {
+				b.WriteString("<td></td>")
+				continue
+			}
+			fmt.Fprintf(&b, `<td style="background:%s">%.2f%%</td>`, heatColor(ret), ret*100)
+		}
+		b.WriteString("</tr>")
+	}
+	b.WriteString("</table>")
+	return b.String()
+}
+
+// heatColor shades green for gains and red for losses, saturating at ±10%.
+func heatColor(ret float64) string {
+	intensity := math.Min(math.Abs(ret)/0.10, 1)
+	fade := int(255 - intensity*155)
+	if ret >= 0 {
+		return fmt.Sprintf("rgb(%d,255,%d)", fade, fade)
+	}
+	return fmt.Sprintf("rgb(255,%d,%d)", fade, fade)
+}
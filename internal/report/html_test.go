@@ -0,0 +1,68 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonthlyReturnsChainMonthEnds(t *testing.T) {
+	jan := time.Date(2024, 1, 31, 20, 0, 0, 0, time.UTC)
+	feb := time.Date(2024, 2, 29, 20, 0, 0, 0, time.UTC)
+	months := MonthlyReturns([]EquityPoint{
+		{Time: jan.Add(-time.Hour), Equity: 105},
+		{Time: jan, Equity: 110},
+		{Time: feb, Equity: 99},
+	}, 100)
+
+	if len(months) != 2 {
+		t.Fatalf("expected 2 months, got %d", len(months))
+	}
+	if months[0].Return < 0.0999 || months[0].Return > 0.1001 {
+		t.Fatalf("expected +10%% in January, got %f", months[0].Return)
+	}
+	if months[1].Return > -0.0999 || months[1].Return < -0.1001 {
+		t.Fatalf("expected -10%% in February, got %f", months[1].Return)
+	}
+}
+
+func TestWriteHTMLIsSelfContained(t *testing.T) {
+	records := []DecisionRecord{
+		{RunID: "a", BarTime: at(0), Symbol: "AAPL", Close: 100, SMA: 99, Intent: "BUY", IntentQty: 1, Result: "dry_run"},
+		{RunID: "a", BarTime: at(1), Symbol: "AAPL", Close: 101, SMA: 100, Intent: "SELL", IntentQty: 1, Result: "dry_run"},
+	}
+	in := FromDecisions(records, nil, 1000)
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, "test <run>", Compute(in), in); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	out := buf.String()
+	if strings.Count(out, "<svg") != 3 {
+		t.Fatalf("expected 3 inline charts")
+	}
+	if strings.Contains(out, "<script") || strings.Contains(out, "<link") || strings.Contains(out, "src=") {
+		t.Fatalf("expected no external assets")
+	}
+	if !strings.Contains(out, "test &lt;run&gt;") {
+		t.Fatalf("expected title to be escaped")
+	}
+}
+
+func TestWriteHTMLEscapesFillText(t *testing.T) {
+	records := []DecisionRecord{
+		{RunID: "a", BarTime: at(0), Symbol: "AAPL", Close: 100, SMA: 99, Intent: "HOLD", Result: "hold"},
+		{RunID: "a", BarTime: at(1), Symbol: "AAPL", Close: 101, SMA: 100, Intent: "HOLD", Result: "hold"},
+	}
+	fills := []Fill{{Time: at(0), Symbol: "AAPL", Side: `<script>alert(1)</script>`, Qty: 1, Price: 100}}
+	in := FromDecisions(records, fills, 1000)
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, "run", Compute(in), in); err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if strings.Contains(buf.String(), "<script") {
+		t.Fatalf("expected the fill side to be escaped")
+	}
+}
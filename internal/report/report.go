@@ -0,0 +1,253 @@
+// Package report evaluates a trading run from its fills and equity curve.
+package report
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+type Fill struct {
+	Time   time.Time `json:"time"`
+	Symbol string    `json:"symbol"`
+	Side   string    `json:"side"`
+	Qty    float64   `json:"qty"`
+	Price  float64   `json:"price"`
+	Fee    float64   `json:"fee,omitempty"`
+}
+
+// EquityPoint is the marked-to-market account value at one bar.
+type EquityPoint struct {
+	Time     time.Time `json:"time"`
+	Equity   float64   `json:"equity"`
+	Price    float64   `json:"price"`
+	SMA      float64   `json:"sma,omitempty"`
+	Position float64   `json:"position"`
+}
+
+type Input struct {
+	Fills          []Fill
+	Equity         []EquityPoint
+	InitialCapital float64
+	// Benchmark, when set, adds a Benchmark comparison to the report.
+	Benchmark       []PricePoint
+	BenchmarkSymbol string
+}
+
+type Report struct {
+	Start               time.Time     `json:"start"`
+	End                 time.Time     `json:"end"`
+	Bars                int           `json:"bars"`
+	InitialCapital      float64       `json:"initial_capital"`
+	FinalEquity         float64       `json:"final_equity"`
+	TotalReturn         float64       `json:"total_return"`
+	AnnualizedReturn    float64       `json:"annualized_return"`
+	AnnualizedVol       float64       `json:"annualized_volatility"`
+	Sharpe              float64       `json:"sharpe"`
+	Sortino             float64       `json:"sortino"`
+	Calmar              float64       `json:"calmar"`
+	MaxDrawdown         float64       `json:"max_drawdown"`
+	MaxDrawdownDuration time.Duration `json:"max_drawdown_duration"`
+	TradeCount          int           `json:"trade_count"`
+	WinRate             float64       `json:"win_rate"`
+	ProfitFactor        float64       `json:"profit_factor"`
+	AvgWin              float64       `json:"avg_win"`
+	AvgLoss             float64       `json:"avg_loss"`
+	Exposure            float64       `json:"exposure"`
+	Turnover            float64       `json:"turnover"`
+	PeriodsPerYear      float64       `json:"periods_per_year"`
+	Trades              []Trade       `json:"trades"`
+	Benchmark           *Benchmark    `json:"benchmark,omitempty"`
+}
+
+// Compute derives the standard metrics. Returns are per equity point and are
+// annualized with a trading-time factor inferred from the bar spacing, so a
+// minute-bar run and a daily-bar run are comparable.
+func Compute(in Input) Report {
+	r := Report{InitialCapital: in.InitialCapital, Bars: len(in.Equity)}
+	r.Trades = RoundTrips(in.Fills)
+	r.TradeCount = len(r.Trades)
+	tradeStats(&r)
+
+	if len(in.Equity) == 0 {
+		r.FinalEquity = in.InitialCapital
+		return r
+	}
+
+	r.Start = in.Equity[0].Time
+	r.End = in.Equity[len(in.Equity)-1].Time
+	r.FinalEquity = in.Equity[len(in.Equity)-1].Equity
+	if in.InitialCapital > 0 {
+		r.TotalReturn = r.FinalEquity/in.InitialCapital - 1
+	}
+
+	returns := Returns(in.Equity, in.InitialCapital)
+	r.PeriodsPerYear = PeriodsPerYear(in.Equity)
+	if n := len(returns); n > 0 && r.TotalReturn > -1 {
+		r.AnnualizedReturn = math.Pow(1+r.TotalReturn, r.PeriodsPerYear/float64(n)) - 1
+	}
+	mean, std := meanStd(returns)
+	r.AnnualizedVol = std * math.Sqrt(r.PeriodsPerYear)
+	if std > 0 {
+		r.Sharpe = mean / std * math.Sqrt(r.PeriodsPerYear)
+	}
+	if downside := downsideDeviation(returns); downside > 0 {
+		r.Sortino = mean / downside * math.Sqrt(r.PeriodsPerYear)
+	}
+
+	r.MaxDrawdown, r.MaxDrawdownDuration = Drawdown(in.Equity)
+	if r.MaxDrawdown > 0 {
+		r.Calmar = r.AnnualizedReturn / r.MaxDrawdown
+	}
+
+	exposed := 0
+	for _, p := range in.Equity {
+		if p.Position != 0 {
+			exposed++
+		}
+	}
+	r.Exposure = float64(exposed) / float64(len(in.Equity))
+
+	traded := 0.0
+	for _, f := range in.Fills {
+		traded += math.Abs(f.Qty * f.Price)
+	}
+	if avg := averageEquity(in.Equity); avg > 0 {
+		r.Turnover = traded / avg
+	}
+
+	if len(in.Benchmark) > 0 {
+		b := CompareBenchmark(in.BenchmarkSymbol, in.Equity, in.Benchmark)
+		r.Benchmark = &b
+	}
+	return r
+}
+
+// Returns are simple per-point returns, the first measured from capital.
+func Returns(equity []EquityPoint, initial float64) []float64 {
+	returns := make([]float64, 0, len(equity))
+	prev := initial
+	for _, p := range equity {
+		if prev > 0 {
+			returns = append(returns, p.Equity/prev-1)
+		}
+		prev = p.Equity
+	}
+	return returns
+}
+
+// Drawdown returns the deepest peak-to-trough loss as a fraction and the
+// longest time spent below a prior peak.
+func Drawdown(equity []EquityPoint) (float64, time.Duration) {
+	if len(equity) == 0 {
+		return 0, 0
+	}
+	peak := equity[0].Equity
+	peakTime := equity[0].Time
+	maxDD := 0.0
+	var longest time.Duration
+	for _, p := range equity {
+		if p.Equity >= peak {
+			peak = p.Equity
+			peakTime = p.Time
+			continue
+		}
+		if peak > 0 {
+			maxDD = math.Max(maxDD, 1-p.Equity/peak)
+		}
+		if d := p.Time.Sub(peakTime); d > longest {
+			longest = d
+		}
+	}
+	return maxDD, longest
+}
+
+// PeriodsPerYear infers the annualization factor from the median bar spacing
+// (252 sessions of 6.5 hours for intraday bars, 252 for daily or slower).
+func PeriodsPerYear(equity []EquityPoint) float64 {
+	if len(equity) < 2 {
+		return 252
+	}
+	gaps := make([]time.Duration, 0, len(equity)-1)
+	for i := 1; i < len(equity); i++ {
+		gaps = append(gaps, equity[i].Time.Sub(equity[i-1].Time))
+	}
+	sort.Slice(gaps, func(i, j int) bool { return gaps[i] < gaps[j] })
+	median := gaps[len(gaps)/2]
+	session := 390 * time.Minute
+	if median <= 0 || median >= session {
+		return 252
+	}
+	return 252 * float64(session) / float64(median)
+}
+
+func tradeStats(r *Report) {
+	grossWin, grossLoss := 0.0, 0.0
+	wins, losses := 0, 0
+	for _, t := range r.Trades {
+		switch {
+		case t.PnL > 0:
+			wins++
+			grossWin += t.PnL
+		case t.PnL < 0:
+			losses++
+			grossLoss -= t.PnL
+		}
+	}
+	if r.TradeCount > 0 {
+		r.WinRate = float64(wins) / float64(r.TradeCount)
+	}
+	if wins > 0 {
+		r.AvgWin = grossWin / float64(wins)
+	}
+	if losses > 0 {
+		r.AvgLoss = -grossLoss / float64(losses)
+	}
+	// Left at zero when nothing lost: +Inf does not survive JSON encoding.
+	if grossLoss > 0 {
+		r.ProfitFactor = grossWin / grossLoss
+	}
+}
+
+func meanStd(values []float64) (float64, float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	mean := sum / float64(len(values))
+	if len(values) < 2 {
+		return mean, 0
+	}
+	ss := 0.0
+	for _, v := range values {
+		ss += (v - mean) * (v - mean)
+	}
+	return mean, math.Sqrt(ss / float64(len(values)-1))
+}
+
+func downsideDeviation(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	ss := 0.0
+	for _, v := range values {
+		if v < 0 {
+			ss += v * v
+		}
+	}
+	return math.Sqrt(ss / float64(len(values)))
+}
+
+func averageEquity(equity []EquityPoint) float64 {
+	if len(equity) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, p := range equity {
+		sum += p.Equity
+	}
+	return sum / float64(len(equity))
+}
@@ -0,0 +1,159 @@
+package report
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 3, 12, 14, 30, 0, 0, time.UTC)
+
+func at(minutes int) time.Time {
+	return t0.Add(time.Duration(minutes) * time.Minute)
+}
+
+func TestRoundTripsFIFOAndReversal(t *testing.T) {
+	fills := []Fill{
+		{Time: at(0), Symbol: "AAPL", Side: "BUY", Qty: 2, Price: 100},
+		{Time: at(1), Symbol: "AAPL", Side: "SELL", Qty: 3, Price: 110},
+		{Time: at(2), Symbol: "AAPL", Side: "BUY", Qty: 1, Price: 105},
+	}
+	trades := RoundTrips(fills)
+	if len(trades) != 2 {
+		t.Fatalf("expected 2 trades, got %d", len(trades))
+	}
+	if trades[0].Direction != "long" || trades[0].Qty != 2 || trades[0].PnL != 20 {
+		t.Fatalf("unexpected long trade %+v", trades[0])
+	}
+	if trades[1].Direction != "short" || trades[1].PnL != 5 {
+		t.Fatalf("unexpected short trade %+v", trades[1])
+	}
+}
+
+func TestRoundTripsMergeScaledExits(t *testing.T) {
+	fills := []Fill{
+		{Time: at(0), Symbol: "AAPL", Side: "BUY", Qty: 4, Price: 100},
+		{Time: at(1), Symbol: "MSFT", Side: "BUY", Qty: 1, Price: 50},
+		{Time: at(2), Symbol: "AAPL", Side: "SELL", Qty: 1, Price: 104},
+		{Time: at(3), Symbol: "MSFT", Side: "SELL", Qty: 1, Price: 51},
+		{Time: at(5), Symbol: "AAPL", Side: "SELL", Qty: 3, Price: 108},
+	}
+	trades := RoundTrips(fills)
+	if len(trades) != 2 {
+		t.Fatalf("expected the two AAPL exits as one trade, got %+v", trades)
+	}
+	aapl := trades[0]
+	if aapl.Qty != 4 || aapl.PnL != 28 || aapl.ExitPrice != 107 || !aapl.ExitTime.Equal(at(5)) || aapl.Holding != 5*time.Minute {
+		t.Fatalf("unexpected merged trade %+v", aapl)
+	}
+}
+
+func TestDrawdownDepthAndDuration(t *testing.T) {
+	equity := []EquityPoint{
+		{Time: at(0), Equity: 100},
+		{Time: at(1), Equity: 120},
+		{Time: at(2), Equity: 90},
+		{Time: at(5), Equity: 110},
+		{Time: at(6), Equity: 125},
+	}
+	dd, duration := Drawdown(equity)
+	if math.Abs(dd-0.25) > 1e-9 {
+		t.Fatalf("expected 25%% drawdown, got %f", dd)
+	}
+	if duration != 4*time.Minute {
+		t.Fatalf("expected 4m under water, got %s", duration)
+	}
+}
+
+func TestComputeFromDecisions(t *testing.T) {
+	records := []DecisionRecord{
+		{RunID: "a", BarTime: at(0), Symbol: "AAPL", Close: 100, Intent: "BUY", IntentQty: 10, Result: "dry_run"},
+		{RunID: "a", BarTime: at(1), Symbol: "AAPL", Close: 105, Intent: "HOLD", Result: "hold"},
+		{RunID: "a", BarTime: at(2), Symbol: "AAPL", Close: 102, Intent: "SELL", IntentQty: 10, Result: "dry_run"},
+		{RunID: "a", BarTime: at(3), Symbol: "AAPL", Close: 99, Intent: "BUY", IntentQty: 1, Result: "rejected"},
+	}
+	r := Compute(FromDecisions(records, nil, 1000))
+
+	if r.TradeCount != 1 || r.WinRate != 1 {
+		t.Fatalf("expected one winning trade, got %d trades win rate %f", r.TradeCount, r.WinRate)
+	}
+	if math.Abs(r.FinalEquity-1020) > 1e-9 {
+		t.Fatalf("expected final equity 1020, got %f", r.FinalEquity)
+	}
+	if math.Abs(r.Exposure-0.5) > 1e-9 {
+		t.Fatalf("expected 50%% exposure, got %f", r.Exposure)
+	}
+	if r.PeriodsPerYear != 252*390 {
+		t.Fatalf("expected minute annualization, got %f", r.PeriodsPerYear)
+	}
+}
+
+func TestPairDecisionsValueBothLegs(t *testing.T) {
+	pair := func(hedge float64, legs ...LegRecord) *PairRecord {
+		return &PairRecord{HedgeSymbol: "PEP", HedgeClose: hedge, Legs: legs}
+	}
+	records := []DecisionRecord{
+		{BarTime: at(0), Symbol: "KO", Close: 60, Result: "dry_run", Pair: pair(170,
+			LegRecord{Symbol: "KO", Intent: "SELL_SHORT", Qty: 3, Result: "dry_run"},
+			LegRecord{Symbol: "PEP", Intent: "BUY", Qty: 1, Result: "dry_run"})},
+		{BarTime: at(1), Symbol: "KO", Close: 58, Result: "hold", Pair: pair(171)},
+		{BarTime: at(2), Symbol: "KO", Close: 59, Result: "dry_run", Pair: pair(169,
+			LegRecord{Symbol: "KO", Intent: "BUY_TO_COVER", Qty: 3, Result: "dry_run"},
+			LegRecord{Symbol: "PEP", Intent: "SELL", Qty: 1, Result: "dry_run"})},
+	}
+	in := FromDecisions(records, nil, 1000)
+	if len(in.Fills) != 4 || in.Fills[1].Price != 170 {
+		t.Fatalf("expected both legs filled at their own closes, got %+v", in.Fills)
+	}
+	// Short 3 KO from 60 to 58 (+6) and long 1 PEP from 170 to 171 (+1).
+	if got := in.Equity[1].Equity; math.Abs(got-1007) > 1e-9 {
+		t.Fatalf("expected equity 1007 marked on both legs, got %f", got)
+	}
+	if got := in.Equity[2].Equity; math.Abs(got-1002) > 1e-9 {
+		t.Fatalf("expected final equity 1002, got %f", got)
+	}
+}
+
+func TestFilterRunDefaultsToLastRun(t *testing.T) {
+	records := []DecisionRecord{{RunID: "a"}, {RunID: "b"}, {RunID: "b"}}
+	if got := FilterRun(records, ""); len(got) != 2 {
+		t.Fatalf("expected last run's 2 records, got %d", len(got))
+	}
+}
+
+func TestCompareBenchmarkAgainstItself(t *testing.T) {
+	prices := []float64{100, 101, 99, 102, 104}
+	var equity []EquityPoint
+	var bench []PricePoint
+	for i, p := range prices {
+		equity = append(equity, EquityPoint{Time: at(i), Equity: p * 10, Price: p})
+		bench = append(bench, PricePoint{Time: at(i), Price: p})
+	}
+	b := CompareBenchmark("SPY", equity, bench)
+	if !almostEqual(b.Beta, 1) || !almostEqual(b.Correlation, 1) || !almostEqual(b.Alpha, 0) {
+		t.Fatalf("holding the benchmark should give beta 1, correlation 1, alpha 0: %+v", b)
+	}
+	if !almostEqual(b.TotalReturn, 0.04) || !almostEqual(b.RelativeReturn, 0) || !almostEqual(b.TrackingError, 0) {
+		t.Fatalf("unexpected relative figures: %+v", b)
+	}
+}
+
+func TestCompareBenchmarkFlatStrategy(t *testing.T) {
+	var equity []EquityPoint
+	for i := 0; i < 4; i++ {
+		equity = append(equity, EquityPoint{Time: at(i), Equity: 1000})
+	}
+	// The benchmark starts before the run and is sampled as of each point.
+	bench := []PricePoint{{Time: at(-5), Price: 50}, {Time: at(1), Price: 55}, {Time: at(3), Price: 60}}
+	b := CompareBenchmark("QQQ", equity, bench)
+	if b.Beta != 0 || b.Correlation != 0 {
+		t.Fatalf("a flat strategy has no beta: %+v", b)
+	}
+	if !almostEqual(b.TotalReturn, 0.2) || !almostEqual(b.RelativeReturn, -0.2) {
+		t.Fatalf("unexpected returns: %+v", b)
+	}
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
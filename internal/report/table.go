@@ -0,0 +1,61 @@
+package report
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+type tableRow struct {
+	label string
+	value string
+}
+
+// WriteTable prints the headline metrics as an aligned two-column table.
+func WriteTable(w io.Writer, r Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	rows := []tableRow{
+		{"Period", fmt.Sprintf("%s → %s (%d bars)", r.Start.Format(time.RFC3339), r.End.Format(time.RFC3339), r.Bars)},
+		{"Initial capital", fmt.Sprintf("%.2f", r.InitialCapital)},
+		{"Final equity", fmt.Sprintf("%.2f", r.FinalEquity)},
+		{"Total return", Percent(r.TotalReturn)},
+		{"Annualized return", Percent(r.AnnualizedReturn)},
+		{"Annualized volatility", Percent(r.AnnualizedVol)},
+		{"Sharpe", fmt.Sprintf("%.2f", r.Sharpe)},
+		{"Sortino", fmt.Sprintf("%.2f", r.Sortino)},
+		{"Calmar", fmt.Sprintf("%.2f", r.Calmar)},
+		{"Max drawdown", Percent(r.MaxDrawdown)},
+		{"Max drawdown duration", r.MaxDrawdownDuration.String()},
+		{"Trades", fmt.Sprintf("%d", r.TradeCount)},
+		{"Win rate", Percent(r.WinRate)},
+		{"Profit factor", fmt.Sprintf("%.2f", r.ProfitFactor)},
+		{"Average win", fmt.Sprintf("%.2f", r.AvgWin)},
+		{"Average loss", fmt.Sprintf("%.2f", r.AvgLoss)},
+		{"Exposure", Percent(r.Exposure)},
+		{"Turnover", fmt.Sprintf("%.2fx", r.Turnover)},
+	}
+	if b := r.Benchmark; b != nil {
+		rows = append(rows, []tableRow{
+			{"Benchmark", b.Symbol},
+			{"Benchmark return", Percent(b.TotalReturn)},
+			{"Relative return", Percent(b.RelativeReturn)},
+			{"Alpha (annualized)", Percent(b.Alpha)},
+			{"Beta", fmt.Sprintf("%.2f", b.Beta)},
+			{"Correlation", fmt.Sprintf("%.2f", b.Correlation)},
+			{"Tracking error", Percent(b.TrackingError)},
+			{"Information ratio", fmt.Sprintf("%.2f", b.InformationRatio)},
+		}...)
+	}
+	for _, row := range rows {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\n", row.label, row.value); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+// Percent formats a fraction as a percentage with two decimals.
+func Percent(v float64) string {
+	return fmt.Sprintf("%.2f%%", v*100)
+}
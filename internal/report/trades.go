@@ -0,0 +1,125 @@
+package report
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Trade is a completed round trip: a position opened and fully or partly
+// closed at a volume-weighted entry and exit.
+type Trade struct {
+	Symbol     string        `json:"symbol"`
+	Direction  string        `json:"direction"`
+	EntryTime  time.Time     `json:"entry_time"`
+	ExitTime   time.Time     `json:"exit_time"`
+	Qty        float64       `json:"qty"`
+	EntryPrice float64       `json:"entry_price"`
+	ExitPrice  float64       `json:"exit_price"`
+	PnL        float64       `json:"pnl"`
+	Return     float64       `json:"return"`
+	Holding    time.Duration `json:"holding"`
+}
+
+type lot struct {
+	time  time.Time
+	qty   float64 // signed: positive long, negative short
+	price float64
+	fee   float64 // entry fee per share
+}
+
+// RoundTrips matches fills FIFO per symbol. A fill that crosses through zero
+// closes the open lots and opens a new position in the other direction.
+func RoundTrips(fills []Fill) []Trade {
+	open := map[string][]lot{}
+	var trades []Trade
+	for _, f := range fills {
+		qty := f.Qty
+		if isSell(f.Side) {
+			qty = -qty
+		}
+		feePerShare := 0.0
+		if f.Qty != 0 {
+			feePerShare = f.Fee / math.Abs(f.Qty)
+		}
+		lots := open[f.Symbol]
+		for qty != 0 && len(lots) > 0 && sameSign(-qty, lots[0].qty) {
+			head := &lots[0]
+			closed := math.Min(math.Abs(qty), math.Abs(head.qty))
+			direction, sign := "long", 1.0
+			if head.qty < 0 {
+				direction, sign = "short", -1.0
+			}
+			pnl := sign*closed*(f.Price-head.price) - closed*(head.fee+feePerShare)
+			trades = append(trades, Trade{
+				Symbol:     f.Symbol,
+				Direction:  direction,
+				EntryTime:  head.time,
+				ExitTime:   f.Time,
+				Qty:        closed,
+				EntryPrice: head.price,
+				ExitPrice:  f.Price,
+				PnL:        pnl,
+				Return:     pnl / (closed * head.price),
+				Holding:    f.Time.Sub(head.time),
+			})
+			head.qty -= sign * closed
+			qty += sign * closed
+			if head.qty == 0 {
+				lots = lots[1:]
+			}
+		}
+		if qty != 0 {
+			lots = append(lots, lot{time: f.Time, qty: qty, price: f.Price, fee: feePerShare})
+		}
+		open[f.Symbol] = lots
+	}
+	return mergeTrades(trades)
+}
+
+// mergeTrades folds the partial closes of one entry into a single trade so
+// a scaled exit counts once: quantities and P&L add up, the exit price is
+// volume-weighted and the trade ends at the last exit.
+func mergeTrades(trades []Trade) []Trade {
+	type entry struct {
+		symbol, direction string
+		time              time.Time
+	}
+	var merged []Trade
+	index := map[entry]int{}
+	for _, t := range trades {
+		key := entry{t.Symbol, t.Direction, t.EntryTime.UTC()}
+		i, ok := index[key]
+		if !ok {
+			index[key] = len(merged)
+			merged = append(merged, t)
+			continue
+		}
+		last := &merged[i]
+		cost := last.Qty*last.EntryPrice + t.Qty*t.EntryPrice
+		proceeds := last.Qty*last.ExitPrice + t.Qty*t.ExitPrice
+		last.Qty += t.Qty
+		last.EntryPrice = cost / last.Qty
+		last.ExitPrice = proceeds / last.Qty
+		last.PnL += t.PnL
+		last.Return = last.PnL / cost
+		if t.ExitTime.After(last.ExitTime) {
+			last.ExitTime = t.ExitTime
+			last.Holding = last.ExitTime.Sub(last.EntryTime)
+		}
+	}
+	return merged
+}
+
+func isSell(side string) bool {
+	switch strings.ToUpper(side) {
+	case "SELL", "SELL_SHORT":
+		return true
+	default:
+		return false
+	}
+}
+
+func sameSign(a, b float64) bool {
+	return (a > 0 && b > 0) || (a < 0 && b < 0)
+}
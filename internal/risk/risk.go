@@ -5,22 +5,34 @@ import (
 	"log/slog"
 	"time"
 
+	"ats/internal/money"
 	"ats/internal/strategy"
+
+	"github.com/shopspring/decimal"
 )
 
 type RiskContext struct {
 	Now            time.Time
-	Price          float64
-	PositionQty    int
+	Price          money.Price
+	Bid            money.Price
+	Ask            money.Price
+	PositionQty    decimal.Decimal
 	OpenOrderCount int
 	LastTradeTime  time.Time
 	MaxQty         int
-	MaxNotional    float64
-	Cooldown       time.Duration
-	KillSwitch     bool
-	ExtendedHours  bool
-	OrderType      string
-	TimeInForce    string
+	MaxNotional    money.Money
+	// AllowShort enables SellShort; covering an existing short is always
+	// allowed. Borrowable is the locate result for a short sale.
+	AllowShort       bool
+	Borrowable       bool
+	MaxShortQty      int
+	MaxShortNotional money.Money
+	MaxSpreadBps     float64
+	Cooldown         time.Duration
+	KillSwitch       bool
+	ExtendedHours    bool
+	OrderType        string
+	TimeInForce      string
 }
 
 type ApprovedIntent struct {
@@ -31,7 +43,7 @@ type ApprovedIntent struct {
 type Gate struct{}
 
 func (g Gate) Evaluate(intent strategy.TradeIntent, ctx RiskContext) (ApprovedIntent, error) {
-	notional := ctx.Price * float64(intent.Qty)
+	notional := money.Notional(intent.Qty, ctx.Price)
 
 	if intent.Action == strategy.Hold {
 		return ApprovedIntent{Intent: intent, Reason: "hold"}, nil
@@ -52,22 +64,72 @@ func (g Gate) Evaluate(intent strategy.TradeIntent, ctx RiskContext) (ApprovedIn
 		slog.Info("risk rejected", "reason", "cooldown_active", "remaining", remaining)
 		return ApprovedIntent{}, fmt.Errorf("cooldown_active")
 	}
-	if intent.Qty <= 0 {
+	if !intent.Qty.IsPositive() {
 		slog.Info("risk rejected", "reason", "invalid_quantity", "qty", intent.Qty)
 		return ApprovedIntent{}, fmt.Errorf("invalid_quantity")
 	}
-	if intent.Action == strategy.Buy && intent.Qty+ctx.PositionQty > ctx.MaxQty {
-		slog.Info("risk rejected", "reason", "max_position_exceeded", "new_qty", intent.Qty+ctx.PositionQty, "max", ctx.MaxQty)
+	if newQty := ctx.PositionQty.Add(intent.Qty); intent.Action == strategy.Buy && newQty.GreaterThan(strategy.Shares(ctx.MaxQty)) {
+		slog.Info("risk rejected", "reason", "max_position_exceeded", "new_qty", newQty, "max", ctx.MaxQty)
 		return ApprovedIntent{}, fmt.Errorf("max_position_exceeded")
 	}
-	if intent.Action == strategy.Sell && ctx.PositionQty <= 0 {
+	if intent.Action == strategy.Buy && ctx.PositionQty.IsNegative() {
+		slog.Info("risk rejected", "reason", "short_position_open")
+		return ApprovedIntent{}, fmt.Errorf("short_position_open")
+	}
+	if intent.Action == strategy.Sell && !ctx.PositionQty.IsPositive() {
 		slog.Info("risk rejected", "reason", "no_position_to_sell")
 		return ApprovedIntent{}, fmt.Errorf("no_position_to_sell")
 	}
-	if notional > ctx.MaxNotional {
+	if intent.Action == strategy.Sell && intent.Qty.GreaterThan(ctx.PositionQty) {
+		slog.Info("risk rejected", "reason", "sell_exceeds_position", "qty", intent.Qty, "position", ctx.PositionQty)
+		return ApprovedIntent{}, fmt.Errorf("sell_exceeds_position")
+	}
+	if intent.Action == strategy.SellShort {
+		if !ctx.AllowShort {
+			slog.Info("risk rejected", "reason", "short_selling_disabled")
+			return ApprovedIntent{}, fmt.Errorf("short_selling_disabled")
+		}
+		if ctx.PositionQty.IsPositive() {
+			slog.Info("risk rejected", "reason", "long_position_open")
+			return ApprovedIntent{}, fmt.Errorf("long_position_open")
+		}
+		if !ctx.Borrowable {
+			slog.Info("risk rejected", "reason", "not_easy_to_borrow")
+			return ApprovedIntent{}, fmt.Errorf("not_easy_to_borrow")
+		}
+		if newQty := ctx.PositionQty.Sub(intent.Qty); newQty.Neg().GreaterThan(strategy.Shares(ctx.MaxShortQty)) {
+			slog.Info("risk rejected", "reason", "max_short_exceeded", "new_qty", newQty, "max", ctx.MaxShortQty)
+			return ApprovedIntent{}, fmt.Errorf("max_short_exceeded")
+		}
+		if notional.GreaterThan(ctx.MaxShortNotional) {
+			slog.Info("risk rejected", "reason", "max_short_notional_exceeded", "notional", notional, "max", ctx.MaxShortNotional)
+			return ApprovedIntent{}, fmt.Errorf("max_short_notional_exceeded")
+		}
+	}
+	if intent.Action == strategy.BuyToCover && !ctx.PositionQty.IsNegative() {
+		slog.Info("risk rejected", "reason", "no_short_to_cover")
+		return ApprovedIntent{}, fmt.Errorf("no_short_to_cover")
+	}
+	if intent.Action == strategy.BuyToCover && intent.Qty.GreaterThan(ctx.PositionQty.Neg()) {
+		slog.Info("risk rejected", "reason", "cover_exceeds_short", "qty", intent.Qty, "position", ctx.PositionQty)
+		return ApprovedIntent{}, fmt.Errorf("cover_exceeds_short")
+	}
+	if notional.GreaterThan(ctx.MaxNotional) {
 		slog.Info("risk rejected", "reason", "max_notional_exceeded", "notional", notional, "max", ctx.MaxNotional)
 		return ApprovedIntent{}, fmt.Errorf("max_notional_exceeded")
 	}
+	if ctx.MaxSpreadBps > 0 {
+		if !ctx.Bid.IsPositive() || !ctx.Ask.IsPositive() || ctx.Ask.LessThan(ctx.Bid) {
+			slog.Info("risk rejected", "reason", "quote_unavailable", "bid", ctx.Bid, "ask", ctx.Ask)
+			return ApprovedIntent{}, fmt.Errorf("quote_unavailable")
+		}
+		mid := ctx.Ask.Add(ctx.Bid).Div(decimal.NewFromInt(2))
+		spreadBps := ctx.Ask.Sub(ctx.Bid).Div(mid).Mul(decimal.NewFromInt(10000)).InexactFloat64()
+		if spreadBps > ctx.MaxSpreadBps {
+			slog.Info("risk rejected", "reason", "max_spread_exceeded", "spread_bps", spreadBps, "max", ctx.MaxSpreadBps)
+			return ApprovedIntent{}, fmt.Errorf("max_spread_exceeded")
+		}
+	}
 	if ctx.ExtendedHours {
 		if ctx.OrderType != "limit" || ctx.TimeInForce != "day" {
 			slog.Info("risk rejected", "reason", "extended_hours_requires_limit_day")
@@ -4,19 +4,22 @@ import (
 	"testing"
 	"time"
 
+	"ats/internal/money"
 	"ats/internal/strategy"
+
+	"github.com/shopspring/decimal"
 )
 
 func TestGateRejectsCooldown(t *testing.T) {
 	gate := Gate{}
-	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: 1}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1)}
 	ctx := RiskContext{
 		Now:           time.Now(),
 		LastTradeTime: time.Now().Add(-30 * time.Second),
 		Cooldown:      time.Minute,
-		Price:         100,
+		Price:         money.FromFloat(100),
 		MaxQty:        5,
-		MaxNotional:   1000,
+		MaxNotional:   money.FromFloat(1000),
 	}
 
 	if _, err := gate.Evaluate(intent, ctx); err == nil {
@@ -26,12 +29,12 @@ func TestGateRejectsCooldown(t *testing.T) {
 
 func TestGateRejectsMaxNotional(t *testing.T) {
 	gate := Gate{}
-	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: 2}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(2)}
 	ctx := RiskContext{
 		Now:         time.Now(),
-		Price:       100,
+		Price:       money.FromFloat(100),
 		MaxQty:      5,
-		MaxNotional: 150,
+		MaxNotional: money.FromFloat(150),
 	}
 
 	if _, err := gate.Evaluate(intent, ctx); err == nil {
@@ -41,12 +44,12 @@ func TestGateRejectsMaxNotional(t *testing.T) {
 
 func TestGateApprovesValidBuy(t *testing.T) {
 	gate := Gate{}
-	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: 1}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1)}
 	ctx := RiskContext{
 		Now:         time.Now(),
-		Price:       100,
+		Price:       money.FromFloat(100),
 		MaxQty:      5,
-		MaxNotional: 500,
+		MaxNotional: money.FromFloat(500),
 	}
 
 	if _, err := gate.Evaluate(intent, ctx); err != nil {
@@ -56,12 +59,12 @@ func TestGateApprovesValidBuy(t *testing.T) {
 
 func TestGateRejectsExtendedHoursWithoutLimitDay(t *testing.T) {
 	gate := Gate{}
-	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: 1}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1)}
 	ctx := RiskContext{
 		Now:           time.Now(),
-		Price:         100,
+		Price:         money.FromFloat(100),
 		MaxQty:        5,
-		MaxNotional:   500,
+		MaxNotional:   money.FromFloat(500),
 		ExtendedHours: true,
 		OrderType:     "market",
 		TimeInForce:   "day",
@@ -71,3 +74,109 @@ func TestGateRejectsExtendedHoursWithoutLimitDay(t *testing.T) {
 		t.Fatalf("expected extended hours rejection")
 	}
 }
+
+func TestGateRejectsWideSpread(t *testing.T) {
+	gate := Gate{}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1)}
+	ctx := RiskContext{
+		Now:          time.Now(),
+		Price:        money.FromFloat(100),
+		Bid:          money.FromFloat(99.5),
+		Ask:          money.FromFloat(100.5),
+		MaxQty:       5,
+		MaxNotional:  money.FromFloat(500),
+		MaxSpreadBps: 20,
+	}
+
+	if _, err := gate.Evaluate(intent, ctx); err == nil || err.Error() != "max_spread_exceeded" {
+		t.Fatalf("expected max spread rejection, got %v", err)
+	}
+
+	ctx.Bid = money.FromFloat(99.99)
+	ctx.Ask = money.FromFloat(100.01)
+	if _, err := gate.Evaluate(intent, ctx); err != nil {
+		t.Fatalf("expected approval for tight spread, got %v", err)
+	}
+}
+
+func TestGateShortLimits(t *testing.T) {
+	gate := Gate{}
+	base := RiskContext{
+		Now:              time.Now(),
+		Price:            money.FromFloat(100),
+		PositionQty:      strategy.Shares(-1),
+		MaxQty:           5,
+		MaxNotional:      money.FromFloat(500),
+		AllowShort:       true,
+		Borrowable:       true,
+		MaxShortQty:      2,
+		MaxShortNotional: money.FromFloat(150),
+	}
+	cases := []struct {
+		name   string
+		intent strategy.TradeIntent
+		edit   func(*RiskContext)
+		want   string
+	}{
+		{"approved", strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, func(*RiskContext) {}, ""},
+		{"disabled", strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, func(c *RiskContext) { c.AllowShort = false }, "short_selling_disabled"},
+		{"hard to borrow", strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, func(c *RiskContext) { c.Borrowable = false }, "not_easy_to_borrow"},
+		{"max short", strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(2)}, func(c *RiskContext) { c.MaxShortNotional = money.FromFloat(1000) }, "max_short_exceeded"},
+		{"max short notional", strategy.TradeIntent{Action: strategy.SellShort, Qty: strategy.Shares(1)}, func(c *RiskContext) { c.PositionQty = decimal.Zero; c.Price = money.FromFloat(200) }, "max_short_notional_exceeded"},
+		{"cover while disabled", strategy.TradeIntent{Action: strategy.BuyToCover, Qty: strategy.Shares(1)}, func(c *RiskContext) { c.AllowShort = false }, ""},
+		{"nothing to cover", strategy.TradeIntent{Action: strategy.BuyToCover, Qty: strategy.Shares(1)}, func(c *RiskContext) { c.PositionQty = decimal.Zero }, "no_short_to_cover"},
+		{"cover more than short", strategy.TradeIntent{Action: strategy.BuyToCover, Qty: strategy.Shares(2)}, func(*RiskContext) {}, "cover_exceeds_short"},
+		{"buy while short", strategy.TradeIntent{Action: strategy.Buy, Qty: strategy.Shares(1)}, func(*RiskContext) {}, "short_position_open"},
+	}
+	for _, c := range cases {
+		ctx := base
+		c.edit(&ctx)
+		_, err := gate.Evaluate(c.intent, ctx)
+		got := ""
+		if err != nil {
+			got = err.Error()
+		}
+		if got != c.want {
+			t.Fatalf("%s: expected %q, got %q", c.name, c.want, got)
+		}
+	}
+}
+
+func TestGateRejectsSellBeyondPosition(t *testing.T) {
+	gate := Gate{}
+	ctx := RiskContext{
+		Now:         time.Now(),
+		Price:       money.FromFloat(100),
+		PositionQty: decimal.RequireFromString("1.5"),
+		MaxQty:      5,
+		MaxNotional: money.FromFloat(500),
+	}
+
+	if _, err := gate.Evaluate(strategy.TradeIntent{Action: strategy.Sell, Qty: decimal.RequireFromString("1.5")}, ctx); err != nil {
+		t.Fatalf("expected selling the whole position to be approved, got %v", err)
+	}
+	_, err := gate.Evaluate(strategy.TradeIntent{Action: strategy.Sell, Qty: strategy.Shares(2)}, ctx)
+	if err == nil || err.Error() != "sell_exceeds_position" {
+		t.Fatalf("expected sell_exceeds_position, got %v", err)
+	}
+}
+
+func TestGateApprovesFractionalBuyWithinLimits(t *testing.T) {
+	gate := Gate{}
+	intent := strategy.TradeIntent{Action: strategy.Buy, Qty: decimal.RequireFromString("0.4")}
+	ctx := RiskContext{
+		Now:         time.Now(),
+		Price:       money.FromFloat(450),
+		PositionQty: decimal.RequireFromString("0.5"),
+		MaxQty:      1,
+		MaxNotional: money.FromFloat(200),
+	}
+
+	if _, err := gate.Evaluate(intent, ctx); err != nil {
+		t.Fatalf("expected approval, got %v", err)
+	}
+	intent.Qty = decimal.RequireFromString("0.6")
+	if _, err := gate.Evaluate(intent, ctx); err == nil {
+		t.Fatalf("expected max position rejection for 1.1 shares")
+	}
+}
@@ -0,0 +1,618 @@
+// Package rules parses and evaluates the conditions of the declarative
+// rules strategy, e.g. "close > ema(50) and rsi(14) < 30".
+//
+// Conditions compare numeric terms with <, <=, >, >=, == and != and combine
+// the results with and, or and not. Terms are numbers, the bar and position
+// variables (see variables), indicator calls (see functions) and + - * /
+// arithmetic over them.
+package rules
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"ats/internal/indicator"
+	"ats/internal/md"
+)
+
+// Env is what a condition is evaluated against. Bars are oldest first and
+// the last one is the current bar.
+type Env struct {
+	Bars          []md.Bar
+	Position      float64
+	AvgEntry      float64
+	UnrealizedPnL float64
+	BarsHeld      int
+}
+
+// Error reports a problem at a column (1-based) of an expression.
+type Error struct {
+	Expr   string
+	Column int
+	Msg    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("col %d in %q: %s", e.Column, e.Expr, e.Msg)
+}
+
+// Expr is a parsed condition.
+type Expr struct {
+	src      string
+	root     node
+	lookback int
+	// lookbackAt is the column of the call that needs the most bars.
+	lookbackAt int
+}
+
+// Parse parses a condition. The result must be true or false, so "close"
+// on its own is an error while "close > 0" is not.
+func Parse(src string) (*Expr, error) {
+	p := &parser{src: src}
+	if err := p.lex(); err != nil {
+		return nil, err
+	}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokEOF {
+		return nil, p.errorf(tok.pos, "unexpected %s", tok)
+	}
+	if root.typ != typeBool {
+		return nil, p.errorf(root.pos, "expression is a number, not a condition (compare it with <, >, ==, ...)")
+	}
+	return &Expr{src: src, root: root.node, lookback: p.lookback, lookbackAt: p.lookbackAt + 1}, nil
+}
+
+// Eval reports whether the condition holds. A condition that involves an
+// indicator that is not ready yet, or a division by zero, anywhere is
+// false, even under not or or.
+func (e *Expr) Eval(env Env) bool {
+	v, ok := e.root.eval(&env)
+	return ok && v != 0
+}
+
+// Lookback is the number of bars the condition's indicators need.
+func (e *Expr) Lookback() int {
+	return e.lookback
+}
+
+// CheckLookback fails when the condition needs more bars than are kept.
+func (e *Expr) CheckLookback(bars int) error {
+	if e.lookback <= bars {
+		return nil
+	}
+	return &Error{Expr: e.src, Column: e.lookbackAt, Msg: fmt.Sprintf("needs %d bars of history but only %d are kept (bars-window)", e.lookback, bars)}
+}
+
+func (e *Expr) String() string {
+	return e.src
+}
+
+type node interface {
+	// eval returns the value (1 or 0 for conditions) and false when it
+	// cannot be computed yet.
+	eval(env *Env) (float64, bool)
+}
+
+type valueType int
+
+const (
+	typeNumber valueType = iota
+	typeBool
+)
+
+// typed is a node with its result type and source offset.
+type typed struct {
+	node node
+	typ  valueType
+	pos  int
+}
+
+type number float64
+
+func (n number) eval(env *Env) (float64, bool) { return float64(n), true }
+
+type variable func(env *Env) (float64, bool)
+
+func (v variable) eval(env *Env) (float64, bool) { return v(env) }
+
+func barValue(pick func(md.Bar) float64) variable {
+	return func(env *Env) (float64, bool) {
+		if len(env.Bars) == 0 {
+			return 0, false
+		}
+		return pick(env.Bars[len(env.Bars)-1]), true
+	}
+}
+
+// variables are the names a condition may use on their own.
+var variables = map[string]variable{
+	"open":           barValue(func(b md.Bar) float64 { return b.Open }),
+	"high":           barValue(func(b md.Bar) float64 { return b.High }),
+	"low":            barValue(func(b md.Bar) float64 { return b.Low }),
+	"close":          barValue(func(b md.Bar) float64 { return b.Close }),
+	"volume":         barValue(func(b md.Bar) float64 { return float64(b.Volume) }),
+	"position":       func(env *Env) (float64, bool) { return env.Position, true },
+	"avg_entry":      func(env *Env) (float64, bool) { return env.AvgEntry, env.Position != 0 },
+	"unrealized_pnl": func(env *Env) (float64, bool) { return env.UnrealizedPnL, true },
+	"bars_held":      func(env *Env) (float64, bool) { return float64(env.BarsHeld), true },
+}
+
+// series are the bar fields an indicator may be applied to.
+var series = map[string]func(md.Bar) float64{
+	"open":   func(b md.Bar) float64 { return b.Open },
+	"high":   func(b md.Bar) float64 { return b.High },
+	"low":    func(b md.Bar) float64 { return b.Low },
+	"close":  func(b md.Bar) float64 { return b.Close },
+	"volume": func(b md.Bar) float64 { return float64(b.Volume) },
+}
+
+// function is an indicator over one series and a window, e.g. sma(close, 20).
+// bars is the history the indicator needs for a window.
+type function struct {
+	calc func(env *Env, values func(md.Bar) float64, window int) (float64, bool)
+	bars func(window int) int
+	// fixed functions read high/low/close themselves and take no series.
+	fixed bool
+}
+
+func over(f func([]float64, int) (float64, bool)) func(*Env, func(md.Bar) float64, int) (float64, bool) {
+	return func(env *Env, values func(md.Bar) float64, window int) (float64, bool) {
+		return f(pluck(env.Bars, values), window)
+	}
+}
+
+func pluck(bars []md.Bar, values func(md.Bar) float64) []float64 {
+	out := make([]float64, len(bars))
+	for i, bar := range bars {
+		out[i] = values(bar)
+	}
+	return out
+}
+
+// windowBars is the history a windowed indicator needs; priorBars adds the
+// bar before the window for indicators over changes.
+func windowBars(n int) int { return n }
+func priorBars(n int) int  { return n + 1 }
+
+func atr(env *Env, _ func(md.Bar) float64, period int) (float64, bool) {
+	highs := pluck(env.Bars, series["high"])
+	lows := pluck(env.Bars, series["low"])
+	closes := pluck(env.Bars, series["close"])
+	return indicator.ATR(highs, lows, closes, period)
+}
+
+var functions = map[string]function{
+	"sma":     {calc: over(indicator.SMA), bars: windowBars},
+	"ema":     {calc: over(indicator.EMA), bars: windowBars},
+	"stddev":  {calc: over(indicator.StdDev), bars: windowBars},
+	"highest": {calc: over(indicator.Highest), bars: windowBars},
+	"lowest":  {calc: over(indicator.Lowest), bars: windowBars},
+	"rsi":     {calc: over(indicator.RSI), bars: priorBars},
+	"atr":     {calc: atr, bars: priorBars, fixed: true},
+}
+
+type call struct {
+	fn     function
+	values func(md.Bar) float64
+	window int
+}
+
+func (c call) eval(env *Env) (float64, bool) {
+	return c.fn.calc(env, c.values, c.window)
+}
+
+type negate struct{ x node }
+
+func (n negate) eval(env *Env) (float64, bool) {
+	v, ok := n.x.eval(env)
+	return -v, ok
+}
+
+type arithmetic struct {
+	op   string
+	l, r node
+}
+
+func (a arithmetic) eval(env *Env) (float64, bool) {
+	l, ok := a.l.eval(env)
+	if !ok {
+		return 0, false
+	}
+	r, ok := a.r.eval(env)
+	if !ok {
+		return 0, false
+	}
+	switch a.op {
+	case "+":
+		return l + r, true
+	case "-":
+		return l - r, true
+	case "*":
+		return l * r, true
+	default:
+		if r == 0 {
+			return 0, false
+		}
+		return l / r, true
+	}
+}
+
+type comparison struct {
+	op   string
+	l, r node
+}
+
+func (c comparison) eval(env *Env) (float64, bool) {
+	l, lok := c.l.eval(env)
+	r, rok := c.r.eval(env)
+	if !lok || !rok {
+		return 0, false
+	}
+	var holds bool
+	switch c.op {
+	case "<":
+		holds = l < r
+	case "<=":
+		holds = l <= r
+	case ">":
+		holds = l > r
+	case ">=":
+		holds = l >= r
+	case "==":
+		holds = l == r
+	default:
+		holds = l != r
+	}
+	return truth(holds), true
+}
+
+type logical struct {
+	and  bool
+	l, r node
+}
+
+// eval does not short-circuit: a side that is not ready makes the whole
+// condition not ready, whatever the other side says.
+func (g logical) eval(env *Env) (float64, bool) {
+	l, lok := g.l.eval(env)
+	r, rok := g.r.eval(env)
+	if !lok || !rok {
+		return 0, false
+	}
+	if g.and {
+		return truth(l != 0 && r != 0), true
+	}
+	return truth(l != 0 || r != 0), true
+}
+
+type not struct{ x node }
+
+func (n not) eval(env *Env) (float64, bool) {
+	v, ok := n.x.eval(env)
+	return truth(v == 0), ok
+}
+
+func truth(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNumber
+	tokIdent
+	tokOp
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+func (t token) String() string {
+	if t.kind == tokEOF {
+		return "end of expression"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type parser struct {
+	src        string
+	tokens     []token
+	next       int
+	lookback   int
+	lookbackAt int
+}
+
+func (p *parser) errorf(pos int, format string, args ...any) error {
+	return &Error{Expr: p.src, Column: pos + 1, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *parser) lex() error {
+	src := p.src
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c >= '0' && c <= '9' || c == '.':
+			start := i
+			for i < len(src) && (src[i] >= '0' && src[i] <= '9' || src[i] == '.') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokNumber, text: src[start:i], pos: start})
+		case c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z':
+			start := i
+			for i < len(src) && (src[i] == '_' || src[i] >= 'a' && src[i] <= 'z' || src[i] >= 'A' && src[i] <= 'Z' || src[i] >= '0' && src[i] <= '9') {
+				i++
+			}
+			p.tokens = append(p.tokens, token{kind: tokIdent, text: strings.ToLower(src[start:i]), pos: start})
+		case strings.ContainsRune("<>=!", rune(c)):
+			start := i
+			i++
+			if i < len(src) && src[i] == '=' {
+				i++
+			}
+			op := src[start:i]
+			if op == "=" || op == "!" {
+				return p.errorf(start, "unknown operator %q (use == or !=, and not)", op)
+			}
+			p.tokens = append(p.tokens, token{kind: tokOp, text: op, pos: start})
+		case strings.ContainsRune("+-*/(),", rune(c)):
+			p.tokens = append(p.tokens, token{kind: tokOp, text: string(c), pos: i})
+			i++
+		default:
+			return p.errorf(i, "unexpected character %q", c)
+		}
+	}
+	p.tokens = append(p.tokens, token{kind: tokEOF, pos: len(src)})
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.next]
+}
+
+func (p *parser) take() token {
+	tok := p.tokens[p.next]
+	if tok.kind != tokEOF {
+		p.next++
+	}
+	return tok
+}
+
+// accept consumes the next token if it is the operator or keyword text.
+func (p *parser) accept(text string) (token, bool) {
+	tok := p.peek()
+	if (tok.kind == tokOp || tok.kind == tokIdent) && tok.text == text {
+		p.next++
+		return tok, true
+	}
+	return tok, false
+}
+
+func (p *parser) expect(text string) error {
+	if tok, ok := p.accept(text); !ok {
+		return p.errorf(tok.pos, "expected %q, found %s", text, tok)
+	}
+	return nil
+}
+
+func (p *parser) want(x typed, typ valueType, context string) error {
+	if x.typ == typ {
+		return nil
+	}
+	if typ == typeBool {
+		return p.errorf(x.pos, "%s needs a condition, found a number", context)
+	}
+	return p.errorf(x.pos, "%s needs a number, found a condition", context)
+}
+
+func (p *parser) parseOr() (typed, error) {
+	return p.parseLogical("or", false, p.parseAnd)
+}
+
+func (p *parser) parseAnd() (typed, error) {
+	return p.parseLogical("and", true, p.parseNot)
+}
+
+func (p *parser) parseLogical(keyword string, and bool, operand func() (typed, error)) (typed, error) {
+	left, err := operand()
+	if err != nil {
+		return typed{}, err
+	}
+	for {
+		if _, ok := p.accept(keyword); !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return typed{}, err
+		}
+		if err := p.want(left, typeBool, keyword); err != nil {
+			return typed{}, err
+		}
+		if err := p.want(right, typeBool, keyword); err != nil {
+			return typed{}, err
+		}
+		left = typed{node: logical{and: and, l: left.node, r: right.node}, typ: typeBool, pos: left.pos}
+	}
+}
+
+func (p *parser) parseNot() (typed, error) {
+	tok, ok := p.accept("not")
+	if !ok {
+		return p.parseComparison()
+	}
+	x, err := p.parseNot()
+	if err != nil {
+		return typed{}, err
+	}
+	if err := p.want(x, typeBool, "not"); err != nil {
+		return typed{}, err
+	}
+	return typed{node: not{x: x.node}, typ: typeBool, pos: tok.pos}, nil
+}
+
+func (p *parser) parseComparison() (typed, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return typed{}, err
+	}
+	tok := p.peek()
+	switch tok.text {
+	case "<", "<=", ">", ">=", "==", "!=":
+		p.take()
+	default:
+		return left, nil
+	}
+	right, err := p.parseSum()
+	if err != nil {
+		return typed{}, err
+	}
+	if err := p.want(left, typeNumber, tok.text); err != nil {
+		return typed{}, err
+	}
+	if err := p.want(right, typeNumber, tok.text); err != nil {
+		return typed{}, err
+	}
+	if next := p.peek(); next.kind == tokOp && strings.ContainsAny(next.text, "<>=!") {
+		return typed{}, p.errorf(next.pos, "comparisons cannot be chained; join them with and")
+	}
+	return typed{node: comparison{op: tok.text, l: left.node, r: right.node}, typ: typeBool, pos: left.pos}, nil
+}
+
+func (p *parser) parseSum() (typed, error) {
+	return p.parseArithmetic("+-", p.parseProduct)
+}
+
+func (p *parser) parseProduct() (typed, error) {
+	return p.parseArithmetic("*/", p.parseUnary)
+}
+
+func (p *parser) parseArithmetic(ops string, operand func() (typed, error)) (typed, error) {
+	left, err := operand()
+	if err != nil {
+		return typed{}, err
+	}
+	for {
+		tok := p.peek()
+		if tok.kind != tokOp || len(tok.text) != 1 || !strings.Contains(ops, tok.text) {
+			return left, nil
+		}
+		p.take()
+		right, err := operand()
+		if err != nil {
+			return typed{}, err
+		}
+		if err := p.want(left, typeNumber, tok.text); err != nil {
+			return typed{}, err
+		}
+		if err := p.want(right, typeNumber, tok.text); err != nil {
+			return typed{}, err
+		}
+		left = typed{node: arithmetic{op: tok.text, l: left.node, r: right.node}, typ: typeNumber, pos: left.pos}
+	}
+}
+
+func (p *parser) parseUnary() (typed, error) {
+	tok, ok := p.accept("-")
+	if !ok {
+		return p.parsePrimary()
+	}
+	x, err := p.parseUnary()
+	if err != nil {
+		return typed{}, err
+	}
+	if err := p.want(x, typeNumber, "-"); err != nil {
+		return typed{}, err
+	}
+	return typed{node: negate{x: x.node}, typ: typeNumber, pos: tok.pos}, nil
+}
+
+func (p *parser) parsePrimary() (typed, error) {
+	tok := p.take()
+	switch tok.kind {
+	case tokNumber:
+		v, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil || math.IsInf(v, 0) {
+			return typed{}, p.errorf(tok.pos, "invalid number %q", tok.text)
+		}
+		return typed{node: number(v), typ: typeNumber, pos: tok.pos}, nil
+	case tokIdent:
+		switch tok.text {
+		case "and", "or", "not":
+			return typed{}, p.errorf(tok.pos, "expected a value, found %s", tok)
+		}
+		if _, ok := p.accept("("); ok {
+			return p.parseCall(tok)
+		}
+		if v, ok := variables[tok.text]; ok {
+			return typed{node: v, typ: typeNumber, pos: tok.pos}, nil
+		}
+		if _, ok := functions[tok.text]; ok {
+			return typed{}, p.errorf(tok.pos, "%s is a function; call it with a window, e.g. %s(14)", tok.text, tok.text)
+		}
+		return typed{}, p.errorf(tok.pos, "unknown variable %q", tok.text)
+	case tokOp:
+		if tok.text == "(" {
+			x, err := p.parseOr()
+			if err != nil {
+				return typed{}, err
+			}
+			if err := p.expect(")"); err != nil {
+				return typed{}, err
+			}
+			x.pos = tok.pos
+			return x, nil
+		}
+	}
+	return typed{}, p.errorf(tok.pos, "expected a value, found %s", tok)
+}
+
+// parseCall parses the arguments of name(window) or name(series, window)
+// after the opening parenthesis.
+func (p *parser) parseCall(name token) (typed, error) {
+	fn, ok := functions[name.text]
+	if !ok {
+		return typed{}, p.errorf(name.pos, "unknown function %q", name.text)
+	}
+	c := call{fn: fn, values: series["close"]}
+	if tok := p.peek(); tok.kind == tokIdent {
+		values, ok := series[tok.text]
+		if !ok {
+			return typed{}, p.errorf(tok.pos, "unknown series %q (use open, high, low, close or volume)", tok.text)
+		}
+		if fn.fixed {
+			return typed{}, p.errorf(tok.pos, "%s takes only a period", name.text)
+		}
+		p.take()
+		c.values = values
+		if err := p.expect(","); err != nil {
+			return typed{}, err
+		}
+	}
+	tok := p.take()
+	n, err := strconv.Atoi(tok.text)
+	if tok.kind != tokNumber || err != nil || n <= 0 {
+		return typed{}, p.errorf(tok.pos, "%s needs a positive whole number of bars, found %s", name.text, tok)
+	}
+	c.window = n
+	if err := p.expect(")"); err != nil {
+		return typed{}, err
+	}
+	if bars := fn.bars(n); bars > p.lookback {
+		p.lookback, p.lookbackAt = bars, name.pos
+	}
+	return typed{node: c, typ: typeNumber, pos: name.pos}, nil
+}
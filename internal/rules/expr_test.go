@@ -0,0 +1,111 @@
+package rules
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"ats/internal/md"
+)
+
+func closes(values ...float64) []md.Bar {
+	bars := make([]md.Bar, len(values))
+	for i, v := range values {
+		bars[i] = md.Bar{Open: v, High: v + 1, Low: v - 1, Close: v}
+	}
+	return bars
+}
+
+func TestEvaluatesConditions(t *testing.T) {
+	env := Env{Bars: closes(10, 11, 12, 13, 14), Position: 2, AvgEntry: 12, BarsHeld: 3}
+	cases := []struct {
+		src  string
+		want bool
+	}{
+		{"close > sma(3)", true},
+		{"close > sma(3) and sma(3) > sma(5)", true},
+		{"close < sma(3) or bars_held >= 3", true},
+		{"not (close > 13)", false},
+		{"highest(high, 5) - lowest(low, 5) == 6", true},
+		{"close / avg_entry - 1 > 0.15", true},
+		{"-close < -13.5", true},
+		{"rsi(4) > 99", true},
+		{"CLOSE > 13 AND position == 2", true},
+		// Indicators without enough history make the whole condition false.
+		{"sma(10) > 0", false},
+		{"sma(10) > 0 or close > 0", false},
+		{"close > 0 and not (sma(10) > 0)", false},
+		{"close / (position - 2) > 0", false},
+	}
+	for _, c := range cases {
+		expr, err := Parse(c.src)
+		if err != nil {
+			t.Fatalf("%s: %v", c.src, err)
+		}
+		if got := expr.Eval(env); got != c.want {
+			t.Fatalf("%s: expected %v, got %v", c.src, c.want, got)
+		}
+	}
+}
+
+func TestNotIsFalseDuringWarmUp(t *testing.T) {
+	expr, err := Parse("not (close > sma(5))")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if expr.Eval(Env{Bars: closes(14, 13, 12, 11)}) {
+		t.Fatalf("expected false while sma(5) is warming up")
+	}
+	if !expr.Eval(Env{Bars: closes(14, 13, 12, 11, 10)}) {
+		t.Fatalf("expected true once sma(5) is ready and the close is below it")
+	}
+}
+
+func TestParseErrorsPointAtTheProblem(t *testing.T) {
+	cases := []struct {
+		src    string
+		column int
+		msg    string
+	}{
+		{"close > emaa(50)", 9, `unknown function "emaa"`},
+		{"close > ema(x)", 13, `unknown series "x"`},
+		{"close > ema(0)", 13, "positive whole number"},
+		{"rsi(14) < 30 and", 17, "expected a value"},
+		{"close > ema(50", 15, `expected ")"`},
+		{"close = 1", 7, "use == or !="},
+		{"close + sma(5)", 1, "not a condition"},
+		{"close and rsi(14) < 30", 1, "and needs a condition"},
+		{"(close > 1) + 2 > 0", 1, "+ needs a number"},
+		{"1 < close < 2", 11, "cannot be chained"},
+		{"atr(high, 14) > 1", 5, "only a period"},
+		{"price > 1", 1, `unknown variable "price"`},
+		{"close > 1 $", 11, "unexpected character"},
+	}
+	for _, c := range cases {
+		_, err := Parse(c.src)
+		var perr *Error
+		if !errors.As(err, &perr) {
+			t.Fatalf("%s: expected a parse error, got %v", c.src, err)
+		}
+		if perr.Column != c.column || !strings.Contains(perr.Msg, c.msg) {
+			t.Fatalf("%s: expected %q at col %d, got %v", c.src, c.msg, c.column, err)
+		}
+	}
+}
+
+func TestLookbackNamesTheLongestCall(t *testing.T) {
+	expr, err := Parse("rsi(14) < 30 and close > ema(50)")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if expr.Lookback() != 50 {
+		t.Fatalf("expected lookback 50, got %d", expr.Lookback())
+	}
+	var perr *Error
+	if err := expr.CheckLookback(40); !errors.As(err, &perr) || perr.Column != 26 {
+		t.Fatalf("expected the ema call at col 26 to be reported, got %v", err)
+	}
+	if err := expr.CheckLookback(50); err != nil {
+		t.Fatalf("expected 50 bars to be enough, got %v", err)
+	}
+}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"ats/internal/md"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Spec is a rules file as written. A long position is opened when any
+// Entry condition holds and closed when any Exit condition does; Short and
+// Cover do the same for short positions.
+type Spec struct {
+	Name      string   `json:"name" yaml:"name"`
+	Timeframe string   `json:"timeframe" yaml:"timeframe"`
+	Entry     []string `json:"entry" yaml:"entry"`
+	Exit      []string `json:"exit" yaml:"exit"`
+	Short     []string `json:"short" yaml:"short"`
+	Cover     []string `json:"cover" yaml:"cover"`
+}
+
+// Condition is one parsed condition and where it came from, e.g. "exit[1]".
+type Condition struct {
+	Label string
+	Expr  *Expr
+}
+
+// Set is a compiled rules file.
+type Set struct {
+	Name      string
+	Timeframe md.Timeframe
+	Entry     []Condition
+	Exit      []Condition
+	Short     []Condition
+	Cover     []Condition
+}
+
+// Load reads a rules file, as YAML when it ends in .yaml or .yml and as
+// JSON otherwise, and compiles it. Unknown keys are rejected so a
+// misspelled section is not silently ignored.
+func Load(path string) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read rules file: %w", err)
+	}
+	var spec Spec
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(&spec)
+	default:
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&spec)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse rules file %s: %w", path, err)
+	}
+	set, err := Compile(spec)
+	if err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
+	}
+	return set, nil
+}
+
+// Compile parses every condition of spec. Errors name the section and
+// index of the condition, e.g. `exit[1]: col 5 in "rsi(x) > 70": ...`.
+func Compile(spec Spec) (*Set, error) {
+	set := &Set{Name: spec.Name}
+	if spec.Timeframe != "" {
+		tf, err := md.ParseTimeframe(spec.Timeframe)
+		if err != nil {
+			return nil, fmt.Errorf("timeframe: %w", err)
+		}
+		set.Timeframe = tf
+	}
+	sections := []struct {
+		name string
+		src  []string
+		dst  *[]Condition
+	}{
+		{"entry", spec.Entry, &set.Entry},
+		{"exit", spec.Exit, &set.Exit},
+		{"short", spec.Short, &set.Short},
+		{"cover", spec.Cover, &set.Cover},
+	}
+	for _, section := range sections {
+		for i, src := range section.src {
+			label := fmt.Sprintf("%s[%d]", section.name, i)
+			if strings.TrimSpace(src) == "" {
+				return nil, fmt.Errorf("%s: empty condition", label)
+			}
+			expr, err := Parse(src)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", label, err)
+			}
+			*section.dst = append(*section.dst, Condition{Label: label, Expr: expr})
+		}
+	}
+	if len(set.Entry) == 0 && len(set.Short) == 0 {
+		return nil, fmt.Errorf("no entry or short conditions")
+	}
+	if len(set.Entry) > 0 && len(set.Exit) == 0 {
+		return nil, fmt.Errorf("entry conditions need at least one exit condition")
+	}
+	if len(set.Short) > 0 && len(set.Cover) == 0 {
+		return nil, fmt.Errorf("short conditions need at least one cover condition")
+	}
+	return set, nil
+}
+
+// CheckLookback fails on the first condition that needs more bars of
+// history than are kept.
+func (s *Set) CheckLookback(bars int) error {
+	for _, section := range [][]Condition{s.Entry, s.Exit, s.Short, s.Cover} {
+		for _, c := range section {
+			if err := c.Expr.CheckLookback(bars); err != nil {
+				return fmt.Errorf("%s: %w", c.Label, err)
+			}
+		}
+	}
+	return nil
+}
+
+// First returns the first condition that holds.
+func First(conditions []Condition, env Env) (Condition, bool) {
+	for _, c := range conditions {
+		if c.Expr.Eval(env) {
+			return c, true
+		}
+	}
+	return Condition{}, false
+}
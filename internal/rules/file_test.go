@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeRules(t *testing.T, name, contents string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	return path
+}
+
+func TestLoadReadsYAMLAndJSON(t *testing.T) {
+	yamlPath := writeRules(t, "dip.yaml", `
+name: rsi_dip
+timeframe: 5m
+entry:
+  - close > ema(50) and rsi(14) < 30
+exit:
+  - rsi(14) > 70
+  - bars_held >= 20
+`)
+	jsonPath := writeRules(t, "dip.json", `{"name": "rsi_dip", "timeframe": "5m", "entry": ["close > ema(50) and rsi(14) < 30"], "exit": ["rsi(14) > 70", "bars_held >= 20"]}`)
+	for _, path := range []string{yamlPath, jsonPath} {
+		set, err := Load(path)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		if set.Name != "rsi_dip" || set.Timeframe != "5m" || len(set.Entry) != 1 || len(set.Exit) != 2 || set.Exit[1].Label != "exit[1]" {
+			t.Fatalf("%s: unexpected set %+v", path, set)
+		}
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	cases := []struct {
+		name, contents, want string
+	}{
+		{"bad.yaml", "entry: [close > 1]\nexit: [rsi(14) >]\n", `exit[0]: col 10 in "rsi(14) >"`},
+		{"typo.yaml", "entry: [close > 1]\nexits: [close < 1]\n", "exits"},
+		{"typo.json", `{"entry": ["close > 1"], "exits": ["close < 1"]}`, `unknown field "exits"`},
+		{"noexit.json", `{"entry": ["close > 1"]}`, "need at least one exit"},
+		{"empty.json", `{}`, "no entry or short conditions"},
+		{"tf.json", `{"timeframe": "7m", "entry": ["close > 1"], "exit": ["close < 1"]}`, "timeframe"},
+	}
+	for _, c := range cases {
+		_, err := Load(writeRules(t, c.name, c.contents))
+		if err == nil || !strings.Contains(err.Error(), c.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", c.name, c.want, err)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Package sizing turns a strategy's direction and strength into a share
+// quantity, so strategies decide what to trade and sizing decides how much.
+package sizing
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"ats/internal/config"
+	"ats/internal/indicator"
+	"ats/internal/md"
+
+	"github.com/shopspring/decimal"
+)
+
+// FractionalPlaces is the broker's precision for fractional share
+// quantities.
+const FractionalPlaces = 9
+
+// Input is what a sizer may look at when sizing one entry.
+type Input struct {
+	// Qty is the strategy's own quantity.
+	Qty    decimal.Decimal
+	Price  float64
+	Equity float64
+	// Strength is the strategy's confidence in [0, 1]; it scales the
+	// method's full size, so 0 sizes nothing.
+	Strength float64
+	// Bars is recent history on the strategy's timeframe, oldest first.
+	Bars []md.Bar
+	// Fractional allows fractional shares; otherwise sizes round down to
+	// whole shares.
+	Fractional bool
+}
+
+type Sizer interface {
+	// Size returns the share quantity for an entry and a short note on how
+	// it was derived, for the decision log.
+	Size(in Input) (decimal.Decimal, string)
+}
+
+// TradeObserver is implemented by sizers that learn from closed trades.
+type TradeObserver interface {
+	// ObserveTrade records the fractional return of a closed trade.
+	ObserveTrade(ret float64)
+}
+
+// New builds the sizer selected by cfg.Sizing. The config is validated at
+// load time, so unknown methods fall back to the strategy's quantity.
+func New(cfg config.Config) Sizer {
+	fixedQty := cfg.SizingQty
+	if fixedQty == 0 {
+		fixedQty = cfg.MaxQty
+	}
+	fixed := FixedQty{Qty: fixedQty}
+	switch cfg.Sizing {
+	case config.SizingFixedQty:
+		return fixed
+	case config.SizingNotional:
+		notional := cfg.SizingNotional
+		if notional == 0 {
+			notional = cfg.MaxNotional
+		}
+		return Notional{Notional: notional}
+	case config.SizingPercentEquity:
+		return PercentEquity{Fraction: cfg.SizingEquityPct}
+	case config.SizingVolatility:
+		return Volatility{RiskFraction: cfg.SizingRiskPct, Period: cfg.SizingATRPeriod}
+	case config.SizingKelly:
+		return NewKelly(cfg.KellyFraction, cfg.KellyMinTrades, fixed)
+	default:
+		return StrategyQty{}
+	}
+}
+
+// strength clamps s to [0, 1].
+func strength(s float64) float64 {
+	return math.Max(0, math.Min(1, s))
+}
+
+// Floor rounds a share count down to whole shares, or to FractionalPlaces
+// when fractional; invalid counts are 0.
+func Floor(value float64, fractional bool) decimal.Decimal {
+	if value <= 0 || math.IsNaN(value) || math.IsInf(value, 0) {
+		return decimal.Zero
+	}
+	if !fractional {
+		return decimal.NewFromFloat(math.Floor(value + 1e-9))
+	}
+	return decimal.NewFromFloat(value).RoundDown(FractionalPlaces)
+}
+
+// StrategyQty keeps the quantity the strategy asked for.
+type StrategyQty struct{}
+
+func (StrategyQty) Size(in Input) (decimal.Decimal, string) {
+	return in.Qty, "strategy qty"
+}
+
+// FixedQty buys the same number of shares every time, rounded to the
+// nearest share (or kept fractional) after scaling by strength.
+type FixedQty struct {
+	Qty int
+}
+
+func (f FixedQty) Size(in Input) (decimal.Decimal, string) {
+	qty := float64(f.Qty) * strength(in.Strength)
+	if !in.Fractional {
+		qty = math.Round(qty)
+	}
+	return Floor(qty, in.Fractional), fmt.Sprintf("fixed_qty %d", f.Qty)
+}
+
+// Notional buys as many shares as a fixed dollar amount allows.
+type Notional struct {
+	Notional float64
+}
+
+func (n Notional) Size(in Input) (decimal.Decimal, string) {
+	if in.Price <= 0 {
+		return decimal.Zero, "notional: no price"
+	}
+	return Floor(n.Notional*strength(in.Strength)/in.Price, in.Fractional), fmt.Sprintf("notional %.2f", n.Notional)
+}
+
+// PercentEquity puts a fixed fraction of equity into each entry.
+type PercentEquity struct {
+	Fraction float64
+}
+
+func (p PercentEquity) Size(in Input) (decimal.Decimal, string) {
+	if in.Price <= 0 {
+		return decimal.Zero, "percent_equity: no price"
+	}
+	return Floor(in.Equity*p.Fraction*strength(in.Strength)/in.Price, in.Fractional), fmt.Sprintf("percent_equity %g of %.2f", p.Fraction, in.Equity)
+}
+
+// Volatility sizes so that a one-ATR move costs RiskFraction of equity:
+// calm markets get bigger positions, volatile ones smaller.
+type Volatility struct {
+	RiskFraction float64
+	Period       int
+}
+
+func (v Volatility) Size(in Input) (decimal.Decimal, string) {
+	highs := make([]float64, len(in.Bars))
+	lows := make([]float64, len(in.Bars))
+	closes := make([]float64, len(in.Bars))
+	for i, bar := range in.Bars {
+		highs[i], lows[i], closes[i] = bar.High, bar.Low, bar.Close
+	}
+	atr, ok := indicator.ATR(highs, lows, closes, v.Period)
+	if !ok || atr <= 0 {
+		return decimal.Zero, fmt.Sprintf("volatility: atr(%d) not ready", v.Period)
+	}
+	return Floor(in.Equity*v.RiskFraction*strength(in.Strength)/atr, in.Fractional), fmt.Sprintf("volatility atr=%.4f risk %g of %.2f", atr, v.RiskFraction, in.Equity)
+}
+
+// Kelly bets a fraction of the Kelly criterion estimated from closed
+// trades: f = p - (1-p)/b with win rate p and payoff ratio b. Until
+// MinTrades trades have closed it defers to Fallback.
+type Kelly struct {
+	Fraction  float64
+	MinTrades int
+	Fallback  Sizer
+
+	mu      sync.Mutex
+	wins    int
+	losses  int
+	winSum  float64
+	lossSum float64
+}
+
+func NewKelly(fraction float64, minTrades int, fallback Sizer) *Kelly {
+	return &Kelly{Fraction: fraction, MinTrades: minTrades, Fallback: fallback}
+}
+
+func (k *Kelly) ObserveTrade(ret float64) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if ret > 0 {
+		k.wins++
+		k.winSum += ret
+	} else {
+		k.losses++
+		k.lossSum -= ret
+	}
+}
+
+// Bet returns the fraction of equity to commit and the number of trades
+// it is based on; ok is false until MinTrades have closed.
+func (k *Kelly) Bet() (f float64, trades int, ok bool) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	trades = k.wins + k.losses
+	if trades == 0 || trades < k.MinTrades {
+		return 0, trades, false
+	}
+	p := float64(k.wins) / float64(trades)
+	full := p
+	if k.wins > 0 && k.losses > 0 && k.lossSum > 0 {
+		b := (k.winSum / float64(k.wins)) / (k.lossSum / float64(k.losses))
+		full = p - (1-p)/b
+	}
+	return math.Max(0, math.Min(1, full*k.Fraction)), trades, true
+}
+
+func (k *Kelly) Size(in Input) (decimal.Decimal, string) {
+	f, trades, ok := k.Bet()
+	if !ok {
+		qty, note := k.Fallback.Size(in)
+		return qty, fmt.Sprintf("kelly warming up (%d/%d trades): %s", trades, k.MinTrades, note)
+	}
+	if in.Price <= 0 {
+		return decimal.Zero, "kelly: no price"
+	}
+	return Floor(in.Equity*f*strength(in.Strength)/in.Price, in.Fractional), fmt.Sprintf("kelly f=%.4f over %d trades", f, trades)
+}
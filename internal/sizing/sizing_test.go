@@ -0,0 +1,99 @@
+package sizing
+
+import (
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+	"ats/internal/md"
+
+	"github.com/shopspring/decimal"
+)
+
+func TestSizersScaleWithStrength(t *testing.T) {
+	in := Input{Price: 50, Equity: 10000, Strength: 0.5}
+	cases := []struct {
+		name  string
+		sizer Sizer
+		want  int
+	}{
+		{"fixed", FixedQty{Qty: 10}, 5},
+		{"notional", Notional{Notional: 1000}, 10},
+		{"percent_equity", PercentEquity{Fraction: 0.1}, 10},
+	}
+	for _, tc := range cases {
+		if got, _ := tc.sizer.Size(in); !got.Equal(decimal.NewFromInt(int64(tc.want))) {
+			t.Fatalf("%s: expected %d, got %s", tc.name, tc.want, got)
+		}
+	}
+	if got, _ := (FixedQty{Qty: 3}).Size(Input{Price: 50}); !got.IsZero() {
+		t.Fatalf("expected zero strength to size nothing, got %s", got)
+	}
+}
+
+func TestVolatilitySizingUsesATR(t *testing.T) {
+	bars := make([]md.Bar, 6)
+	for i := range bars {
+		bars[i] = md.Bar{High: 101, Low: 99, Close: 100}
+	}
+	v := Volatility{RiskFraction: 0.01, Period: 5}
+	// ATR 2: risking 1% of 10000 per ATR buys 50 shares.
+	if got, _ := v.Size(Input{Price: 100, Equity: 10000, Strength: 1, Bars: bars}); !got.Equal(decimal.NewFromInt(50)) {
+		t.Fatalf("expected 50 shares, got %s", got)
+	}
+	if got, note := v.Size(Input{Price: 100, Equity: 10000, Strength: 1, Bars: bars[:3]}); !got.Equal(decimal.NewFromInt(0)) || !strings.Contains(note, "not ready") {
+		t.Fatalf("expected no size before ATR is ready, got %s (%s)", got, note)
+	}
+}
+
+func TestKellyWarmsUpThenBetsEdge(t *testing.T) {
+	k := NewKelly(0.5, 4, FixedQty{Qty: 1})
+	in := Input{Price: 100, Equity: 10000, Strength: 1}
+	if got, note := k.Size(in); !got.Equal(decimal.NewFromInt(1)) || !strings.Contains(note, "warming up") {
+		t.Fatalf("expected fallback size, got %s (%s)", got, note)
+	}
+	// 3 wins of +2%, 1 loss of -1%: p=0.75, b=2, full Kelly 0.625.
+	for _, ret := range []float64{0.02, 0.02, -0.01, 0.02} {
+		k.ObserveTrade(ret)
+	}
+	f, trades, ok := k.Bet()
+	if !ok || trades != 4 || f < 0.3124 || f > 0.3126 {
+		t.Fatalf("expected half Kelly 0.3125 over 4 trades, got %g over %d (ok=%v)", f, trades, ok)
+	}
+	if got, _ := k.Size(in); !got.Equal(decimal.NewFromInt(31)) {
+		t.Fatalf("expected 31 shares, got %s", got)
+	}
+
+	losing := NewKelly(1, 1, FixedQty{Qty: 1})
+	losing.ObserveTrade(-0.01)
+	if got, _ := losing.Size(in); !got.Equal(decimal.NewFromInt(0)) {
+		t.Fatalf("expected no bet without an edge, got %s", got)
+	}
+}
+
+func TestNewDefaultsToStrategyQty(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxQty = 7
+	in := Input{Qty: decimal.NewFromInt(1), Price: 10, Strength: 1}
+	if got, _ := New(cfg).Size(in); !got.Equal(decimal.NewFromInt(1)) {
+		t.Fatalf("expected the strategy's quantity, got %s", got)
+	}
+	cfg.Sizing = config.SizingFixedQty
+	if got, _ := New(cfg).Size(in); !got.Equal(decimal.NewFromInt(7)) {
+		t.Fatalf("expected fixed size of max-qty, got %s", got)
+	}
+}
+
+func TestFractionalSizing(t *testing.T) {
+	in := Input{Price: 400, Equity: 10000, Strength: 1, Fractional: true}
+	if got, _ := (Notional{Notional: 100}).Size(in); !got.Equal(decimal.RequireFromString("0.25")) {
+		t.Fatalf("expected 0.25 shares, got %s", got)
+	}
+	in.Fractional = false
+	if got, _ := (Notional{Notional: 100}).Size(in); !got.IsZero() {
+		t.Fatalf("expected no whole share, got %s", got)
+	}
+	if got := Floor(1.0/3, true); got.String() != "0.333333333" {
+		t.Fatalf("expected rounding down to %d places, got %s", FractionalPlaces, got)
+	}
+}
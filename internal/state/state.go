@@ -6,17 +6,72 @@ import (
 	"os"
 	"sync"
 	"time"
+
+	"ats/internal/clock"
+	"ats/internal/money"
+
+	"github.com/shopspring/decimal"
 )
 
+// Position quantities are signed (negative while short) and may be
+// fractional. EntryTime is when the position was opened or last flipped
+// sides, and BarsHeld counts the strategy bars completed since then.
 type Position struct {
-	Qty      int
-	AvgEntry float64
+	Qty       decimal.Decimal
+	AvgEntry  money.Price
+	EntryTime time.Time
+	BarsHeld  int
+}
+
+// Fill is one execution of our orders. Qty is positive; Side is "buy" or
+// "sell".
+type Fill struct {
+	Time  time.Time
+	Side  string
+	Qty   decimal.Decimal
+	Price money.Price
+}
+
+// Signed is the fill quantity as a position change.
+func (f Fill) Signed() decimal.Decimal {
+	if f.Side == "sell" {
+		return f.Qty.Neg()
+	}
+	return f.Qty
+}
+
+// RoundTrip is a position from its entry until it is closed out, over
+// however many fills that took.
+type RoundTrip struct {
+	Realized money.Money
+	// Basis is the entry cost of the shares closed.
+	Basis money.Money
+}
+
+// Return is the realized P&L as a fraction of the basis.
+func (r RoundTrip) Return() float64 {
+	if !r.Basis.IsPositive() {
+		return 0
+	}
+	return r.Realized.Div(r.Basis).InexactFloat64()
 }
 
 type OpenOrder struct {
 	ClientOrderID string
 	OrderID       string
 	Status        string
+	Side          string // "buy" or "sell"
+	Qty           decimal.Decimal
+	FilledQty     decimal.Decimal
+}
+
+// PendingQty is the signed quantity still to fill: positive for buys.
+func (o OpenOrder) PendingQty() decimal.Decimal {
+	remaining := decimal.Max(o.Qty.Sub(o.FilledQty), decimal.Zero)
+	if o.Side == "sell" {
+		return remaining.Neg()
+	}
+	return remaining
 }
 
 type Snapshot struct {
@@ -24,18 +79,50 @@ type Snapshot struct {
 	OpenOrders    map[string]OpenOrder
 	LastTradeTime time.Time
 	LastBarTime   time.Time
+	// RealizedPnL accumulates from fills; Equity is the broker's account
+	// equity as of the last reconciliation (0 when unknown).
+	RealizedPnL money.Money
+	Equity      money.Money
+	LastFill    *Fill
+	// Strategy is the saved state of a Stateful strategy, nil when there
+	// is none.
+	Strategy *StrategyState
+}
+
+// StrategyState is a strategy's own state, tagged with the strategy name so
+// it is never restored into a different strategy.
+type StrategyState struct {
+	Name  string
+	State json.RawMessage
+}
+
+// PendingQty nets the unfilled quantity of every open order.
+func (s Snapshot) PendingQty() decimal.Decimal {
+	pending := decimal.Zero
+	for _, order := range s.OpenOrders {
+		pending = pending.Add(order.PendingQty())
+	}
+	return pending
 }
 
 type Store struct {
 	mu       sync.RWMutex
 	snapshot Snapshot
+	clock    clock.Clock
+	// filled is the position the fills seen so far add up to while
+	// reconciliation has moved the position ahead of them; nil otherwise.
+	filled *Position
+	// trip accumulates the closing fills of the current position.
+	trip RoundTrip
 }
 
-func NewStore() *Store {
+// NewStore stamps trade times from clk (the wall clock when nil).
+func NewStore(clk clock.Clock) *Store {
 	return &Store{
 		snapshot: Snapshot{
 			OpenOrders: map[string]OpenOrder{},
 		},
+		clock: clock.OrReal(clk),
 	}
 }
 
@@ -50,16 +137,151 @@ func (s *Store) Snapshot() Snapshot {
 	return copy
 }
 
+// UpdatePosition replaces the position, e.g. from broker reconciliation.
+// A position on the same side keeps its entry time and bar count; one that
+// opened or flipped without a fill being seen is stamped as entered now,
+// until the fill arrives (see ApplyFill). A change no fill explains by the
+// next update that confirms it is taken as is.
 func (s *Store) UpdatePosition(position Position) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	oldQty := s.snapshot.Position.Qty
+	old := s.snapshot.Position
+	oldQty := old.Qty
+	switch {
+	case s.filled != nil && (s.filled.Qty.Equal(position.Qty) || oldQty.Equal(position.Qty)):
+		s.filled = nil
+	case s.filled == nil && !oldQty.Equal(position.Qty):
+		s.filled = &old
+	}
+	switch {
+	case position.Qty.IsZero():
+		position.EntryTime, position.BarsHeld = time.Time{}, 0
+	case position.EntryTime.IsZero() && position.Qty.Sign() == old.Qty.Sign():
+		position.EntryTime, position.BarsHeld = old.EntryTime, old.BarsHeld
+	case position.EntryTime.IsZero():
+		position.EntryTime, position.BarsHeld = s.clock.Now(), 0
+	}
 	s.snapshot.Position = position
-	if oldQty != position.Qty {
+	if !oldQty.Equal(position.Qty) {
 		slog.Info("position updated", "old_qty", oldQty, "new_qty", position.Qty, "avg_entry", position.AvgEntry)
 	}
 }
 
+// ApplyFill adds a fill to the position and records it as the last fill.
+// Adding to a position averages the entry price; crossing through zero
+// starts a new position at the fill price and time. It returns the P&L
+// realized by the part of the fill that reduced the position and, when the
+// fill closed the position out, the round trip it completed.
+//
+// A fill that reconciliation already counted is applied to the position
+// the earlier fills add up to instead, so its entry time, last fill and
+// realized P&L are still recorded once the fills catch up.
+func (s *Store) ApplyFill(fill Fill) (money.Money, *RoundTrip) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	qty, price := fill.Signed(), fill.Price
+	pos := s.snapshot.Position
+	if s.filled != nil {
+		pos = *s.filled
+	}
+	newQty := pos.Qty.Add(qty)
+	avg := pos.AvgEntry
+	entered, bars := pos.EntryTime, pos.BarsHeld
+	realized := decimal.Zero
+	var trip *RoundTrip
+	if closed := ClosedQty(pos.Qty, qty); !closed.IsZero() {
+		realized = closed.Mul(price.Sub(pos.AvgEntry))
+		s.snapshot.RealizedPnL = s.snapshot.RealizedPnL.Add(realized)
+		s.trip.Realized = s.trip.Realized.Add(realized)
+		s.trip.Basis = s.trip.Basis.Add(money.Notional(closed.Abs(), pos.AvgEntry))
+		if closed.Abs().Equal(pos.Qty.Abs()) {
+			done := s.trip
+			trip, s.trip = &done, RoundTrip{}
+		}
+	}
+	switch {
+	case newQty.IsZero():
+		avg = decimal.Zero
+		entered, bars = time.Time{}, 0
+	case pos.Qty.IsZero() || pos.Qty.Sign() != newQty.Sign():
+		avg = price
+		entered, bars = fill.Time, 0
+	case pos.Qty.Sign() == qty.Sign():
+		cost := money.Notional(pos.Qty.Abs(), pos.AvgEntry).Add(money.Notional(qty.Abs(), price))
+		avg = cost.Div(newQty.Abs())
+	}
+	next := Position{Qty: newQty, AvgEntry: avg, EntryTime: entered, BarsHeld: bars}
+	if s.filled == nil || newQty.Equal(s.snapshot.Position.Qty) {
+		s.snapshot.Position, s.filled = next, nil
+	} else {
+		s.filled = &next
+	}
+	s.snapshot.LastFill = &fill
+	slog.Info("position updated", "old_qty", pos.Qty, "new_qty", newQty, "avg_entry", avg, "fill_price", price, "realized", realized)
+	return realized, trip
+}
+
+// ClosedQty is the signed part of a fill of qty that closes an existing
+// position of held shares: positive when closing a long, negative when
+// covering a short, 0 when the fill adds to the position.
+func ClosedQty(held, qty decimal.Decimal) decimal.Decimal {
+	switch {
+	case held.IsPositive() && qty.IsNegative():
+		return decimal.Min(held, qty.Neg())
+	case held.IsNegative() && qty.IsPositive():
+		return decimal.Min(held.Neg(), qty).Neg()
+	default:
+		return decimal.Zero
+	}
+}
+
+// CountBar advances the bars held by an open position by one strategy bar.
+func (s *Store) CountBar() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.snapshot.Position.Qty.IsZero() {
+		s.snapshot.Position.BarsHeld++
+	}
+}
+
+// SetStrategyState records the strategy's state for the next Save.
+func (s *Store) SetStrategyState(name string, data json.RawMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.Strategy = &StrategyState{Name: name, State: data}
+}
+
+func (s *Store) SetEquity(equity money.Money) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.Equity = equity
+}
+
+// SetOpenOrder adds or replaces one open order.
+func (s *Store) SetOpenOrder(order OpenOrder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshot.OpenOrders[order.ClientOrderID] = order
+}
+
+// AddOrderFill records a partial fill against an open order so its
+// pending quantity shrinks before the broker reports it filled.
+func (s *Store) AddOrderFill(clientOrderID string, qty decimal.Decimal) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if order, ok := s.snapshot.OpenOrders[clientOrderID]; ok {
+		order.FilledQty = order.FilledQty.Add(qty)
+		s.snapshot.OpenOrders[clientOrderID] = order
+	}
+}
+
+// RemoveOpenOrder drops an order that is no longer working.
+func (s *Store) RemoveOpenOrder(clientOrderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.snapshot.OpenOrders, clientOrderID)
+}
+
 func (s *Store) SetOpenOrders(orders map[string]OpenOrder) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -76,6 +298,12 @@ func (s *Store) SetLastTradeTime(t time.Time) {
 	s.snapshot.LastTradeTime = t
 }
 
+// RecordTrade marks now as the last trade time, which the cooldown is
+// measured from.
+func (s *Store) RecordTrade() {
+	s.SetLastTradeTime(s.clock.Now())
+}
+
 func (s *Store) SetLastBarTime(t time.Time) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -0,0 +1,301 @@
+package strategy
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"ats/internal/config"
+	"ats/internal/md"
+
+	"github.com/shopspring/decimal"
+)
+
+// Ensemble voting modes.
+const (
+	// VoteUnanimous acts only when every member wants the same trade.
+	VoteUnanimous = "unanimous"
+	// VoteMajority acts when more than half of the members agree.
+	VoteMajority = "majority"
+	// VoteWeighted acts when an action's share of the total weight exceeds
+	// the threshold.
+	VoteWeighted = "weighted"
+	// VoteFirstNonHold takes the first member, in order, that wants to trade.
+	VoteFirstNonHold = "first_non_hold"
+	// VoteVeto follows the first member unless another votes the opposite way.
+	VoteVeto = "veto"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "ensemble",
+		Description: "combines the intents of the strategies in the config's ensemble section by vote",
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			members := make([]Member, 0, len(cfg.Ensemble.Members))
+			for _, m := range cfg.Ensemble.Members {
+				if m.Strategy == "ensemble" {
+					return nil, fmt.Errorf("ensembles cannot be nested")
+				}
+				memberCfg := cfg
+				memberCfg.StrategyParams = m.Params
+				strat, err := Build(m.Strategy, memberCfg, nil)
+				if err != nil {
+					return nil, fmt.Errorf("member %s: %w", m.Strategy, err)
+				}
+				members = append(members, Member{Name: m.Strategy, Strategy: strat, Weight: m.Weight})
+			}
+			return NewEnsemble(cfg.Ensemble.Vote, cfg.Ensemble.Threshold, members)
+		},
+	})
+}
+
+// Member is one child strategy of an Ensemble. Weight only matters for
+// weighted voting; zero counts as 1.
+type Member struct {
+	Name     string
+	Strategy Strategy
+	Weight   float64
+}
+
+// ChildIntent is what one ensemble member wanted on a bar.
+type ChildIntent struct {
+	Strategy string          `json:"strategy"`
+	Action   Action          `json:"action"`
+	Qty      decimal.Decimal `json:"qty"`
+	Strength *float64        `json:"strength,omitempty"`
+	Reason   string          `json:"reason"`
+	Weight   float64         `json:"weight,omitempty"`
+}
+
+// Ensemble runs every member on each bar and combines their intents. All
+// members are evaluated even when the vote is already decided, so stateful
+// members see every bar.
+type Ensemble struct {
+	Vote      string
+	Threshold float64
+	Members   []Member
+	timeframe md.Timeframe
+}
+
+func NewEnsemble(vote string, threshold float64, members []Member) (*Ensemble, error) {
+	switch vote {
+	case VoteUnanimous, VoteMajority, VoteWeighted, VoteFirstNonHold, VoteVeto:
+	default:
+		return nil, fmt.Errorf("invalid ensemble vote: %q", vote)
+	}
+	if len(members) == 0 {
+		return nil, fmt.Errorf("ensemble needs at least one member")
+	}
+	if vote == VoteWeighted && (threshold <= 0 || threshold >= 1) {
+		return nil, fmt.Errorf("ensemble threshold must be in (0, 1), got %g", threshold)
+	}
+	e := &Ensemble{Vote: vote, Threshold: threshold, Members: make([]Member, len(members))}
+	for i, m := range members {
+		if m.Weight < 0 {
+			return nil, fmt.Errorf("member %s: weight must be >= 0", m.Name)
+		}
+		if m.Weight == 0 {
+			m.Weight = 1
+		}
+		e.Members[i] = m
+		// The engine evaluates one timeframe, so members that declare their
+		// own must agree on it.
+		if tf, ok := m.Strategy.(TimeframeStrategy); ok && tf.Timeframe() != "" {
+			if e.timeframe != "" && e.timeframe != tf.Timeframe() {
+				return nil, fmt.Errorf("member %s runs on %s but another member runs on %s", m.Name, tf.Timeframe(), e.timeframe)
+			}
+			e.timeframe = tf.Timeframe()
+		}
+	}
+	return e, nil
+}
+
+func (e *Ensemble) Timeframe() md.Timeframe {
+	return e.timeframe
+}
+
+func (e *Ensemble) Timeframes() []md.Timeframe {
+	var timeframes []md.Timeframe
+	for _, m := range e.Members {
+		if multi, ok := m.Strategy.(MultiTimeframeStrategy); ok {
+			timeframes = append(timeframes, multi.Timeframes()...)
+		}
+	}
+	return timeframes
+}
+
+// memberState is one member's saved state. Members are matched by position
+// and name, since the same strategy may appear twice with different params.
+type memberState struct {
+	Name  string          `json:"name"`
+	State json.RawMessage `json:"state,omitempty"`
+}
+
+// MarshalState saves the state of every Stateful member.
+func (e *Ensemble) MarshalState() ([]byte, error) {
+	states := make([]memberState, len(e.Members))
+	saved := false
+	for i, m := range e.Members {
+		states[i].Name = m.Name
+		stateful, ok := m.Strategy.(Stateful)
+		if !ok {
+			continue
+		}
+		data, err := stateful.MarshalState()
+		if err != nil {
+			return nil, fmt.Errorf("member %s: %w", m.Name, err)
+		}
+		states[i].State = data
+		saved = saved || data != nil
+	}
+	if !saved {
+		return nil, nil
+	}
+	return json.Marshal(states)
+}
+
+// UnmarshalState restores each member saved at the same position under the
+// same name; the rest start fresh.
+func (e *Ensemble) UnmarshalState(data []byte) error {
+	var states []memberState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return err
+	}
+	for i, m := range e.Members {
+		stateful, ok := m.Strategy.(Stateful)
+		if !ok || i >= len(states) || states[i].Name != m.Name || states[i].State == nil {
+			continue
+		}
+		if err := stateful.UnmarshalState(states[i].State); err != nil {
+			return fmt.Errorf("member %s: %w", m.Name, err)
+		}
+	}
+	return nil
+}
+
+func (e *Ensemble) Decide(snapshot MarketSnapshot) TradeIntent {
+	children := make([]ChildIntent, len(e.Members))
+	for i, m := range e.Members {
+		// Targets vote as the trade that reaches them.
+		intent := m.Strategy.Decide(snapshot).Resolve(snapshot.PositionQty)
+		children[i] = ChildIntent{Strategy: m.Name, Action: intent.Action, Qty: intent.Qty, Strength: intent.Strength, Reason: intent.Reason, Weight: m.Weight}
+	}
+	intent := e.combine(children)
+	intent.Children = children
+	return intent
+}
+
+func (e *Ensemble) combine(children []ChildIntent) TradeIntent {
+	switch e.Vote {
+	case VoteFirstNonHold:
+		for _, c := range children {
+			if c.Action != Hold {
+				return e.agree(c.Action, []ChildIntent{c}, children)
+			}
+		}
+		return TradeIntent{Action: Hold, Reason: e.Vote + ": all_hold"}
+	case VoteVeto:
+		primary := children[0]
+		if primary.Action == Hold {
+			return TradeIntent{Action: Hold, Reason: e.Vote + ": " + primary.Reason}
+		}
+		for _, c := range children[1:] {
+			if c.Action != Hold && c.Action != primary.Action {
+				return TradeIntent{Action: Hold, Reason: e.Vote + ": vetoed_by_" + c.Strategy}
+			}
+		}
+		return e.agree(primary.Action, children[:1], children)
+	}
+
+	votes := make(map[Action]float64)
+	var total float64
+	for _, c := range children {
+		weight := e.weight(c)
+		total += weight
+		if c.Action != Hold {
+			votes[c.Action] += weight
+		}
+	}
+	var passes func(votes float64) bool
+	switch e.Vote {
+	case VoteUnanimous:
+		passes = func(votes float64) bool { return votes == total }
+	case VoteWeighted:
+		passes = func(votes float64) bool { return votes > total*e.Threshold }
+	default:
+		passes = func(votes float64) bool { return votes > total/2 }
+	}
+	// The winner needs strictly more votes than any other trade, so a tie
+	// holds.
+	var winner Action
+	var best, runnerUp float64
+	var tally []string
+	for _, action := range voteOrder {
+		v, ok := votes[action]
+		if !ok {
+			continue
+		}
+		tally = append(tally, fmt.Sprintf("%s=%g", strings.ToLower(string(action)), v))
+		if v > best {
+			winner, best, runnerUp = action, v, best
+		} else if v > runnerUp {
+			runnerUp = v
+		}
+	}
+	if winner != "" && passes(best) && best > runnerUp {
+		return e.agree(winner, children, children)
+	}
+	return TradeIntent{Action: Hold, Reason: fmt.Sprintf("%s: no_consensus %s of %g", e.Vote, strings.Join(tally, " "), total)}
+}
+
+// voteOrder lists the trades an ensemble can vote for, in the order the
+// tally is reported.
+var voteOrder = []Action{Buy, Sell, SellShort, BuyToCover}
+
+// weight is c's vote: its member weight under weighted voting, else 1.
+func (e *Ensemble) weight(c ChildIntent) float64 {
+	if e.Vote == VoteWeighted {
+		return c.Weight
+	}
+	return 1
+}
+
+// agree builds the combined intent from the voters that chose action,
+// trading the smallest quantity any of them asked for at their (weighted)
+// mean strength. Voters without a strength count as full.
+func (e *Ensemble) agree(action Action, voters []ChildIntent, children []ChildIntent) TradeIntent {
+	qty := decimal.Zero
+	var reasons []string
+	var strength, weights float64
+	rated := false
+	for _, c := range voters {
+		if c.Action != action {
+			continue
+		}
+		if qty.IsZero() || (c.Qty.IsPositive() && c.Qty.LessThan(qty)) {
+			qty = c.Qty
+		}
+		s := 1.0
+		if c.Strength != nil {
+			s, rated = *c.Strength, true
+		}
+		strength += s * e.weight(c)
+		weights += e.weight(c)
+		reasons = append(reasons, c.Strategy+"="+c.Reason)
+	}
+	supporters := 0
+	for _, c := range children {
+		if c.Action == action {
+			supporters++
+		}
+	}
+	intent := TradeIntent{
+		Action: action,
+		Qty:    qty,
+		Reason: fmt.Sprintf("%s %d/%d: %s", e.Vote, supporters, len(children), strings.Join(reasons, ",")),
+	}
+	if rated && weights > 0 {
+		intent.Strength = Strength(strength / weights)
+	}
+	return intent
+}
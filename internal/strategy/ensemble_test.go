@@ -0,0 +1,150 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+)
+
+// fixed always returns the same intent.
+type fixed TradeIntent
+
+func (f fixed) Decide(snapshot MarketSnapshot) TradeIntent {
+	return TradeIntent(f)
+}
+
+func members(actions ...Action) []Member {
+	out := make([]Member, len(actions))
+	for i, action := range actions {
+		qty := 0
+		if action != Hold {
+			qty = i + 1
+		}
+		out[i] = Member{Name: string(rune('a' + i)), Strategy: fixed{Action: action, Qty: Shares(qty), Reason: "r"}}
+	}
+	return out
+}
+
+func TestEnsembleVoting(t *testing.T) {
+	cases := []struct {
+		vote    string
+		actions []Action
+		weights []float64
+		want    Action
+		qty     int
+	}{
+		{VoteUnanimous, []Action{Buy, Buy}, nil, Buy, 1},
+		{VoteUnanimous, []Action{Buy, Hold}, nil, Hold, 0},
+		{VoteMajority, []Action{Buy, Hold, Buy}, nil, Buy, 1},
+		{VoteMajority, []Action{Buy, Sell, Hold}, nil, Hold, 0},
+		{VoteWeighted, []Action{Buy, Hold, Hold}, []float64{3, 1, 1}, Buy, 1},
+		{VoteWeighted, []Action{Buy, Sell, Sell}, []float64{2, 1, 1}, Hold, 0},
+		{VoteMajority, []Action{SellShort, Hold, SellShort}, nil, SellShort, 1},
+		{VoteUnanimous, []Action{BuyToCover, BuyToCover}, nil, BuyToCover, 1},
+		{VoteWeighted, []Action{SellShort, Buy, Hold}, []float64{3, 1, 1}, SellShort, 1},
+		{VoteMajority, []Action{SellShort, Buy, Hold}, nil, Hold, 0},
+		{VoteFirstNonHold, []Action{Hold, Sell, Buy}, nil, Sell, 2},
+		{VoteVeto, []Action{Buy, Hold}, nil, Buy, 1},
+		{VoteVeto, []Action{Buy, Sell}, nil, Hold, 0},
+		{VoteVeto, []Action{Hold, Buy}, nil, Hold, 0},
+	}
+	for _, tc := range cases {
+		ms := members(tc.actions...)
+		for i, w := range tc.weights {
+			ms[i].Weight = w
+		}
+		e, err := NewEnsemble(tc.vote, 0.5, ms)
+		if err != nil {
+			t.Fatalf("%s %v: %v", tc.vote, tc.actions, err)
+		}
+		intent := e.Decide(MarketSnapshot{})
+		if intent.Action != tc.want || !intent.Qty.Equal(Shares(tc.qty)) {
+			t.Fatalf("%s %v: expected %s x%d, got %s x%s (%s)", tc.vote, tc.actions, tc.want, tc.qty, intent.Action, intent.Qty, intent.Reason)
+		}
+		if len(intent.Children) != len(tc.actions) {
+			t.Fatalf("%s %v: expected every child intent recorded, got %+v", tc.vote, tc.actions, intent.Children)
+		}
+	}
+}
+
+func TestEnsembleCarriesWeightedStrength(t *testing.T) {
+	ms := []Member{
+		{Name: "a", Strategy: fixed{Action: Buy, Qty: Shares(1), Strength: Strength(0.2)}, Weight: 3},
+		{Name: "b", Strategy: fixed{Action: Buy, Qty: Shares(1)}, Weight: 1},
+		{Name: "c", Strategy: fixed{Action: Sell, Qty: Shares(1), Strength: Strength(0)}, Weight: 1},
+	}
+	e, err := NewEnsemble(VoteWeighted, 0.5, ms)
+	if err != nil {
+		t.Fatalf("new ensemble: %v", err)
+	}
+	// (0.2*3 + 1*1) / 4: the unrated member counts as full, the seller not at all.
+	intent := e.Decide(MarketSnapshot{})
+	if intent.Action != Buy || intent.Strength == nil || *intent.Strength < 0.3999 || *intent.Strength > 0.4001 {
+		t.Fatalf("expected a buy at strength 0.4, got %s %v (%s)", intent.Action, intent.Strength, intent.Reason)
+	}
+
+	unrated, err := NewEnsemble(VoteMajority, 0, members(Buy, Buy))
+	if err != nil {
+		t.Fatalf("new ensemble: %v", err)
+	}
+	if intent := unrated.Decide(MarketSnapshot{}); intent.Strength != nil {
+		t.Fatalf("expected no strength when no member set one, got %v", *intent.Strength)
+	}
+}
+
+func TestEnsembleBuildsMembersFromConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.Ensemble.Vote = VoteWeighted
+	cfg.Ensemble.Members = []config.EnsembleMember{
+		{Strategy: "sma", Weight: 2},
+		{Strategy: "mean_reversion", Params: map[string]float64{"band_pct": 0.03}},
+	}
+	strat, err := Build("ensemble", cfg, nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	e := strat.(*Ensemble)
+	if len(e.Members) != 2 || e.Members[0].Weight != 2 || e.Members[1].Weight != 1 {
+		t.Fatalf("unexpected members: %+v", e.Members)
+	}
+	if mr := e.Members[1].Strategy.(MeanReversion); mr.BandPct != 0.03 {
+		t.Fatalf("expected member params applied, got %+v", mr)
+	}
+
+	cfg.Ensemble.Members[1].Params = map[string]float64{"nope": 1}
+	if _, err := Build("ensemble", cfg, nil); err == nil || !strings.Contains(err.Error(), "member mean_reversion") {
+		t.Fatalf("expected member param error, got %v", err)
+	}
+	cfg.Ensemble.Members = nil
+	if _, err := Build("ensemble", cfg, nil); err == nil {
+		t.Fatalf("expected error for an empty ensemble")
+	}
+}
+
+func TestEnsembleStateRestoresMembersByPosition(t *testing.T) {
+	build := func() *Ensemble {
+		e, err := NewEnsemble(VoteMajority, 0, []Member{
+			{Name: "random_noise", Strategy: NewRandomNoise(1)},
+			{Name: "sma", Strategy: SMA{MaxQty: 1}},
+			{Name: "random_noise", Strategy: NewRandomNoise(1)},
+		})
+		if err != nil {
+			t.Fatalf("build: %v", err)
+		}
+		return e
+	}
+	before := build()
+	before.Members[2].Strategy.Decide(MarketSnapshot{})
+	data, err := before.MarshalState()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	after := build()
+	if err := after.UnmarshalState(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if first, third := after.Members[0].Strategy.(*RandomNoise), after.Members[2].Strategy.(*RandomNoise); first.tradeCount != 0 || third.tradeCount != 1 {
+		t.Fatalf("expected only the third member restored, got %d and %d", first.tradeCount, third.tradeCount)
+	}
+}
@@ -8,10 +8,28 @@ import (
 	"sync"
 	"time"
 
+	"ats/internal/config"
 	"ats/internal/llm"
+	"ats/internal/llm/ollama"
 	"ats/internal/llm/prompts"
+
+	"github.com/shopspring/decimal"
 )
 
+func init() {
+	Register(Definition{
+		Name:        "llm",
+		Description: "asks an Ollama model for each decision; configured with the LLM_* settings",
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			if cfg.LLMModel == "" {
+				return nil, fmt.Errorf("llm-model is required")
+			}
+			client := llm.New(ollama.New(cfg.LLMBaseURL, cfg.LLMModel))
+			return NewLLMStrategy(client, cfg.MaxQty, cfg.LLMSystemPromptPath, cfg.LLMDecisionPromptPath, cfg.LLMTimeout, cfg.LLMContextPrompt), nil
+		},
+	})
+}
+
 type LLMStrategy struct {
 	client         *llm.Client
 	maxQty         int
@@ -24,9 +42,9 @@ type LLMStrategy struct {
 }
 
 type llmDecision struct {
-	Action string `json:"action"`
-	Qty    int    `json:"qty"`
-	Reason string `json:"reason"`
+	Action string  `json:"action"`
+	Qty    float64 `json:"qty"`
+	Reason string  `json:"reason"`
 }
 
 func NewLLMStrategy(
@@ -88,7 +106,7 @@ func (s *LLMStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
 	}
 
 	action := normalizeAction(decision.Action)
-	qty := clampQty(decision.Qty, s.maxQty)
+	qty := clampQty(decimal.NewFromFloat(decision.Qty), s.maxQty)
 	reason := decision.Reason
 	if reason == "" {
 		reason = "llm_decision"
@@ -96,13 +114,16 @@ func (s *LLMStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
 
 	switch action {
 	case Buy:
-		if qty == 0 {
+		if qty.IsZero() {
 			return TradeIntent{Action: Hold, Reason: "llm_zero_qty"}
 		}
-		return TradeIntent{Action: Buy, Qty: qty, Reason: reason}
+		// The model's quantity out of max-qty is its conviction under other
+		// sizing methods.
+		strength := qty.Div(Shares(s.maxQty)).InexactFloat64()
+		return TradeIntent{Action: Buy, Qty: qty, Strength: &strength, Reason: reason}
 	case Sell:
-		qty = min(qty, snapshot.PositionQty)
-		if qty == 0 {
+		qty = decimal.Min(qty, snapshot.PositionQty)
+		if !qty.IsPositive() {
 			return TradeIntent{Action: Hold, Reason: "llm_zero_qty"}
 		}
 		return TradeIntent{Action: Sell, Qty: qty, Reason: reason}
@@ -165,12 +186,9 @@ func normalizeAction(action string) Action {
 	}
 }
 
-func clampQty(qty int, maxQty int) int {
-	if qty < 0 {
-		return 0
-	}
-	if qty > maxQty {
-		return maxQty
+func clampQty(qty decimal.Decimal, maxQty int) decimal.Decimal {
+	if qty.IsNegative() {
+		return decimal.Zero
 	}
-	return qty
+	return decimal.Min(qty, Shares(maxQty))
 }
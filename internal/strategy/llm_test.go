@@ -67,17 +67,16 @@ func TestLLMStrategyDecide_BuyClampsQty(t *testing.T) {
 	)
 
 	intent := strategy.Decide(MarketSnapshot{
-		Timestamp:   time.Now(),
-		Close:       101.2,
-		SMA:         100.1,
-		PositionQty: 0,
+		Timestamp: time.Now(),
+		Close:     101.2,
+		SMA:       100.1,
 	})
 
 	if intent.Action != Buy {
 		t.Fatalf("expected BUY, got %s", intent.Action)
 	}
-	if intent.Qty != 2 {
-		t.Fatalf("expected qty 2, got %d", intent.Qty)
+	if !intent.Qty.Equal(Shares(2)) {
+		t.Fatalf("expected qty 2, got %s", intent.Qty)
 	}
 	if intent.Reason != "signal" {
 		t.Fatalf("expected reason signal, got %q", intent.Reason)
@@ -120,14 +119,14 @@ func TestLLMStrategyDecide_SellClampsToPosition(t *testing.T) {
 		Timestamp:   time.Now(),
 		Close:       99.2,
 		SMA:         100.1,
-		PositionQty: 3,
+		PositionQty: Shares(3),
 	})
 
 	if intent.Action != Sell {
 		t.Fatalf("expected SELL, got %s", intent.Action)
 	}
-	if intent.Qty != 3 {
-		t.Fatalf("expected qty 3, got %d", intent.Qty)
+	if !intent.Qty.Equal(Shares(3)) {
+		t.Fatalf("expected qty 3, got %s", intent.Qty)
 	}
 	if intent.Reason != "take_profit" {
 		t.Fatalf("expected reason take_profit, got %q", intent.Reason)
@@ -153,10 +152,9 @@ func TestLLMStrategyDecide_InvalidResponse(t *testing.T) {
 	)
 
 	intent := strategy.Decide(MarketSnapshot{
-		Timestamp:   time.Now(),
-		Close:       100,
-		SMA:         100,
-		PositionQty: 0,
+		Timestamp: time.Now(),
+		Close:     100,
+		SMA:       100,
 	})
 
 	if intent.Action != Hold {
@@ -179,10 +177,9 @@ func TestLLMStrategyDecide_ProviderError(t *testing.T) {
 	)
 
 	intent := strategy.Decide(MarketSnapshot{
-		Timestamp:   time.Now(),
-		Close:       100,
-		SMA:         100,
-		PositionQty: 0,
+		Timestamp: time.Now(),
+		Close:     100,
+		SMA:       100,
 	})
 
 	if intent.Action != Hold {
@@ -225,10 +222,9 @@ func TestLLMStrategyDecide_PromptTemplateError(t *testing.T) {
 	)
 
 	intent := strategy.Decide(MarketSnapshot{
-		Timestamp:   time.Now(),
-		Close:       100,
-		SMA:         100,
-		PositionQty: 0,
+		Timestamp: time.Now(),
+		Close:     100,
+		SMA:       100,
 	})
 
 	if intent.Action != Hold {
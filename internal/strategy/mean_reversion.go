@@ -1,5 +1,106 @@
 package strategy
 
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"ats/internal/config"
+	"ats/internal/indicator"
+)
+
+func init() {
+	mr := NewMeanReversion(0)
+	Register(Definition{
+		Name:        "mean_reversion",
+		Description: "buy below the lower SMA band, sell above the upper band or on a stop below the SMA",
+		Params: []ParamSpec{
+			{Name: "band_pct", Kind: ParamFloat, Default: mr.BandPct, Min: 0, Max: 1, Description: "band width as a fraction of the SMA"},
+			{Name: "min_bars", Kind: ParamInt, Default: float64(mr.MinBars), Min: 0, Description: "bars required before trading, at most bars-window"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			s := NewMeanReversion(cfg.MaxQty)
+			s.BandPct = params.Float("band_pct")
+			s.MinBars = params.Int("min_bars")
+			if s.MinBars > cfg.BarsWindow {
+				return nil, fmt.Errorf("min_bars (%d) exceeds bars-window (%d), so it would never trade", s.MinBars, cfg.BarsWindow)
+			}
+			return s, nil
+		},
+	})
+
+	rsi := NewRSIMeanReversion(0)
+	Register(Definition{
+		Name:        "rsi_mean_reversion",
+		Description: "buy when RSI is oversold below the SMA, sell when it is overbought or on a quick profit",
+		Params: []ParamSpec{
+			{Name: "rsi_period", Kind: ParamInt, Default: float64(rsi.RSIPeriod), Min: 2, Description: "RSI lookback in bars"},
+			{Name: "oversold", Kind: ParamFloat, Default: rsi.Oversold, Min: 0, Max: 100, Description: "buy below this RSI"},
+			{Name: "overbought", Kind: ParamFloat, Default: rsi.Overbought, Min: 0, Max: 100, Description: "sell above this RSI"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			s := NewRSIMeanReversion(cfg.MaxQty)
+			s.RSIPeriod = params.Int("rsi_period")
+			s.Oversold = params.Float("oversold")
+			s.Overbought = params.Float("overbought")
+			if s.Oversold >= s.Overbought {
+				return nil, fmt.Errorf("oversold (%g) must be below overbought (%g)", s.Oversold, s.Overbought)
+			}
+			return s, nil
+		},
+	})
+
+	mom := NewMomentumStrategy(0)
+	Register(Definition{
+		Name:        "momentum",
+		Description: "buy closes that break out above the recent high, sell when momentum reverses below the SMA",
+		Params: []ParamSpec{
+			{Name: "lookback_bars", Kind: ParamInt, Default: float64(mom.LookbackBars), Min: 1, Description: "previous closes that define the recent high"},
+			{Name: "breakout_pct", Kind: ParamFloat, Default: mom.BreakoutPct, Min: 0, Max: 1, Description: "breakout distance above the recent high"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			s := NewMomentumStrategy(cfg.MaxQty)
+			s.LookbackBars = params.Int("lookback_bars")
+			s.BreakoutPct = params.Float("breakout_pct")
+			return s, nil
+		},
+	})
+
+	scalp := NewScalpingStrategy(0)
+	Register(Definition{
+		Name:        "scalping",
+		Description: "buy small dips below the SMA, exit on a small gain, a stop or after a few bars",
+		Params: []ParamSpec{
+			{Name: "entry_threshold", Kind: ParamFloat, Default: scalp.EntryThreshold, Min: 0, Max: 1, Description: "dip below the SMA that triggers an entry"},
+			{Name: "profit_target", Kind: ParamFloat, Default: scalp.ProfitTarget, Min: 0, Max: 1, Description: "gain above the entry price that triggers an exit"},
+			{Name: "max_hold_bars", Kind: ParamInt, Default: float64(scalp.MaxHoldBars), Min: 1, Description: "force an exit after this many bars"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			s := NewScalpingStrategy(cfg.MaxQty)
+			s.EntryThreshold = params.Float("entry_threshold")
+			s.ProfitTarget = params.Float("profit_target")
+			s.MaxHoldBars = params.Int("max_hold_bars")
+			return s, nil
+		},
+	})
+
+	Register(Definition{
+		Name:        "random_alternating",
+		Description: "test strategy: buy when flat, sell when long, every bar",
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			return NewRandomAlternating(cfg.MaxQty), nil
+		},
+	})
+
+	Register(Definition{
+		Name:        "random_noise",
+		Description: "test strategy: cycles through buy, hold and flip to stress order handling",
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			return NewRandomNoise(cfg.MaxQty), nil
+		},
+	})
+}
+
 // MeanReversion implements a Bollinger Bands style strategy
 // Buys when price dips below lower band (oversold), sells when it hits upper band (overbought)
 type MeanReversion struct {
@@ -21,21 +122,25 @@ func (m MeanReversion) Decide(snapshot MarketSnapshot) TradeIntent {
 	if snapshot.SMA == 0 {
 		return TradeIntent{Action: Hold, Reason: "insufficient_data"}
 	}
+	if barsSeen(snapshot) < m.MinBars {
+		return TradeIntent{Action: Hold, Reason: "warming_up"}
+	}
 
 	lowerBand := snapshot.SMA * (1 - m.BandPct)
 	upperBand := snapshot.SMA * (1 + m.BandPct)
 
 	// Buy: price below lower band and no position
-	if snapshot.PositionQty == 0 && snapshot.Close < lowerBand {
+	if snapshot.PositionQty.IsZero() && snapshot.Close < lowerBand {
 		return TradeIntent{
-			Action: Buy,
-			Qty:    m.MaxQty,
-			Reason: "price_below_lower_band",
+			Action:   Buy,
+			Qty:      Shares(m.MaxQty),
+			Strength: Strength(1),
+			Reason:   "price_below_lower_band",
 		}
 	}
 
 	// Sell: price above upper band and have position
-	if snapshot.PositionQty > 0 && snapshot.Close > upperBand {
+	if snapshot.PositionQty.IsPositive() && snapshot.Close > upperBand {
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -44,7 +149,7 @@ func (m MeanReversion) Decide(snapshot MarketSnapshot) TradeIntent {
 	}
 
 	// Also sell if price drops below SMA (stop loss / mean reversion failed)
-	if snapshot.PositionQty > 0 && snapshot.Close < snapshot.SMA*0.995 {
+	if snapshot.PositionQty.IsPositive() && snapshot.Close < snapshot.SMA*0.995 {
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -55,13 +160,25 @@ func (m MeanReversion) Decide(snapshot MarketSnapshot) TradeIntent {
 	return TradeIntent{Action: Hold, Reason: "within_bands"}
 }
 
-// RSIMeanReversion combines RSI oversold signals with mean reversion
+// barsSeen is the number of bars in the snapshot's longest frame, that of
+// the strategy's own timeframe unless a finer one was requested.
+func barsSeen(snapshot MarketSnapshot) int {
+	n := 0
+	for _, frame := range snapshot.Frames {
+		n = max(n, len(frame.Bars))
+	}
+	return n
+}
+
+// RSIMeanReversion buys when Wilder's RSI over RSIPeriod closes is below
+// Oversold with the close under the SMA, and sells when the RSI rises above
+// Overbought or the position is up 0.5%.
 type RSIMeanReversion struct {
 	MaxQty     int
 	RSIPeriod  int
-	Oversold   float64   // RSI below this = buy
-	Overbought float64   // RSI above this = sell
-	rsivalues  []float64 // stores gains/losses for RSI calc
+	Oversold   float64 // RSI below this = buy
+	Overbought float64 // RSI above this = sell
+	rsi        rsiTracker
 }
 
 func NewRSIMeanReversion(maxQty int) *RSIMeanReversion {
@@ -70,42 +187,34 @@ func NewRSIMeanReversion(maxQty int) *RSIMeanReversion {
 		RSIPeriod:  7,  // short period for quick signals
 		Oversold:   35, // less extreme than 30
 		Overbought: 65, // less extreme than 70
-		rsivalues:  make([]float64, 0, 20),
 	}
 }
 
 func (r *RSIMeanReversion) Decide(snapshot MarketSnapshot) TradeIntent {
-	// Simple price-based proxy for quick testing
-	// In real implementation, you'd calculate actual RSI from price history
-
-	// Mean reversion with tighter bands for frequency
-	lowerBand := snapshot.SMA * 0.985 // 1.5% below SMA
-	upperBand := snapshot.SMA * 1.015 // 1.5% above SMA
-
-	// Buy signal: price near lower band (oversold)
-	if snapshot.PositionQty == 0 && snapshot.Close <= lowerBand {
-		return TradeIntent{
-			Action: Buy,
-			Qty:    r.MaxQty,
-			Reason: "oversold_band",
-		}
+	rsi, ok := r.rsi.add(snapshot.Close, r.RSIPeriod)
+	if !ok {
+		return TradeIntent{Action: Hold, Reason: "warming_up"}
 	}
 
-	// Sell signal: price near upper band (overbought)
-	if snapshot.PositionQty > 0 && snapshot.Close >= upperBand {
+	if snapshot.PositionQty.IsZero() && rsi < r.Oversold && snapshot.Close < snapshot.SMA {
 		return TradeIntent{
-			Action: Sell,
-			Qty:    snapshot.PositionQty,
-			Reason: "overbought_band",
+			Action:   Buy,
+			Qty:      Shares(r.MaxQty),
+			Strength: Strength(1),
+			Reason:   "rsi_oversold",
 		}
 	}
 
-	// Quick exit: small profit target (0.5%)
-	// This ensures quick turnover for testing
-	if snapshot.PositionQty > 0 {
-		// Assuming avg entry is around SMA when we bought
-		profitTarget := snapshot.SMA * 1.005
-		if snapshot.Close >= profitTarget {
+	if snapshot.PositionQty.IsPositive() {
+		if rsi > r.Overbought {
+			return TradeIntent{
+				Action: Sell,
+				Qty:    snapshot.PositionQty,
+				Reason: "rsi_overbought",
+			}
+		}
+		// Quick exit: small profit target (0.5%)
+		if snapshot.Close >= snapshot.AvgEntry*1.005 {
 			return TradeIntent{
 				Action: Sell,
 				Qty:    snapshot.PositionQty,
@@ -117,15 +226,74 @@ func (r *RSIMeanReversion) Decide(snapshot MarketSnapshot) TradeIntent {
 	return TradeIntent{Action: Hold, Reason: "no_signal"}
 }
 
-// MomentumStrategy buys on breakout, sells on reversal
-// Good for trending markets
+// rsiTracker updates Wilder's RSI one close at a time; after the same closes
+// it matches indicator.RSI over the whole series.
+type rsiTracker struct {
+	closes  int
+	last    float64
+	avgGain float64
+	avgLoss float64
+}
+
+func (t *rsiTracker) add(close float64, period int) (float64, bool) {
+	if t.closes > 0 {
+		change := close - t.last
+		up, down := math.Max(change, 0), math.Max(-change, 0)
+		if t.closes <= period {
+			t.avgGain += up / float64(period)
+			t.avgLoss += down / float64(period)
+		} else {
+			t.avgGain = (t.avgGain*float64(period-1) + up) / float64(period)
+			t.avgLoss = (t.avgLoss*float64(period-1) + down) / float64(period)
+		}
+	}
+	t.closes++
+	t.last = close
+	if t.closes <= period {
+		return 0, false
+	}
+	if t.avgLoss == 0 {
+		return 100, true
+	}
+	return 100 - 100/(1+t.avgGain/t.avgLoss), true
+}
+
+type rsiState struct {
+	Period  int     `json:"period"`
+	Closes  int     `json:"closes"`
+	Last    float64 `json:"last"`
+	AvgGain float64 `json:"avg_gain"`
+	AvgLoss float64 `json:"avg_loss"`
+}
+
+// MarshalState keeps the running RSI, so a restart does not warm up again.
+func (r *RSIMeanReversion) MarshalState() ([]byte, error) {
+	return json.Marshal(rsiState{Period: r.RSIPeriod, Closes: r.rsi.closes, Last: r.rsi.last, AvgGain: r.rsi.avgGain, AvgLoss: r.rsi.avgLoss})
+}
+
+// UnmarshalState restores the running RSI unless it was averaged over a
+// different period, in which case the strategy warms up afresh.
+func (r *RSIMeanReversion) UnmarshalState(data []byte) error {
+	var state rsiState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if state.Period != r.RSIPeriod {
+		return nil
+	}
+	r.rsi = rsiTracker{closes: state.Closes, last: state.Last, avgGain: state.AvgGain, avgLoss: state.AvgLoss}
+	return nil
+}
+
+// MomentumStrategy buys a close more than BreakoutPct above the highest of
+// the previous LookbackBars closes, and sells when the close falls back
+// below the SMA. Good for trending markets.
 type MomentumStrategy struct {
 	MaxQty       int
 	LookbackBars int
 	BreakoutPct  float64
 	StopLossPct  float64
-	highs        []float64
-	lows         []float64
+	closes       []float64 // previous closes, at most LookbackBars
 }
 
 func NewMomentumStrategy(maxQty int) *MomentumStrategy {
@@ -134,28 +302,33 @@ func NewMomentumStrategy(maxQty int) *MomentumStrategy {
 		LookbackBars: 5,     // very short for quick signals
 		BreakoutPct:  0.008, // 0.8% breakout
 		StopLossPct:  0.015, // 1.5% stop
-		highs:        make([]float64, 0, 20),
-		lows:         make([]float64, 0, 20),
 	}
 }
 
 func (m *MomentumStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
-	// Track recent highs and lows using SMA as proxy
-	// In production, you'd use actual bar high/low from ring buffer
-
-	recentHigh := snapshot.SMA * 1.005
+	recentHigh, ready := indicator.Highest(m.closes, m.LookbackBars)
+	m.closes = append(m.closes, snapshot.Close)
+	if len(m.closes) > m.LookbackBars {
+		m.closes = append([]float64(nil), m.closes[len(m.closes)-m.LookbackBars:]...)
+	}
 
 	// Buy breakout: price breaks above recent high
-	if snapshot.PositionQty == 0 && snapshot.Close > recentHigh*(1+m.BreakoutPct) {
-		return TradeIntent{
-			Action: Buy,
-			Qty:    m.MaxQty,
-			Reason: "breakout_above_high",
+	if snapshot.PositionQty.IsZero() {
+		if !ready {
+			return TradeIntent{Action: Hold, Reason: "warming_up"}
+		}
+		if snapshot.Close > recentHigh*(1+m.BreakoutPct) {
+			return TradeIntent{
+				Action:   Buy,
+				Qty:      Shares(m.MaxQty),
+				Strength: Strength(1),
+				Reason:   "breakout_above_high",
+			}
 		}
 	}
 
 	// Sell: stop loss or momentum reversal
-	if snapshot.PositionQty > 0 {
+	if snapshot.PositionQty.IsPositive() {
 		// Simple momentum reversal - price dropping below SMA
 		if snapshot.Close < snapshot.SMA*0.998 {
 			return TradeIntent{
@@ -174,9 +347,8 @@ func (m *MomentumStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
 type ScalpingStrategy struct {
 	MaxQty         int
 	EntryThreshold float64 // how far below SMA to enter (e.g., 0.005 = 0.5%)
-	ProfitTarget   float64 // exit when up this much (e.g., 0.003 = 0.3%)
+	ProfitTarget   float64 // exit when up this much from entry (e.g., 0.003 = 0.3%)
 	MaxHoldBars    int     // force exit after N bars
-	barsInPosition int
 }
 
 func NewScalpingStrategy(maxQty int) *ScalpingStrategy {
@@ -185,33 +357,25 @@ func NewScalpingStrategy(maxQty int) *ScalpingStrategy {
 		EntryThreshold: 0.008, // 0.8% below SMA
 		ProfitTarget:   0.005, // 0.5% profit target
 		MaxHoldBars:    5,     // max 5 bars in position
-		barsInPosition: 0,
 	}
 }
 
 func (s *ScalpingStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
-	entryPrice := snapshot.SMA * (1 - s.EntryThreshold)
-	profitTarget := snapshot.SMA * (1 + s.ProfitTarget)
-
 	// Entry: quick dip
-	if snapshot.PositionQty == 0 {
-		s.barsInPosition = 0
-		if snapshot.Close <= entryPrice {
+	if snapshot.PositionQty.IsZero() {
+		if snapshot.Close <= snapshot.SMA*(1-s.EntryThreshold) {
 			return TradeIntent{
-				Action: Buy,
-				Qty:    s.MaxQty,
-				Reason: "scalp_entry",
+				Action:   Buy,
+				Qty:      Shares(s.MaxQty),
+				Strength: Strength(1),
+				Reason:   "scalp_entry",
 			}
 		}
 		return TradeIntent{Action: Hold, Reason: "waiting_for_dip"}
 	}
 
-	// Exit logic when in position
-	s.barsInPosition++
-
 	// Take profit
-	if snapshot.Close >= profitTarget {
-		s.barsInPosition = 0
+	if snapshot.Close >= snapshot.AvgEntry*(1+s.ProfitTarget) {
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -220,8 +384,7 @@ func (s *ScalpingStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
 	}
 
 	// Time-based exit (don't hold too long)
-	if s.barsInPosition >= s.MaxHoldBars {
-		s.barsInPosition = 0
+	if snapshot.BarsSinceEntry >= s.MaxHoldBars {
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -230,9 +393,8 @@ func (s *ScalpingStrategy) Decide(snapshot MarketSnapshot) TradeIntent {
 	}
 
 	// Stop loss (small loss acceptable)
-	stopPrice := entryPrice * 0.996 // 0.4% stop
+	stopPrice := snapshot.AvgEntry * 0.996 // 0.4% stop
 	if snapshot.Close <= stopPrice {
-		s.barsInPosition = 0
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -255,11 +417,12 @@ func NewRandomAlternating(maxQty int) *RandomAlternating {
 
 func (r *RandomAlternating) Decide(snapshot MarketSnapshot) TradeIntent {
 	// Alternate buy/sell based on current position
-	if snapshot.PositionQty == 0 {
+	if snapshot.PositionQty.IsZero() {
 		return TradeIntent{
-			Action: Buy,
-			Qty:    r.MaxQty,
-			Reason: "test_buy",
+			Action:   Buy,
+			Qty:      Shares(r.MaxQty),
+			Strength: Strength(1),
+			Reason:   "test_buy",
 		}
 	}
 	return TradeIntent{
@@ -296,11 +459,12 @@ func (r *RandomNoise) Decide(snapshot MarketSnapshot) TradeIntent {
 
 	switch mod {
 	case 0:
-		if snapshot.PositionQty == 0 {
+		if snapshot.PositionQty.IsZero() {
 			return TradeIntent{
-				Action: Buy,
-				Qty:    r.MaxQty,
-				Reason: "random_buy",
+				Action:   Buy,
+				Qty:      Shares(r.MaxQty),
+				Strength: Strength(1),
+				Reason:   "random_buy",
 			}
 		}
 		return TradeIntent{
@@ -312,7 +476,7 @@ func (r *RandomNoise) Decide(snapshot MarketSnapshot) TradeIntent {
 		return TradeIntent{Action: Hold, Reason: "random_hold"}
 	case 2:
 		// Flip position
-		if snapshot.PositionQty > 0 {
+		if snapshot.PositionQty.IsPositive() {
 			return TradeIntent{
 				Action: Sell,
 				Qty:    snapshot.PositionQty,
@@ -320,11 +484,51 @@ func (r *RandomNoise) Decide(snapshot MarketSnapshot) TradeIntent {
 			}
 		}
 		return TradeIntent{
-			Action: Buy,
-			Qty:    r.MaxQty,
-			Reason: "random_flip_buy",
+			Action:   Buy,
+			Qty:      Shares(r.MaxQty),
+			Strength: Strength(1),
+			Reason:   "random_flip_buy",
 		}
 	}
 
 	return TradeIntent{Action: Hold, Reason: "random_hold"}
 }
+
+type momentumState struct {
+	Closes []float64 `json:"closes"`
+}
+
+// MarshalState keeps the closes behind the recent high.
+func (m *MomentumStrategy) MarshalState() ([]byte, error) {
+	return json.Marshal(momentumState{Closes: m.closes})
+}
+
+func (m *MomentumStrategy) UnmarshalState(data []byte) error {
+	var state momentumState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	if len(state.Closes) > m.LookbackBars {
+		state.Closes = state.Closes[len(state.Closes)-m.LookbackBars:]
+	}
+	m.closes = state.Closes
+	return nil
+}
+
+type noiseState struct {
+	TradeCount int `json:"trade_count"`
+}
+
+// MarshalState keeps the position in the buy/hold/flip cycle.
+func (r *RandomNoise) MarshalState() ([]byte, error) {
+	return json.Marshal(noiseState{TradeCount: r.tradeCount})
+}
+
+func (r *RandomNoise) UnmarshalState(data []byte) error {
+	var state noiseState
+	if err := json.Unmarshal(data, &state); err != nil {
+		return err
+	}
+	r.tradeCount = state.TradeCount
+	return nil
+}
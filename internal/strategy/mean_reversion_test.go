@@ -0,0 +1,166 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"ats/internal/indicator"
+	"ats/internal/md"
+)
+
+func TestScalpingExitsFromEntryContext(t *testing.T) {
+	s := NewScalpingStrategy(1)
+	cases := []struct {
+		name     string
+		snapshot MarketSnapshot
+		want     string
+	}{
+		{"profit from entry", MarketSnapshot{Close: 100.6, SMA: 101, PositionQty: Shares(1), AvgEntry: 100, BarsSinceEntry: 1}, "scalp_profit"},
+		{"held too long", MarketSnapshot{Close: 100, SMA: 100, PositionQty: Shares(1), AvgEntry: 100, BarsSinceEntry: 5}, "time_exit"},
+		{"stop below entry", MarketSnapshot{Close: 99.5, SMA: 100, PositionQty: Shares(1), AvgEntry: 100, BarsSinceEntry: 1}, "scalp_stop"},
+		{"in position", MarketSnapshot{Close: 100.2, SMA: 100, PositionQty: Shares(1), AvgEntry: 100, BarsSinceEntry: 4}, "in_position"},
+	}
+	for _, c := range cases {
+		if got := s.Decide(c.snapshot); got.Reason != c.want {
+			t.Fatalf("%s: expected %s, got %s", c.name, c.want, got.Reason)
+		}
+	}
+}
+
+func TestMeanReversionWaitsForMinBars(t *testing.T) {
+	m := NewMeanReversion(1)
+	m.MinBars = 4
+	snapshot := func(bars int) MarketSnapshot {
+		frame := Frame{Timeframe: md.OneMinute, Bars: make([]md.Bar, bars)}
+		return MarketSnapshot{Close: 95, SMA: 100, Frames: map[md.Timeframe]Frame{md.OneMinute: frame}}
+	}
+	if intent := m.Decide(snapshot(3)); intent.Action != Hold || intent.Reason != "warming_up" {
+		t.Fatalf("expected to wait for 4 bars, got %+v", intent)
+	}
+	if intent := m.Decide(snapshot(4)); intent.Action != Buy || intent.Reason != "price_below_lower_band" {
+		t.Fatalf("expected a buy below the band after 4 bars, got %+v", intent)
+	}
+}
+
+func TestRSITrackerMatchesIndicator(t *testing.T) {
+	closes := []float64{100, 101, 100.5, 102, 101, 99, 98.5, 99.5, 100, 97, 96, 98, 99}
+	var tracker rsiTracker
+	for i, c := range closes {
+		got, ok := tracker.add(c, 5)
+		want, wantOK := indicator.RSI(closes[:i+1], 5)
+		if ok != wantOK || math.Abs(got-want) > 1e-9 {
+			t.Fatalf("close %d: expected %v/%v, got %v/%v", i, want, wantOK, got, ok)
+		}
+	}
+}
+
+func TestRSIMeanReversionUsesItsThresholds(t *testing.T) {
+	falling := func(r *RSIMeanReversion) TradeIntent {
+		var intent TradeIntent
+		for i := 0; i < 5; i++ {
+			intent = r.Decide(MarketSnapshot{Close: 100 - float64(i), SMA: 101})
+		}
+		return intent
+	}
+	r := NewRSIMeanReversion(1)
+	r.RSIPeriod = 3
+	if intent := falling(r); intent.Action != Buy || intent.Reason != "rsi_oversold" {
+		t.Fatalf("expected an oversold buy, got %+v", intent)
+	}
+	r = NewRSIMeanReversion(1)
+	r.RSIPeriod = 6
+	if intent := falling(r); intent.Reason != "warming_up" {
+		t.Fatalf("expected a longer period to still be warming up, got %+v", intent)
+	}
+	r = NewRSIMeanReversion(1)
+	r.RSIPeriod, r.Oversold = 3, 0
+	if intent := falling(r); intent.Action != Hold {
+		t.Fatalf("expected no buy with oversold at 0, got %+v", intent)
+	}
+}
+
+func TestMomentumBreaksOutAboveTheLookbackHigh(t *testing.T) {
+	m := NewMomentumStrategy(1)
+	m.LookbackBars = 3
+	for _, c := range []float64{100, 101, 100} {
+		if intent := m.Decide(MarketSnapshot{Close: c, SMA: 100}); intent.Reason != "warming_up" {
+			t.Fatalf("expected warm-up, got %+v", intent)
+		}
+	}
+	if intent := m.Decide(MarketSnapshot{Close: 101.5, SMA: 100}); intent.Action != Hold {
+		t.Fatalf("expected no breakout within 0.8%% of the high, got %+v", intent)
+	}
+	if intent := m.Decide(MarketSnapshot{Close: 102.5, SMA: 100}); intent.Action != Buy || intent.Reason != "breakout_above_high" {
+		t.Fatalf("expected a breakout buy, got %+v", intent)
+	}
+}
+
+func TestRandomNoiseStateResumesTheCycle(t *testing.T) {
+	before := NewRandomNoise(1)
+	before.Decide(MarketSnapshot{})
+	data, err := before.MarshalState()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	after := NewRandomNoise(1)
+	if err := after.UnmarshalState(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	want := before.Decide(MarketSnapshot{})
+	if got := after.Decide(MarketSnapshot{}); got.Reason != want.Reason {
+		t.Fatalf("expected the restored strategy to continue with %s, got %s", want.Reason, got.Reason)
+	}
+}
+
+// restoredMatches runs closes through an uninterrupted strategy and through
+// one restarted from saved state at split, expecting the same intents.
+func restoredMatches(t *testing.T, newStrategy func() interface {
+	Strategy
+	Stateful
+}, closes []float64, split int) {
+	t.Helper()
+	snapshot := func(c float64) MarketSnapshot { return MarketSnapshot{Close: c, SMA: 100} }
+	uninterrupted, before := newStrategy(), newStrategy()
+	for _, c := range closes[:split] {
+		uninterrupted.Decide(snapshot(c))
+		before.Decide(snapshot(c))
+	}
+	data, err := before.MarshalState()
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	after := newStrategy()
+	if err := after.UnmarshalState(data); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for i, c := range closes[split:] {
+		want, got := uninterrupted.Decide(snapshot(c)), after.Decide(snapshot(c))
+		if got.Action != want.Action || got.Reason != want.Reason {
+			t.Fatalf("close %d: expected %s %s after restore, got %s %s", split+i, want.Action, want.Reason, got.Action, got.Reason)
+		}
+	}
+}
+
+func TestRSIMeanReversionStateResumesTheRSI(t *testing.T) {
+	closes := []float64{100, 99.5, 99.8, 99, 98.6, 98.9, 98, 97.5, 97.9, 97, 96.5, 97.2, 96}
+	restoredMatches(t, func() interface {
+		Strategy
+		Stateful
+	} {
+		r := NewRSIMeanReversion(1)
+		r.RSIPeriod = 5
+		return r
+	}, closes, 4)
+}
+
+func TestMomentumStateResumesTheLookback(t *testing.T) {
+	closes := []float64{100, 100.5, 100.2, 100.4, 101, 101.6, 101.2, 102.5}
+	restoredMatches(t, func() interface {
+		Strategy
+		Stateful
+	} {
+		m := NewMomentumStrategy(1)
+		m.LookbackBars = 3
+		return m
+	}, closes, 2)
+}
@@ -0,0 +1,184 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"ats/internal/config"
+	"ats/internal/indicator"
+
+	"github.com/shopspring/decimal"
+)
+
+func init() {
+	pairs := NewPairs(0)
+	Register(Definition{
+		Name:        "pairs",
+		Description: "trade the spread of --symbol against --pair-symbol when its z-score around a rolling OLS hedge strays",
+		Params: []ParamSpec{
+			{Name: "lookback", Kind: ParamInt, Default: float64(pairs.Lookback), Min: 10, Description: "bars in the hedge ratio regression and spread z-score"},
+			{Name: "entry_z", Kind: ParamFloat, Default: pairs.EntryZ, Min: 0, Description: "spread z-score that opens a position"},
+			{Name: "exit_z", Kind: ParamFloat, Default: pairs.ExitZ, Min: 0, Description: "z-score within which the position is closed"},
+			{Name: "stop_z", Kind: ParamFloat, Default: pairs.StopZ, Min: 0, Description: "z-score beyond which a position is stopped out (0 = no stop)"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			s := NewPairs(cfg.MaxQty)
+			s.Lookback = params.Int("lookback")
+			s.EntryZ = params.Float("entry_z")
+			s.ExitZ = params.Float("exit_z")
+			s.StopZ = params.Float("stop_z")
+			switch {
+			case cfg.PairSymbol == "":
+				return nil, fmt.Errorf("strategy pairs needs a hedge symbol (--pair-symbol)")
+			case !cfg.AllowShort:
+				return nil, fmt.Errorf("strategy pairs sells one leg short; enable --allow-short")
+			case cfg.TrendTimeframe != "":
+				return nil, fmt.Errorf("strategy pairs does not support --trend-timeframe")
+			case cfg.NotionalOrders:
+				return nil, fmt.Errorf("strategy pairs sizes both legs in shares; --notional-orders is not supported")
+			case s.Lookback > cfg.BarsWindow:
+				return nil, fmt.Errorf("pairs lookback (%d) exceeds bars-window (%d)", s.Lookback, cfg.BarsWindow)
+			case s.ExitZ >= s.EntryZ:
+				return nil, fmt.Errorf("exit_z (%g) must be below entry_z (%g)", s.ExitZ, s.EntryZ)
+			case s.StopZ > 0 && s.StopZ <= s.EntryZ:
+				return nil, fmt.Errorf("stop_z (%g) must be above entry_z (%g)", s.StopZ, s.EntryZ)
+			}
+			return s, nil
+		},
+	})
+}
+
+// PairSnapshot is what a pair strategy sees when both legs have completed
+// a bar for the same time. Y is the primary symbol and X the hedge.
+type PairSnapshot struct {
+	Timestamp time.Time
+	Y, X      PairLeg
+}
+
+// PairLeg is one side of a pair. Closes are aligned bar for bar with the
+// other leg, oldest first.
+type PairLeg struct {
+	Symbol      string
+	Closes      []float64
+	PositionQty decimal.Decimal
+	PendingQty  decimal.Decimal
+	AvgEntry    float64
+}
+
+// PairIntent asks for signed target positions in both legs (Action Target)
+// or leaves them alone (Action Hold). HedgeRatio, Spread and ZScore are
+// recorded with the decision.
+type PairIntent struct {
+	Action     Action
+	Y, X       decimal.Decimal
+	HedgeRatio float64
+	Spread     float64
+	ZScore     float64
+	Reason     string
+}
+
+// PairStrategy is implemented by strategies that trade two symbols as one
+// position. The engine calls DecidePair instead of Decide once per aligned
+// bar and sends both legs together.
+type PairStrategy interface {
+	DecidePair(snapshot PairSnapshot) PairIntent
+}
+
+// Pairs trades mean reversion of the spread y - beta*x, where beta is the
+// slope of an OLS regression of Y's closes on X's over Lookback bars. A
+// z-score above EntryZ sells the spread (short Y, long beta*X), one below
+// -EntryZ buys it; the position is closed once the z-score is back within
+// ExitZ or has run past StopZ. Y is traded in MaxQty shares, scaled down so
+// the hedge leg stays within MaxQty too.
+type Pairs struct {
+	MaxQty   int
+	Lookback int
+	EntryZ   float64
+	ExitZ    float64
+	StopZ    float64
+}
+
+func NewPairs(maxQty int) *Pairs {
+	return &Pairs{MaxQty: maxQty, Lookback: 40, EntryZ: 2, ExitZ: 0.5, StopZ: 4}
+}
+
+// Decide holds: pairs are evaluated on both legs through DecidePair, which
+// the engine only does when a hedge symbol is configured.
+func (p *Pairs) Decide(snapshot MarketSnapshot) TradeIntent {
+	return TradeIntent{Action: Hold, Reason: "pairs_needs_pair_symbol"}
+}
+
+func (p *Pairs) DecidePair(snapshot PairSnapshot) PairIntent {
+	y, x := snapshot.Y.Closes, snapshot.X.Closes
+	alpha, beta, ok := indicator.OLS(x, y, p.Lookback)
+	if !ok {
+		return PairIntent{Action: Hold, Reason: "warming_up"}
+	}
+	intent := PairIntent{Action: Hold, HedgeRatio: beta}
+	residuals := make([]float64, p.Lookback)
+	for i := range residuals {
+		residuals[i] = y[len(y)-p.Lookback+i] - alpha - beta*x[len(x)-p.Lookback+i]
+	}
+	intent.Spread = residuals[len(residuals)-1]
+	std, _ := indicator.StdDev(residuals, p.Lookback)
+	if std == 0 || beta <= 0 {
+		intent.Reason = "no_hedge"
+		return intent
+	}
+	z := intent.Spread / std
+	intent.ZScore = z
+
+	yHeld := snapshot.Y.PositionQty.Add(snapshot.Y.PendingQty)
+	xHeld := snapshot.X.PositionQty.Add(snapshot.X.PendingQty)
+	flat := func(reason string) PairIntent {
+		intent.Action, intent.Y, intent.X, intent.Reason = Target, decimal.Zero, decimal.Zero, reason
+		return intent
+	}
+	switch {
+	case yHeld.IsZero() && xHeld.IsZero():
+		if math.Abs(z) < p.EntryZ {
+			intent.Reason = "spread_within_entry"
+			return intent
+		}
+		yQty, xQty := p.legs(beta)
+		if yQty.IsZero() || xQty.IsZero() {
+			intent.Reason = "hedge_below_one_share"
+			return intent
+		}
+		intent.Action = Target
+		if z > 0 {
+			intent.Y, intent.X, intent.Reason = yQty.Neg(), xQty, fmt.Sprintf("short_spread z=%.2f", z)
+		} else {
+			intent.Y, intent.X, intent.Reason = yQty, xQty.Neg(), fmt.Sprintf("long_spread z=%.2f", z)
+		}
+		return intent
+	case yHeld.IsZero() || xHeld.IsZero() || yHeld.Sign() == xHeld.Sign():
+		// Both legs on the same side, or one missing, is not a hedged
+		// position; the engine unwinds leg failures, this catches the rest.
+		return flat("broken_pair")
+	}
+	// Long the spread means Y was bought because z was below -EntryZ.
+	if yHeld.IsNegative() {
+		z = -z
+	}
+	switch {
+	case z >= -p.ExitZ:
+		return flat(fmt.Sprintf("spread_converged z=%.2f", intent.ZScore))
+	case p.StopZ > 0 && z <= -p.StopZ:
+		return flat(fmt.Sprintf("spread_stop z=%.2f", intent.ZScore))
+	}
+	intent.Reason = "holding_spread"
+	return intent
+}
+
+// legs sizes the pair: MaxQty shares of Y against beta times as many of X,
+// both whole shares and neither above MaxQty.
+func (p *Pairs) legs(beta float64) (decimal.Decimal, decimal.Decimal) {
+	yQty := float64(p.MaxQty)
+	if beta*yQty > float64(p.MaxQty) {
+		yQty = math.Floor(float64(p.MaxQty) / beta)
+	}
+	xQty := math.Min(math.Round(beta*yQty), float64(p.MaxQty))
+	return decimal.NewFromFloat(yQty), decimal.NewFromFloat(xQty)
+}
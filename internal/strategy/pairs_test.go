@@ -0,0 +1,77 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+)
+
+// pairLegs builds x rising by one per bar and y = 2x plus a small
+// alternating residual, with last added to y's final close.
+func pairLegs(n int, last float64) (PairLeg, PairLeg) {
+	y := PairLeg{Symbol: "KO"}
+	x := PairLeg{Symbol: "PEP"}
+	for i := 0; i < n; i++ {
+		xc := 50 + float64(i)
+		residual := 0.1
+		if i%2 == 1 {
+			residual = -0.1
+		}
+		if i == n-1 {
+			residual = last
+		}
+		x.Closes = append(x.Closes, xc)
+		y.Closes = append(y.Closes, 2*xc+residual)
+	}
+	return y, x
+}
+
+func TestPairsEntersAgainstAWideSpreadAndExitsOnConvergence(t *testing.T) {
+	p := NewPairs(10)
+	p.Lookback = 10
+
+	y, x := pairLegs(9, 0)
+	if intent := p.DecidePair(PairSnapshot{Y: y, X: x}); intent.Action != Hold || intent.Reason != "warming_up" {
+		t.Fatalf("expected warm-up hold, got %+v", intent)
+	}
+
+	y, x = pairLegs(12, 1.5)
+	intent := p.DecidePair(PairSnapshot{Y: y, X: x})
+	if intent.Action != Target || !intent.Y.IsNegative() || !intent.X.IsPositive() || !strings.HasPrefix(intent.Reason, "short_spread") {
+		t.Fatalf("expected to sell the spread, got %+v", intent)
+	}
+	if intent.HedgeRatio < 1.9 || intent.HedgeRatio > 2.2 || intent.ZScore < p.EntryZ {
+		t.Fatalf("unexpected hedge %.3f or z %.2f", intent.HedgeRatio, intent.ZScore)
+	}
+	// Y shrinks from MaxQty so that beta times as many X shares fit.
+	if !intent.Y.Equal(Shares(-4)) || !intent.X.Equal(Shares(8)) {
+		t.Fatalf("expected legs -4/8, got %s/%s", intent.Y, intent.X)
+	}
+
+	y, x = pairLegs(12, 0)
+	y.PositionQty, x.PositionQty = intent.Y, intent.X
+	if intent := p.DecidePair(PairSnapshot{Y: y, X: x}); intent.Action != Target || !intent.Y.IsZero() || !intent.X.IsZero() || !strings.HasPrefix(intent.Reason, "spread_converged") {
+		t.Fatalf("expected to close the spread, got %+v", intent)
+	}
+
+	y.PositionQty = Shares(0)
+	if intent := p.DecidePair(PairSnapshot{Y: y, X: x}); intent.Action != Target || intent.Reason != "broken_pair" {
+		t.Fatalf("expected a one-legged position to be closed, got %+v", intent)
+	}
+}
+
+func TestPairsNeedsHedgeSymbolAndShorts(t *testing.T) {
+	cfg := config.Default()
+	if _, err := Build("pairs", cfg, nil); err == nil || !strings.Contains(err.Error(), "--pair-symbol") {
+		t.Fatalf("expected missing hedge symbol error, got %v", err)
+	}
+	cfg.PairSymbol = "PEP"
+	if _, err := Build("pairs", cfg, nil); err == nil || !strings.Contains(err.Error(), "--allow-short") {
+		t.Fatalf("expected short selling error, got %v", err)
+	}
+	cfg.AllowShort = true
+	if _, err := Build("pairs", cfg, map[string]float64{"exit_z": 3}); err == nil {
+		t.Fatalf("expected exit_z above entry_z to be rejected")
+	}
+}
@@ -0,0 +1,179 @@
+package strategy
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"ats/internal/config"
+)
+
+type ParamKind string
+
+const (
+	ParamInt   ParamKind = "int"
+	ParamFloat ParamKind = "float"
+)
+
+// ParamSpec describes one numeric strategy parameter. Min and Max are
+// inclusive; a Max of 0 leaves the value unbounded above.
+type ParamSpec struct {
+	Name        string
+	Kind        ParamKind
+	Default     float64
+	Min         float64
+	Max         float64
+	Description string
+}
+
+// Params are resolved parameter values: every parameter of the schema is
+// present and has been validated.
+type Params map[string]float64
+
+func (p Params) Float(name string) float64 {
+	return p[name]
+}
+
+func (p Params) Int(name string) int {
+	return int(p[name])
+}
+
+// Constructor builds a fresh strategy instance. cfg carries settings shared
+// by all strategies (MaxQty, LLM endpoints, ...).
+type Constructor func(cfg config.Config, params Params) (Strategy, error)
+
+// Definition is a strategy as known to the registry.
+type Definition struct {
+	Name        string
+	Description string
+	Params      []ParamSpec
+	New         Constructor
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]Definition{}
+)
+
+// Register adds a strategy definition. It panics on a missing name or
+// constructor and on duplicates, since those are programming errors.
+func Register(def Definition) {
+	if def.Name == "" || def.New == nil {
+		panic("strategy: Register needs a name and a constructor")
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if _, dup := registry[def.Name]; dup {
+		panic("strategy: Register called twice for " + def.Name)
+	}
+	registry[def.Name] = def
+}
+
+func Lookup(name string) (Definition, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	def, ok := registry[name]
+	return def, ok
+}
+
+// Definitions returns every registered strategy sorted by name.
+func Definitions() []Definition {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	defs := make([]Definition, 0, len(registry))
+	for _, def := range registry {
+		defs = append(defs, def)
+	}
+	sort.Slice(defs, func(i, j int) bool { return defs[i].Name < defs[j].Name })
+	return defs
+}
+
+// Names lists the registered strategies.
+func Names() []string {
+	defs := Definitions()
+	names := make([]string, len(defs))
+	for i, def := range defs {
+		names[i] = def.Name
+	}
+	return names
+}
+
+// Build constructs the named strategy from cfg.StrategyParams with
+// overrides applied on top. Every call returns a new instance, so stateful
+// strategies can run side by side.
+func Build(name string, cfg config.Config, overrides map[string]float64) (Strategy, error) {
+	def, ok := Lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown strategy: %s (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	raw := make(map[string]float64, len(cfg.StrategyParams)+len(overrides))
+	for k, v := range cfg.StrategyParams {
+		raw[k] = v
+	}
+	for k, v := range overrides {
+		raw[k] = v
+	}
+	params, err := def.Resolve(raw)
+	if err != nil {
+		return nil, err
+	}
+	strat, err := def.New(cfg, params)
+	if err != nil {
+		return nil, fmt.Errorf("strategy %s: %w", name, err)
+	}
+	return strat, nil
+}
+
+// Resolve validates raw values against the schema and fills in defaults.
+func (d Definition) Resolve(raw map[string]float64) (Params, error) {
+	for key := range raw {
+		if _, ok := d.param(key); !ok {
+			known := make([]string, len(d.Params))
+			for i, p := range d.Params {
+				known[i] = p.Name
+			}
+			if len(known) == 0 {
+				return nil, fmt.Errorf("strategy %s takes no parameters, got %q", d.Name, key)
+			}
+			return nil, fmt.Errorf("strategy %s has no parameter %q (known: %s)", d.Name, key, strings.Join(known, ", "))
+		}
+	}
+	params := make(Params, len(d.Params))
+	for _, spec := range d.Params {
+		v, ok := raw[spec.Name]
+		if !ok {
+			params[spec.Name] = spec.Default
+			continue
+		}
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil, fmt.Errorf("strategy %s: %s must be finite", d.Name, spec.Name)
+		}
+		if spec.Kind == ParamInt && v != math.Trunc(v) {
+			return nil, fmt.Errorf("strategy %s: %s must be an integer, got %g", d.Name, spec.Name, v)
+		}
+		if v < spec.Min || (spec.Max != 0 && v > spec.Max) {
+			return nil, fmt.Errorf("strategy %s: %s must be in %s, got %g", d.Name, spec.Name, spec.Range(), v)
+		}
+		params[spec.Name] = v
+	}
+	return params, nil
+}
+
+func (d Definition) param(name string) (ParamSpec, bool) {
+	for _, p := range d.Params {
+		if p.Name == name {
+			return p, true
+		}
+	}
+	return ParamSpec{}, false
+}
+
+// Range formats the accepted interval, e.g. "[0, 1]" or "[1, inf)".
+func (p ParamSpec) Range() string {
+	if p.Max == 0 {
+		return fmt.Sprintf("[%g, inf)", p.Min)
+	}
+	return fmt.Sprintf("[%g, %g]", p.Min, p.Max)
+}
@@ -0,0 +1,75 @@
+package strategy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+)
+
+func TestBuildAppliesDefaultsAndOverrides(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxQty = 3
+	cfg.StrategyParams = map[string]float64{"band_pct": 0.05, "min_bars": 4}
+
+	strat, err := Build("mean_reversion", cfg, map[string]float64{"min_bars": 7})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	mr := strat.(MeanReversion)
+	if mr.MaxQty != 3 || mr.BandPct != 0.05 || mr.MinBars != 7 {
+		t.Fatalf("unexpected strategy: %+v", mr)
+	}
+
+	strat, err = Build("scalping", config.Default(), nil)
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	if s := strat.(*ScalpingStrategy); s.MaxHoldBars != NewScalpingStrategy(1).MaxHoldBars {
+		t.Fatalf("expected default max_hold_bars, got %d", s.MaxHoldBars)
+	}
+}
+
+func TestBuildRejectsInvalidParams(t *testing.T) {
+	cases := []struct {
+		name   string
+		params map[string]float64
+		want   string
+	}{
+		{"unknown", map[string]float64{"band": 1}, `no parameter "band"`},
+		{"fractional int", map[string]float64{"min_bars": 2.5}, "must be an integer"},
+		{"out of range", map[string]float64{"band_pct": 2}, "must be in [0, 1]"},
+		{"beyond the window", map[string]float64{"min_bars": 51}, "exceeds bars-window"},
+	}
+	for _, tc := range cases {
+		_, err := Build("mean_reversion", config.Default(), tc.params)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.name, tc.want, err)
+		}
+	}
+
+	if _, err := Build("rsi_mean_reversion", config.Default(), map[string]float64{"oversold": 70}); err == nil {
+		t.Fatalf("expected constructor to reject oversold above overbought")
+	}
+	if _, err := Build("nope", config.Default(), nil); err == nil || !strings.Contains(err.Error(), "unknown strategy") {
+		t.Fatalf("expected unknown strategy error, got %v", err)
+	}
+}
+
+func TestEveryRegisteredStrategyBuildsWithDefaults(t *testing.T) {
+	cfg := config.Default()
+	cfg.LLMModel = "test-model"
+	cfg.Ensemble.Members = []config.EnsembleMember{{Strategy: "sma"}}
+	cfg.Symbol, cfg.PairSymbol, cfg.AllowShort = "KO", "PEP", true
+	cfg.RulesPath = filepath.Join(t.TempDir(), "rules.json")
+	if err := os.WriteFile(cfg.RulesPath, []byte(`{"entry": ["close > sma(5)"], "exit": ["close < sma(5)"]}`), 0o644); err != nil {
+		t.Fatalf("write rules: %v", err)
+	}
+	for _, def := range Definitions() {
+		if _, err := Build(def.Name, cfg, nil); err != nil {
+			t.Fatalf("%s: %v", def.Name, err)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package strategy
+
+import (
+	"fmt"
+
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/rules"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "rules",
+		Description: "entry and exit conditions over indicators, read from the rules file (--rules)",
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			if cfg.RulesPath == "" {
+				return nil, fmt.Errorf("strategy rules needs a rules file (--rules)")
+			}
+			set, err := rules.Load(cfg.RulesPath)
+			if err != nil {
+				return nil, err
+			}
+			return NewRules(set, cfg)
+		},
+	})
+}
+
+// Rules trades the conditions of a rules file. Exits are checked before
+// entries, so a bar that satisfies both leaves the position closed.
+type Rules struct {
+	Set    *rules.Set
+	MaxQty int
+	// frame is the timeframe whose bars the conditions are evaluated over.
+	frame md.Timeframe
+}
+
+// NewRules checks that the configured history covers every condition and
+// that short conditions are only used with short selling enabled.
+func NewRules(set *rules.Set, cfg config.Config) (*Rules, error) {
+	if err := set.CheckLookback(cfg.BarsWindow); err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", cfg.RulesPath, err)
+	}
+	if len(set.Short) > 0 && !cfg.AllowShort {
+		return nil, fmt.Errorf("rules file %s has short conditions but short selling is disabled (--allow-short)", cfg.RulesPath)
+	}
+	frame := set.Timeframe
+	if frame == "" {
+		tf, err := md.ParseTimeframe(cfg.Timeframe)
+		if err != nil {
+			return nil, err
+		}
+		frame = tf
+	}
+	return &Rules{Set: set, MaxQty: cfg.MaxQty, frame: frame}, nil
+}
+
+// Timeframe is the rules file's timeframe; empty defers to the configured one.
+func (r *Rules) Timeframe() md.Timeframe {
+	return r.Set.Timeframe
+}
+
+func (r *Rules) Decide(snapshot MarketSnapshot) TradeIntent {
+	env := rules.Env{
+		Bars:          snapshot.Frames[r.frame].Bars,
+		Position:      snapshot.PositionQty.InexactFloat64(),
+		AvgEntry:      snapshot.AvgEntry,
+		UnrealizedPnL: snapshot.UnrealizedPnL,
+		BarsHeld:      snapshot.BarsSinceEntry,
+	}
+	switch {
+	case snapshot.PositionQty.IsPositive():
+		if c, ok := rules.First(r.Set.Exit, env); ok {
+			return TradeIntent{Action: Sell, Qty: snapshot.PositionQty, Reason: "exit: " + c.Expr.String()}
+		}
+	case snapshot.PositionQty.IsNegative():
+		if c, ok := rules.First(r.Set.Cover, env); ok {
+			return TradeIntent{Action: BuyToCover, Qty: snapshot.PositionQty.Neg(), Reason: "cover: " + c.Expr.String()}
+		}
+	default:
+		if c, ok := rules.First(r.Set.Entry, env); ok {
+			return TradeIntent{Action: Buy, Qty: Shares(r.MaxQty), Strength: Strength(1), Reason: "entry: " + c.Expr.String()}
+		}
+		if c, ok := rules.First(r.Set.Short, env); ok {
+			return TradeIntent{Action: SellShort, Qty: Shares(r.MaxQty), Strength: Strength(1), Reason: "short: " + c.Expr.String()}
+		}
+	}
+	return TradeIntent{Action: Hold, Reason: "no_rule"}
+}
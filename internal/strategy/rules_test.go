@@ -0,0 +1,52 @@
+package strategy
+
+import (
+	"strings"
+	"testing"
+
+	"ats/internal/config"
+	"ats/internal/md"
+	"ats/internal/rules"
+)
+
+func rulesStrategy(t *testing.T, spec rules.Spec, cfg config.Config) (*Rules, error) {
+	t.Helper()
+	set, err := rules.Compile(spec)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	return NewRules(set, cfg)
+}
+
+func TestRulesEntersAndExits(t *testing.T) {
+	cfg := config.Default()
+	cfg.MaxQty = 2
+	s, err := rulesStrategy(t, rules.Spec{Entry: []string{"close > sma(3)"}, Exit: []string{"bars_held >= 2", "close < avg_entry"}}, cfg)
+	if err != nil {
+		t.Fatalf("new: %v", err)
+	}
+	bars := []md.Bar{{Close: 10}, {Close: 11}, {Close: 12}}
+	snapshot := MarketSnapshot{Close: 12, Frames: map[md.Timeframe]Frame{md.Timeframe(cfg.Timeframe): {Bars: bars}}}
+	if intent := s.Decide(snapshot); intent.Action != Buy || !intent.Qty.Equal(Shares(2)) || intent.Reason != "entry: close > sma(3)" {
+		t.Fatalf("expected an entry, got %+v", intent)
+	}
+	snapshot.PositionQty, snapshot.AvgEntry, snapshot.BarsSinceEntry = Shares(2), 12.5, 1
+	if intent := s.Decide(snapshot); intent.Action != Sell || intent.Reason != "exit: close < avg_entry" {
+		t.Fatalf("expected the stop exit, got %+v", intent)
+	}
+	snapshot.AvgEntry = 11
+	if intent := s.Decide(snapshot); intent.Action != Hold {
+		t.Fatalf("expected a hold, got %+v", intent)
+	}
+}
+
+func TestRulesValidateAgainstConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.BarsWindow = 20
+	if _, err := rulesStrategy(t, rules.Spec{Entry: []string{"close > ema(50)"}, Exit: []string{"close < 1"}}, cfg); err == nil || !strings.Contains(err.Error(), `entry[0]: col 9 in "close > ema(50)": needs 50 bars`) {
+		t.Fatalf("expected a lookback error, got %v", err)
+	}
+	if _, err := rulesStrategy(t, rules.Spec{Short: []string{"close < 1"}, Cover: []string{"close > 1"}}, cfg); err == nil || !strings.Contains(err.Error(), "--allow-short") {
+		t.Fatalf("expected short conditions to need allow-short, got %v", err)
+	}
+}
@@ -1,18 +1,48 @@
 package strategy
 
+import (
+	"fmt"
+
+	"ats/internal/config"
+)
+
+func init() {
+	Register(Definition{
+		Name:        "sma",
+		Description: "buy when the close crosses above its SMA (--sma-window), sell below it",
+		Params: []ParamSpec{
+			{Name: "long_short", Kind: ParamInt, Default: 0, Min: 0, Max: 1, Description: "1 reverses into a short below the SMA instead of going flat (needs --allow-short)"},
+		},
+		New: func(cfg config.Config, params Params) (Strategy, error) {
+			longShort := params.Int("long_short") == 1
+			if longShort && !cfg.AllowShort {
+				return nil, fmt.Errorf("long_short requires allow-short")
+			}
+			return SMA{MaxQty: cfg.MaxQty, LongShort: longShort}, nil
+		},
+	})
+}
+
 type SMA struct {
 	MaxQty int
+	// LongShort is always in the market: long above the SMA, short below.
+	// The engine closes the old side before opening the new one.
+	LongShort bool
 }
 
 func (s SMA) Decide(snapshot MarketSnapshot) TradeIntent {
-	if snapshot.PositionQty == 0 && snapshot.Close > snapshot.SMA {
+	if s.LongShort {
+		return s.decideLongShort(snapshot)
+	}
+	if snapshot.PositionQty.IsZero() && snapshot.Close > snapshot.SMA {
 		return TradeIntent{
-			Action: Buy,
-			Qty:    min(s.MaxQty, 1),
-			Reason: "close_above_sma",
+			Action:   Buy,
+			Qty:      Shares(min(s.MaxQty, 1)),
+			Strength: Strength(1),
+			Reason:   "close_above_sma",
 		}
 	}
-	if snapshot.PositionQty > 0 && snapshot.Close < snapshot.SMA {
+	if snapshot.PositionQty.IsPositive() && snapshot.Close < snapshot.SMA {
 		return TradeIntent{
 			Action: Sell,
 			Qty:    snapshot.PositionQty,
@@ -22,6 +52,16 @@ func (s SMA) Decide(snapshot MarketSnapshot) TradeIntent {
 	return TradeIntent{Action: Hold, Reason: "no_signal"}
 }
 
+func (s SMA) decideLongShort(snapshot MarketSnapshot) TradeIntent {
+	switch {
+	case !snapshot.PositionQty.IsPositive() && snapshot.Close > snapshot.SMA:
+		return TradeIntent{Action: Buy, Qty: Shares(min(s.MaxQty, 1)), Strength: Strength(1), Reason: "close_above_sma"}
+	case !snapshot.PositionQty.IsNegative() && snapshot.Close < snapshot.SMA:
+		return TradeIntent{Action: SellShort, Qty: Shares(min(s.MaxQty, 1)), Strength: Strength(1), Reason: "close_below_sma"}
+	}
+	return TradeIntent{Action: Hold, Reason: "no_signal"}
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -5,13 +5,12 @@ import "testing"
 func TestSMABuySignal(t *testing.T) {
 	strat := SMA{MaxQty: 2}
 	snapshot := MarketSnapshot{
-		Close:       101,
-		SMA:         100,
-		PositionQty: 0,
+		Close: 101,
+		SMA:   100,
 	}
 	intent := strat.Decide(snapshot)
-	if intent.Action != Buy || intent.Qty != 1 {
-		t.Fatalf("expected BUY qty=1, got %s qty=%d", intent.Action, intent.Qty)
+	if intent.Action != Buy || !intent.Qty.Equal(Shares(1)) {
+		t.Fatalf("expected BUY qty=1, got %s qty=%s", intent.Action, intent.Qty)
 	}
 }
 
@@ -20,11 +19,11 @@ func TestSMASellSignal(t *testing.T) {
 	snapshot := MarketSnapshot{
 		Close:       99,
 		SMA:         100,
-		PositionQty: 3,
+		PositionQty: Shares(3),
 	}
 	intent := strat.Decide(snapshot)
-	if intent.Action != Sell || intent.Qty != 3 {
-		t.Fatalf("expected SELL qty=3, got %s qty=%d", intent.Action, intent.Qty)
+	if intent.Action != Sell || !intent.Qty.Equal(Shares(3)) {
+		t.Fatalf("expected SELL qty=3, got %s qty=%s", intent.Action, intent.Qty)
 	}
 }
 
@@ -33,10 +32,31 @@ func TestSMAHoldSignal(t *testing.T) {
 	snapshot := MarketSnapshot{
 		Close:       100,
 		SMA:         100,
-		PositionQty: 1,
+		PositionQty: Shares(1),
 	}
 	intent := strat.Decide(snapshot)
 	if intent.Action != Hold {
 		t.Fatalf("expected HOLD, got %s", intent.Action)
 	}
 }
+
+func TestSMALongShortReverses(t *testing.T) {
+	strat := SMA{MaxQty: 2, LongShort: true}
+	cases := []struct {
+		close    float64
+		position int
+		want     Action
+	}{
+		{99, 1, SellShort},
+		{99, 0, SellShort},
+		{99, -1, Hold},
+		{101, -1, Buy},
+		{101, 1, Hold},
+	}
+	for _, c := range cases {
+		intent := strat.Decide(MarketSnapshot{Close: c.close, SMA: 100, PositionQty: Shares(c.position)})
+		if intent.Action != c.want {
+			t.Fatalf("close %v position %d: expected %s, got %s", c.close, c.position, c.want, intent.Action)
+		}
+	}
+}
@@ -1,6 +1,12 @@
 package strategy
 
-import "time"
+import (
+	"time"
+
+	"ats/internal/md"
+
+	"github.com/shopspring/decimal"
+)
 
 type Action string
 
@@ -8,21 +14,175 @@ const (
 	Hold Action = "HOLD"
 	Buy  Action = "BUY"
 	Sell Action = "SELL"
+	// SellShort opens or adds to a short position and BuyToCover closes
+	// one; both require short selling to be enabled. From a long position
+	// SellShort first closes the long (see the engine).
+	SellShort  Action = "SELL_SHORT"
+	BuyToCover Action = "BUY_TO_COVER"
+	// Target asks for a position of TradeIntent.Target shares; the engine
+	// trades the difference from the current position and open orders.
+	Target Action = "TARGET"
 )
 
 type MarketSnapshot struct {
-	Timestamp   time.Time
-	Close       float64
-	SMA         float64
-	PositionQty int
+	Timestamp time.Time
+	Close     float64
+	SMA       float64
+	// PositionQty is signed (negative while short) and may be fractional.
+	PositionQty decimal.Decimal
+	// AvgEntry, UnrealizedPnL, EntryTime and BarsSinceEntry describe the
+	// open position and are zero when flat. BarsSinceEntry counts bars of
+	// the strategy's timeframe completed since the position was opened,
+	// including this one.
+	AvgEntry       float64
+	UnrealizedPnL  float64
+	EntryTime      time.Time
+	BarsSinceEntry int
+	// LastFill is our most recent execution, nil before the first one.
+	LastFill *Fill
+	// OpenOrders are our working orders; PendingQty nets their unfilled
+	// quantity (positive for buys).
+	OpenOrders []OpenOrder
+	PendingQty decimal.Decimal
+	// Bid, Ask, Spread and LastTrade are zero unless quote subscriptions are enabled.
+	Bid       float64
+	Ask       float64
+	Spread    float64
+	LastTrade float64
+	// Frames holds completed bars per requested timeframe, including the
+	// strategy's own. Higher timeframes only contain bars that had closed by
+	// the end of the current bar, so there is no look-ahead.
+	Frames map[md.Timeframe]Frame
+}
+
+// Fill is one of our executions. Qty is positive; Side is "buy" or "sell".
+type Fill struct {
+	Time  time.Time
+	Side  string
+	Qty   decimal.Decimal
+	Price float64
+}
+
+// OpenOrder is a working order. Side is "buy" or "sell".
+type OpenOrder struct {
+	ClientOrderID string
+	Status        string
+	Side          string
+	Qty           decimal.Decimal
+	FilledQty     decimal.Decimal
+}
+
+// Frame is the bar history for one timeframe, oldest first.
+type Frame struct {
+	Timeframe md.Timeframe
+	Bars      []md.Bar
+	SMA       float64 // SMA of closes over the configured window; 0 until ready
+}
+
+func (f Frame) Closes() []float64 {
+	values := make([]float64, len(f.Bars))
+	for i, bar := range f.Bars {
+		values[i] = bar.Close
+	}
+	return values
+}
+
+func (f Frame) Highs() []float64 {
+	values := make([]float64, len(f.Bars))
+	for i, bar := range f.Bars {
+		values[i] = bar.High
+	}
+	return values
+}
+
+func (f Frame) Lows() []float64 {
+	values := make([]float64, len(f.Bars))
+	for i, bar := range f.Bars {
+		values[i] = bar.Low
+	}
+	return values
 }
 
+// Last returns the most recent completed bar.
+func (f Frame) Last() (md.Bar, bool) {
+	if len(f.Bars) == 0 {
+		return md.Bar{}, false
+	}
+	return f.Bars[len(f.Bars)-1], true
+}
+
+// TradeIntent is a strategy's view of what to do. Buys and short sales
+// open Qty shares under the default "strategy" sizing; any other sizing
+// method picks the quantity itself, scaled by Strength in [0, 1] (nil
+// meaning full size, 0 meaning none). Sells and covers close Qty shares,
+// or the whole position when Qty is 0. Target intents name the (signed)
+// position to hold instead and bypass sizing.
 type TradeIntent struct {
-	Action Action
-	Qty    int
-	Reason string
+	Action   Action
+	Qty      decimal.Decimal
+	Target   decimal.Decimal
+	Strength *float64
+	Reason   string
+	// Children holds the member intents behind a composite decision (see
+	// Ensemble); nil for single strategies.
+	Children []ChildIntent
+}
+
+// TargetPosition is an intent to hold qty shares.
+func TargetPosition(qty decimal.Decimal, reason string) TradeIntent {
+	return TradeIntent{Action: Target, Target: qty, Reason: reason}
+}
+
+// Strength is s as an intent strength.
+func Strength(s float64) *float64 {
+	return &s
+}
+
+// Shares is n whole shares as a quantity.
+func Shares(n int) decimal.Decimal {
+	return decimal.NewFromInt(int64(n))
+}
+
+// Resolve turns a Target intent into the Buy, Sell or Hold that reaches it
+// from position; other intents are returned unchanged.
+func (t TradeIntent) Resolve(position decimal.Decimal) TradeIntent {
+	if t.Action != Target {
+		return t
+	}
+	delta := t.Target.Sub(position)
+	switch {
+	case delta.IsPositive():
+		t.Action, t.Qty = Buy, delta
+	case delta.IsNegative():
+		t.Action, t.Qty = Sell, delta.Neg()
+	default:
+		t.Action, t.Qty = Hold, decimal.Zero
+	}
+	return t
 }
 
 type Strategy interface {
 	Decide(snapshot MarketSnapshot) TradeIntent
 }
+
+// TimeframeStrategy is implemented by strategies that operate on aggregated
+// bars. The engine only calls Decide when a bar of that timeframe completes.
+// An empty timeframe defers to the configured one.
+type TimeframeStrategy interface {
+	Timeframe() md.Timeframe
+}
+
+// MultiTimeframeStrategy is implemented by strategies that need bar history
+// from timeframes other than the one they are evaluated on.
+type MultiTimeframeStrategy interface {
+	Timeframes() []md.Timeframe
+}
+
+// Stateful is implemented by strategies that keep state across bars which
+// should survive a restart. The engine saves MarshalState into the
+// checkpoint and hands it back to UnmarshalState before the first bar. A
+// nil state means there is nothing to save.
+type Stateful interface {
+	MarshalState() ([]byte, error)
+	UnmarshalState(data []byte) error
+}
@@ -0,0 +1,64 @@
+package strategy
+
+import "ats/internal/md"
+
+// TrendFilter gates an inner strategy's entries on a higher-timeframe trend:
+// buys, and targets above the current position, only pass while the last
+// completed trend bar closed above its SMA, and short sales only while it
+// closed below. Exits are never blocked.
+type TrendFilter struct {
+	Inner          Strategy
+	TrendTimeframe md.Timeframe
+}
+
+// Timeframe forwards the inner strategy's own timeframe, if it declares one.
+func (t TrendFilter) Timeframe() md.Timeframe {
+	if tf, ok := t.Inner.(TimeframeStrategy); ok {
+		return tf.Timeframe()
+	}
+	return ""
+}
+
+func (t TrendFilter) Timeframes() []md.Timeframe {
+	timeframes := []md.Timeframe{t.TrendTimeframe}
+	if multi, ok := t.Inner.(MultiTimeframeStrategy); ok {
+		timeframes = append(timeframes, multi.Timeframes()...)
+	}
+	return timeframes
+}
+
+// MarshalState forwards to the inner strategy, if it is Stateful.
+func (t TrendFilter) MarshalState() ([]byte, error) {
+	if stateful, ok := t.Inner.(Stateful); ok {
+		return stateful.MarshalState()
+	}
+	return nil, nil
+}
+
+func (t TrendFilter) UnmarshalState(data []byte) error {
+	if stateful, ok := t.Inner.(Stateful); ok {
+		return stateful.UnmarshalState(data)
+	}
+	return nil
+}
+
+func (t TrendFilter) Decide(snapshot MarketSnapshot) TradeIntent {
+	intent := t.Inner.Decide(snapshot)
+	long := intent.Action == Buy || (intent.Action == Target && intent.Target.GreaterThan(snapshot.PositionQty))
+	short := intent.Action == SellShort
+	if !long && !short {
+		return intent
+	}
+	frame, ok := snapshot.Frames[t.TrendTimeframe]
+	if !ok || frame.SMA == 0 {
+		return TradeIntent{Action: Hold, Reason: "trend_not_ready", Children: intent.Children}
+	}
+	last, _ := frame.Last()
+	if long && last.Close <= frame.SMA {
+		return TradeIntent{Action: Hold, Reason: "trend_filter_down", Children: intent.Children}
+	}
+	if short && last.Close >= frame.SMA {
+		return TradeIntent{Action: Hold, Reason: "trend_filter_up", Children: intent.Children}
+	}
+	return intent
+}
@@ -0,0 +1,44 @@
+package strategy
+
+import (
+	"testing"
+
+	"ats/internal/md"
+)
+
+func trendFrame(closes ...float64) Frame {
+	bars := make([]md.Bar, len(closes))
+	sum := 0.0
+	for i, c := range closes {
+		bars[i] = md.Bar{Close: c}
+		sum += c
+	}
+	return Frame{Timeframe: md.OneHour, Bars: bars, SMA: sum / float64(len(closes))}
+}
+
+func TestTrendFilterBlocksBuysInDowntrend(t *testing.T) {
+	filter := TrendFilter{Inner: SMA{MaxQty: 1}, TrendTimeframe: md.OneHour}
+	snapshot := MarketSnapshot{
+		Close:  101,
+		SMA:    100,
+		Frames: map[md.Timeframe]Frame{md.OneHour: trendFrame(110, 105, 100)},
+	}
+
+	if intent := filter.Decide(snapshot); intent.Action != Hold || intent.Reason != "trend_filter_down" {
+		t.Fatalf("expected trend filter hold, got %s %q", intent.Action, intent.Reason)
+	}
+
+	snapshot.Frames[md.OneHour] = trendFrame(100, 105, 110)
+	if intent := filter.Decide(snapshot); intent.Action != Buy {
+		t.Fatalf("expected BUY in uptrend, got %s", intent.Action)
+	}
+}
+
+func TestTrendFilterNeverBlocksExits(t *testing.T) {
+	filter := TrendFilter{Inner: SMA{MaxQty: 1}, TrendTimeframe: md.OneHour}
+	snapshot := MarketSnapshot{Close: 99, SMA: 100, PositionQty: Shares(1)}
+
+	if intent := filter.Decide(snapshot); intent.Action != Sell {
+		t.Fatalf("expected SELL to pass through, got %s", intent.Action)
+	}
+}